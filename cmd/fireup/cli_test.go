@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/panozzaj/fireup/internal/diff"
@@ -337,3 +338,48 @@ func TestFindSimilarNames(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterGroup(t *testing.T) {
+	apps := []AppStatus{
+		{Name: "blog"},
+		{Name: "shop", Group: "work"},
+		{Name: "acme", Group: "work/clients"},
+		{Name: "lib", Group: "workshop"},
+	}
+
+	tests := []struct {
+		group string
+		want  string
+	}{
+		{"work", "shop,acme"},
+		{"work/", "shop,acme"},
+		{"work/clients", "acme"},
+		{"oss", ""},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, app := range filterGroup(apps, tt.group) {
+			names = append(names, app.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("filterGroup(%q) = %q, want %q", tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFileNames(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "blog"), []byte("3000"), 0644)
+	os.WriteFile(filepath.Join(dir, "config-defaults.yml"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(dir, "work"), 0755)
+	os.WriteFile(filepath.Join(dir, "work", ".fireup-group"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "work", "shop.yml"), []byte("cmd: x"), 0644)
+
+	apps, err := configFileNames(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 2 || apps[0].Name != "blog" || apps[1].Name != "shop" || apps[1].Group != "work" {
+		t.Errorf("unexpected apps: %+v", apps)
+	}
+}
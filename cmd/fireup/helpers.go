@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/panozzaj/fireup/internal/process"
 	"github.com/panozzaj/fireup/internal/setup"
 )
 
@@ -71,20 +72,8 @@ func isPfPlistOutdated() bool {
 
 // getProcessOnPort returns the process name listening on a port, or empty string if unknown
 func getProcessOnPort(port int) string {
-	// Try lsof to find the process (works without sudo for processes we own)
-	cmd := exec.Command("lsof", "-i", fmt.Sprintf(":%d", port), "-sTCP:LISTEN", "-n", "-P")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-
-	// Parse lsof output - format: COMMAND PID USER ...
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines[1:] { // Skip header
-		fields := strings.Fields(line)
-		if len(fields) >= 1 {
-			return fields[0] // Return command name
-		}
+	if l, ok := process.ListenerOnPort(port); ok {
+		return l.Command
 	}
 	return ""
 }
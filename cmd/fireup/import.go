@@ -0,0 +1,365 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeService is the subset of a docker-compose service that fireup can run.
+// Fields with several accepted shapes are decoded loosely.
+type composeService struct {
+	Image       string      `yaml:"image"`
+	Command     interface{} `yaml:"command"`     // string or list
+	Ports       []yaml.Node `yaml:"ports"`       // "3000", "3000:3000", or {target: 3000}
+	Environment interface{} `yaml:"environment"` // map or list of KEY=VALUE
+	DependsOn   interface{} `yaml:"depends_on"`  // list or map
+	Build       interface{} `yaml:"build"`       // context path or {context: path}
+}
+
+// cmdImport handles the 'import' command
+func cmdImport(args []string) {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printImportUsage()
+		if len(args) == 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	switch args[0] {
+	case "compose":
+		cmdImportCompose(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown import source: %s\n\n", args[0])
+		printImportUsage()
+		os.Exit(1)
+	}
+}
+
+func printImportUsage() {
+	fmt.Println(`fireup import - Create an app config from another process manager's file
+
+USAGE:
+    fireup import compose [options] <file>
+
+SOURCES:
+    compose    docker-compose.yml services with a command
+
+For Procfiles, reference them directly instead of importing:
+    procfile: Procfile.dev       (in an app's YAML config)`)
+}
+
+// cmdImportCompose converts a docker-compose file into a fireup config
+func cmdImportCompose(args []string) {
+	fs := flag.NewFlagSet("import compose", flag.ExitOnError)
+
+	var (
+		opts initOptions
+		yes  bool
+	)
+
+	fs.StringVar(&opts.Name, "name", "", "App name (default: the compose file's directory name)")
+	fs.StringVar(&opts.ConfigDir, "dir", getDefaultConfigDir(), "Configuration directory")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the generated config without writing it")
+	fs.BoolVar(&opts.Force, "force", false, "Overwrite an existing config file")
+	fs.BoolVar(&yes, "yes", false, "Write the config without prompting (same as FIREUP_YES=1)")
+
+	fs.Usage = func() {
+		fmt.Println(`fireup import compose - Convert docker-compose services into a fireup config
+
+USAGE:
+    fireup import compose [options] <file>
+
+OPTIONS:`)
+		fs.PrintDefaults()
+		fmt.Println(`
+CONVERSION:
+    command       Becomes cmd; the container port is replaced with $PORT
+    ports         The first container port is replaced in the command
+    environment   Becomes env
+    depends_on    Kept for services that are imported
+    build         The build context becomes the service dir
+
+    Services without a command (e.g., databases from an image) are skipped
+    and keep running in Docker. The config is shown as a diff before
+    anything is written.
+
+EXAMPLES:
+    fireup import compose docker-compose.yml
+    fireup import compose --dry-run ~/projects/shop/compose.yml`)
+	}
+
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" || arg == "help" {
+			fs.Usage()
+			os.Exit(0)
+		}
+	}
+
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: fireup import compose [options] <file>\n")
+		os.Exit(1)
+	}
+
+	if yes {
+		os.Setenv("FIREUP_YES", "1")
+	}
+
+	if err := runImportCompose(fs.Arg(0), opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runImportCompose converts file and writes the config via a diff.Plan preview
+func runImportCompose(file string, opts initOptions) error {
+	path, err := filepath.Abs(expandHome(file))
+	if err != nil {
+		return err
+	}
+	root := filepath.Dir(path)
+
+	services, notes, err := parseCompose(path)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+		}
+		return fmt.Errorf("no services with a command found in %s", file)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = appNameFromDir(root)
+	}
+	if name == "" {
+		return fmt.Errorf("could not derive an app name from %s; use --name", root)
+	}
+
+	content := renderConfig("fireup import compose", name, root, services, false)
+
+	if opts.DryRun {
+		fmt.Print(content)
+		return nil
+	}
+
+	configPath := filepath.Join(opts.ConfigDir, name+".yml")
+	if _, err := os.Stat(configPath); err == nil && !opts.Force {
+		return fmt.Errorf("config already exists: %s (use --force to overwrite)", configPath)
+	}
+
+	fmt.Printf("Imported from %s:\n", path)
+	for _, svc := range services {
+		fmt.Printf("  %-12s %s\n", svc.Name, svc.Command)
+	}
+	for _, note := range notes {
+		fmt.Printf("  %sNote: %s%s\n", colorYellow, note, colorReset)
+	}
+
+	return writeGeneratedConfig(configPath, name, content, opts)
+}
+
+// parseCompose reads a compose file and converts services that have a
+// command. Services with ports come first so the first web-facing service
+// becomes the default. Returns the services and notes about skipped ones.
+func parseCompose(path string) ([]detectedService, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var compose struct {
+		Services map[string]composeService `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		services []detectedService
+		notes    []string
+		hasPorts = make(map[string]bool)
+		imported = make(map[string]bool)
+	)
+	for _, name := range names {
+		svc := compose.Services[name]
+		command := composeCommand(svc.Command)
+		if command == "" {
+			if svc.Image != "" {
+				notes = append(notes, fmt.Sprintf("%s: no command (image %s); left to run in Docker", name, svc.Image))
+			} else {
+				notes = append(notes, fmt.Sprintf("%s: no command; left to run in Docker", name))
+			}
+			continue
+		}
+
+		if port := composeContainerPort(svc.Ports); port != "" {
+			hasPorts[slugifyServiceName(name)] = true
+			replaced := regexp.MustCompile(`\b`+port+`\b`).ReplaceAllString(command, "$$PORT")
+			if replaced == command {
+				notes = append(notes, fmt.Sprintf("%s: command does not mention port %s; make sure it listens on $PORT", name, port))
+			}
+			command = replaced
+		}
+
+		services = append(services, detectedService{
+			Name:    slugifyServiceName(name),
+			Command: command,
+			Source:  filepath.Base(path),
+			Dir:     composeBuildDir(svc.Build),
+			Env:     composeEnv(svc.Environment),
+		})
+		imported[name] = true
+	}
+
+	// Keep dependencies that were imported; the rest stay in Docker
+	for i, name := range importedNames(names, imported) {
+		for _, dep := range composeList(compose.Services[name].DependsOn) {
+			if imported[dep] {
+				services[i].DependsOn = append(services[i].DependsOn, slugifyServiceName(dep))
+			}
+		}
+	}
+
+	sort.SliceStable(services, func(i, j int) bool {
+		return hasPorts[services[i].Name] && !hasPorts[services[j].Name]
+	})
+	return services, notes, nil
+}
+
+// importedNames returns names that were imported, in order
+func importedNames(names []string, imported map[string]bool) []string {
+	var result []string
+	for _, name := range names {
+		if imported[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// slugifyServiceName makes a compose service name safe for a subdomain
+func slugifyServiceName(name string) string {
+	slug := invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}
+
+// composeCommand returns the command as a shell string
+func composeCommand(v interface{}) string {
+	switch cmd := v.(type) {
+	case string:
+		return strings.TrimSpace(cmd)
+	case []interface{}:
+		var parts []string
+		for _, part := range cmd {
+			s := fmt.Sprint(part)
+			if s == "" || strings.ContainsAny(s, " \t'\"$`\\|&;<>()*?") {
+				s = "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+// composeContainerPort returns the container side of the first port mapping
+func composeContainerPort(ports []yaml.Node) string {
+	for _, node := range ports {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			// "3000", "8080:3000", "127.0.0.1:8080:3000", "3000/tcp"
+			spec := strings.SplitN(node.Value, "/", 2)[0]
+			parts := strings.Split(spec, ":")
+			port := parts[len(parts)-1]
+			if _, err := strconv.Atoi(port); err == nil {
+				return port
+			}
+		case yaml.MappingNode:
+			var long struct {
+				Target int `yaml:"target"`
+			}
+			if node.Decode(&long) == nil && long.Target > 0 {
+				return strconv.Itoa(long.Target)
+			}
+		}
+	}
+	return ""
+}
+
+// composeEnv converts environment (map or KEY=VALUE list) to a map
+func composeEnv(v interface{}) map[string]string {
+	env := make(map[string]string)
+	switch e := v.(type) {
+	case map[string]interface{}:
+		for k, val := range e {
+			if val == nil {
+				continue // Passed through from the host environment
+			}
+			env[k] = fmt.Sprint(val)
+		}
+	case []interface{}:
+		for _, item := range e {
+			if k, val, ok := strings.Cut(fmt.Sprint(item), "="); ok {
+				env[k] = val
+			}
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// composeList converts a list or map (long depends_on syntax) to names
+func composeList(v interface{}) []string {
+	var names []string
+	switch l := v.(type) {
+	case []interface{}:
+		for _, item := range l {
+			names = append(names, fmt.Sprint(item))
+		}
+	case map[string]interface{}:
+		for name := range l {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	return names
+}
+
+// composeBuildDir returns the build context as a directory relative to the compose file
+func composeBuildDir(v interface{}) string {
+	var context string
+	switch b := v.(type) {
+	case string:
+		context = b
+	case map[string]interface{}:
+		if c, ok := b["context"].(string); ok {
+			context = c
+		}
+	}
+	context = filepath.Clean(context)
+	if context == "." || filepath.IsAbs(context) || strings.HasPrefix(context, "..") {
+		return ""
+	}
+	return context
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseCompose(t *testing.T) {
+	root := t.TempDir()
+	writeProjectFile(t, root, "docker-compose.yml", `
+services:
+  db:
+    image: postgres:16
+    ports: ["5432:5432"]
+  worker:
+    build: .
+    command: bundle exec sidekiq
+    depends_on: [db, api]
+    environment:
+      - QUEUE=default
+  api:
+    build:
+      context: ./api
+    command: ["bin/rails", "server", "-p", "3000", "-b", "0.0.0.0"]
+    ports:
+      - "127.0.0.1:8080:3000"
+    environment:
+      RAILS_ENV: development
+      SECRET:
+    depends_on:
+      db:
+        condition: service_healthy
+`)
+
+	services, notes, err := parseCompose(root + "/docker-compose.yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected api and worker, got %+v", services)
+	}
+
+	api := services[0]
+	if api.Name != "api" {
+		t.Fatalf("expected service with ports first, got %q", api.Name)
+	}
+	if api.Command != "bin/rails server -p $PORT -b 0.0.0.0" {
+		t.Errorf("unexpected api command: %q", api.Command)
+	}
+	if api.Dir != "api" {
+		t.Errorf("unexpected api dir: %q", api.Dir)
+	}
+	if api.Env["RAILS_ENV"] != "development" {
+		t.Errorf("unexpected api env: %v", api.Env)
+	}
+	if _, ok := api.Env["SECRET"]; ok {
+		t.Error("pass-through variables without a value should be skipped")
+	}
+	if len(api.DependsOn) != 0 {
+		t.Errorf("dependencies left in Docker should be dropped, got %v", api.DependsOn)
+	}
+
+	worker := services[1]
+	if worker.Dir != "" || worker.Env["QUEUE"] != "default" {
+		t.Errorf("unexpected worker: %+v", worker)
+	}
+	if strings.Join(worker.DependsOn, ",") != "api" {
+		t.Errorf("expected worker to depend on api only, got %v", worker.DependsOn)
+	}
+
+	if len(notes) != 1 || !strings.Contains(notes[0], "db") {
+		t.Errorf("expected a note about db, got %v", notes)
+	}
+
+	// The generated config should round-trip through YAML
+	out := renderConfig("fireup import compose", "shop", root, services, false)
+	var parsed struct {
+		Services map[string]struct {
+			Command   string            `yaml:"cmd"`
+			Dir       string            `yaml:"dir"`
+			Default   bool              `yaml:"default"`
+			DependsOn []string          `yaml:"depends_on"`
+			Env       map[string]string `yaml:"env"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("generated YAML does not parse: %v\n%s", err, out)
+	}
+	if !parsed.Services["api"].Default || parsed.Services["api"].Dir != "api" {
+		t.Errorf("unexpected api config: %+v\n%s", parsed.Services["api"], out)
+	}
+	if parsed.Services["worker"].DependsOn[0] != "api" {
+		t.Errorf("unexpected worker config: %+v\n%s", parsed.Services["worker"], out)
+	}
+}
+
+func TestComposeContainerPort(t *testing.T) {
+	tests := []struct {
+		ports string
+		want  string
+	}{
+		{`["3000"]`, "3000"},
+		{`["8080:3000"]`, "3000"},
+		{`["127.0.0.1:8080:3000/tcp"]`, "3000"},
+		{`[{target: 4000, published: 80}]`, "4000"},
+		{`[]`, ""},
+	}
+	for _, tt := range tests {
+		var ports []yaml.Node
+		if err := yaml.Unmarshal([]byte(tt.ports), &ports); err != nil {
+			t.Fatal(err)
+		}
+		if got := composeContainerPort(ports); got != tt.want {
+			t.Errorf("composeContainerPort(%s) = %q, want %q", tt.ports, got, tt.want)
+		}
+	}
+}
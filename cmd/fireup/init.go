@@ -0,0 +1,465 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/diff"
+)
+
+// detectedService is a service proposed by project detection
+type detectedService struct {
+	Name      string
+	Command   string
+	Source    string // File that triggered the detection (for display)
+	Dir       string // Working directory relative to root (optional)
+	Env       map[string]string
+	DependsOn []string
+}
+
+// initOptions controls how 'fireup init' and 'fireup add' behave
+type initOptions struct {
+	Name      string
+	ConfigDir string
+	DryRun    bool // Print the generated YAML instead of writing it
+	Force     bool // Overwrite an existing config file
+}
+
+// cmdInit handles the 'init' command (detect stack in the current directory)
+func cmdInit(args []string) {
+	runInitCommand("init", args)
+}
+
+// cmdAdd handles the 'add' command (detect stack in the given directory)
+func cmdAdd(args []string) {
+	runInitCommand("add", args)
+}
+
+// runInitCommand parses flags shared by 'init' and 'add' and runs detection
+func runInitCommand(command string, args []string) {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+
+	var (
+		opts initOptions
+		yes  bool
+	)
+
+	fs.StringVar(&opts.Name, "name", "", "App name (default: directory name)")
+	fs.StringVar(&opts.ConfigDir, "dir", getDefaultConfigDir(), "Configuration directory")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the generated config without writing it")
+	fs.BoolVar(&opts.Force, "force", false, "Overwrite an existing config file")
+	fs.BoolVar(&yes, "yes", false, "Write the config without prompting (same as FIREUP_YES=1)")
+
+	fs.Usage = func() {
+		if command == "add" {
+			fmt.Println(`fireup add - Detect a project's stack and create a config for it
+
+USAGE:
+    fireup add [options] <project-dir>
+
+OPTIONS:`)
+		} else {
+			fmt.Println(`fireup init - Detect this project's stack and create a config for it
+
+USAGE:
+    fireup init [options]
+
+OPTIONS:`)
+		}
+		fs.PrintDefaults()
+		fmt.Println(`
+DETECTS:
+    Procfile.dev, Procfile    One service per process line
+    bin/rails                 bin/rails server -p $PORT
+    config.ru                 bundle exec rackup -p $PORT
+    manage.py                 python manage.py runserver 127.0.0.1:$PORT
+    mix.exs                   mix phx.server (Phoenix) or mix run
+    go.mod                    go run .
+    Cargo.toml                cargo run
+    package.json              dev/start script (vite/next get --port)
+    index.html                static: true
+
+    The generated config is shown as a diff before anything is written.
+
+EXAMPLES:
+    fireup init                       # Detect the current directory
+    fireup init --dry-run             # Print the config to stdout
+    fireup init --yes --name shop     # Write without prompting
+    fireup add ~/projects/blog        # Detect another directory`)
+	}
+
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" || arg == "help" {
+			fs.Usage()
+			os.Exit(0)
+		}
+	}
+
+	fs.Parse(args)
+
+	var root string
+	if command == "add" {
+		if fs.NArg() < 1 {
+			fmt.Fprintf(os.Stderr, "Usage: fireup add [options] <project-dir>\n")
+			os.Exit(1)
+		}
+		root = fs.Arg(0)
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		root = cwd
+	}
+
+	if yes {
+		os.Setenv("FIREUP_YES", "1")
+	}
+
+	if err := runInit(root, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runInit detects services in root and writes a config via a diff.Plan preview
+func runInit(root string, opts initOptions) error {
+	root, err := filepath.Abs(expandHome(root))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("project directory not found: %s", root)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", root)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = appNameFromDir(root)
+	}
+	if name == "" {
+		return fmt.Errorf("could not derive an app name from %s; use --name", root)
+	}
+
+	services, static, notes := detectProject(root)
+	if len(services) == 0 && !static {
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "Note: %s\n", note)
+		}
+		return fmt.Errorf("no supported project files found in %s", root)
+	}
+
+	content := renderInitConfig(name, root, services, static)
+
+	if opts.DryRun {
+		fmt.Print(content)
+		return nil
+	}
+
+	configPath := filepath.Join(opts.ConfigDir, name+".yml")
+	if _, err := os.Stat(configPath); err == nil && !opts.Force {
+		return fmt.Errorf("config already exists: %s (use --force to overwrite)", configPath)
+	}
+
+	fmt.Printf("Detected in %s:\n", root)
+	if static {
+		fmt.Println("  static site (index.html)")
+	}
+	for _, svc := range services {
+		fmt.Printf("  %-12s %s  %s(%s)%s\n", svc.Name, svc.Command, colorGray, svc.Source, colorReset)
+	}
+	for _, note := range notes {
+		fmt.Printf("  %sNote: %s%s\n", colorYellow, note, colorReset)
+	}
+
+	return writeGeneratedConfig(configPath, name, content, opts)
+}
+
+// writeGeneratedConfig writes a generated app config after showing a diff.Plan preview
+func writeGeneratedConfig(configPath, name, content string, opts initOptions) error {
+	if err := os.MkdirAll(opts.ConfigDir, 0755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	plan := diff.NewPlan()
+	plan.CreateStatic(configPath, content)
+	if !confirmWithPlan(plan, fmt.Sprintf("Write %s?", configPath)) {
+		return fmt.Errorf("cancelled")
+	}
+	if err := plan.Execute(); err != nil {
+		return err
+	}
+
+	globalCfg, _ := getConfigWithDefaults()
+	fmt.Printf("%s✓ Created %s%s\n", colorGreen, configPath, colorReset)
+	fmt.Printf("  Visit http://%s.%s\n", name, globalCfg.TLD)
+	return nil
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// invalidNameChars matches characters that aren't safe in a hostname label
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// appNameFromDir derives a hostname-safe app name from a directory path
+func appNameFromDir(dir string) string {
+	name := strings.ToLower(filepath.Base(dir))
+	name = invalidNameChars.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
+
+// detectProject inspects root and proposes services.
+// Returns the services, whether the directory looks like a plain static site,
+// and notes about files that were found but not converted.
+func detectProject(root string) ([]detectedService, bool, []string) {
+	var notes []string
+
+	for _, compose := range []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"} {
+		if fileExists(filepath.Join(root, compose)) {
+			notes = append(notes, fmt.Sprintf("found %s; run 'fireup import compose %s' to convert its services", compose, compose))
+			break
+		}
+	}
+
+	// A Procfile is the project's own declaration of its processes, so prefer it
+	for _, procfile := range []string{"Procfile.dev", "Procfile"} {
+		path := filepath.Join(root, procfile)
+		if !fileExists(path) {
+			continue
+		}
+		services, err := parseProcfile(path)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("could not read %s: %v", procfile, err))
+			continue
+		}
+		if len(services) > 0 {
+			return services, false, notes
+		}
+	}
+
+	var services []detectedService
+	taken := func(name string) bool {
+		for _, existing := range services {
+			if existing.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	// The first service is "web"; the others are named after their stack,
+	// with a number if that's taken too
+	add := func(svc detectedService, stack string) {
+		if taken(svc.Name) {
+			svc.Name = stack
+			for i := 2; taken(svc.Name); i++ {
+				svc.Name = fmt.Sprintf("%s-%d", stack, i)
+			}
+		}
+		services = append(services, svc)
+	}
+
+	switch {
+	case fileExists(filepath.Join(root, "bin", "rails")):
+		add(detectedService{Name: "web", Command: "bin/rails server -p $PORT -b 127.0.0.1", Source: "bin/rails"}, "rails")
+	case fileExists(filepath.Join(root, "config.ru")):
+		add(detectedService{Name: "web", Command: "bundle exec rackup -p $PORT -o 127.0.0.1", Source: "config.ru"}, "rack")
+	}
+
+	if fileExists(filepath.Join(root, "manage.py")) {
+		add(detectedService{Name: "web", Command: "python manage.py runserver 127.0.0.1:$PORT", Source: "manage.py"}, "django")
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "mix.exs")); err == nil {
+		if strings.Contains(string(data), ":phoenix") {
+			add(detectedService{Name: "web", Command: "mix phx.server", Source: "mix.exs"}, "phoenix")
+		} else {
+			add(detectedService{Name: "web", Command: "mix run --no-halt", Source: "mix.exs"}, "elixir")
+		}
+	}
+
+	if fileExists(filepath.Join(root, "go.mod")) {
+		add(detectedService{Name: "web", Command: "go run .", Source: "go.mod"}, "go")
+	}
+
+	if fileExists(filepath.Join(root, "Cargo.toml")) {
+		add(detectedService{Name: "web", Command: "cargo run", Source: "Cargo.toml"}, "rust")
+	}
+
+	if svc, ok := detectNodeService(root); ok {
+		add(svc, "node")
+	}
+
+	static := len(services) == 0 && fileExists(filepath.Join(root, "index.html"))
+	return services, static, notes
+}
+
+// detectNodeService proposes a service from package.json scripts
+func detectNodeService(root string) (detectedService, bool) {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return detectedService{}, false
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return detectedService{}, false
+	}
+
+	var script string
+	for _, candidate := range []string{"dev", "start", "serve"} {
+		if _, ok := pkg.Scripts[candidate]; ok {
+			script = candidate
+			break
+		}
+	}
+	if script == "" {
+		return detectedService{}, false
+	}
+
+	runner := "npm run"
+	switch {
+	case fileExists(filepath.Join(root, "pnpm-lock.yaml")):
+		runner = "pnpm run"
+	case fileExists(filepath.Join(root, "yarn.lock")):
+		runner = "yarn run"
+	case fileExists(filepath.Join(root, "bun.lockb")), fileExists(filepath.Join(root, "bun.lock")):
+		runner = "bun run"
+	}
+
+	// Some dev servers ignore $PORT and need it passed explicitly
+	cmd := runner + " " + script
+	body := pkg.Scripts[script]
+	switch {
+	case strings.Contains(body, "vite"):
+		cmd += " -- --port $PORT --strictPort"
+	case strings.Contains(body, "next "), strings.HasSuffix(body, "next"):
+		cmd += " -- -p $PORT"
+	}
+
+	return detectedService{Name: "web", Command: cmd, Source: "package.json"}, true
+}
+
+// parseProcfile reads "name: command" lines from a Procfile
+func parseProcfile(path string) ([]detectedService, error) {
+	entries, err := config.ParseProcfile(path)
+	if err != nil {
+		return nil, err
+	}
+	source := filepath.Base(path)
+	var services []detectedService
+	for _, entry := range entries {
+		services = append(services, detectedService{Name: entry.Name, Command: entry.Command, Source: source})
+	}
+	return services, nil
+}
+
+// fileExists returns true if path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// renderInitConfig produces the YAML config for the detected services
+func renderInitConfig(name, root string, services []detectedService, static bool) string {
+	return renderConfig("fireup init", name, root, services, static)
+}
+
+// renderConfig produces a YAML app config. generator names the command
+// that created it, for the header comment.
+func renderConfig(generator, name, root string, services []detectedService, static bool) string {
+	var sb strings.Builder
+
+	displayRoot := root
+	if home, err := os.UserHomeDir(); err == nil && home != "" && isSubdir(root, home) {
+		displayRoot = "~" + root[len(home):]
+	}
+
+	sb.WriteString(fmt.Sprintf("# Generated by %s\nname: %s\nroot: %s\n", generator, name, yamlString(displayRoot)))
+
+	if static {
+		sb.WriteString("static: true\n")
+		return sb.String()
+	}
+
+	if len(services) == 1 && services[0].Dir == "" {
+		sb.WriteString(fmt.Sprintf("cmd: %s\n", yamlString(services[0].Command)))
+		writeEnv(&sb, "", services[0].Env)
+		return sb.String()
+	}
+
+	// Prefer a service named "web" as the default, otherwise the first one
+	defaultName := services[0].Name
+	for _, svc := range services {
+		if svc.Name == "web" {
+			defaultName = "web"
+			break
+		}
+	}
+
+	sorted := make([]detectedService, len(services))
+	copy(sorted, services)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name == defaultName && sorted[j].Name != defaultName
+	})
+
+	sb.WriteString("services:\n")
+	for _, svc := range sorted {
+		sb.WriteString(fmt.Sprintf("  %s:\n    cmd: %s\n", svc.Name, yamlString(svc.Command)))
+		if svc.Dir != "" {
+			sb.WriteString(fmt.Sprintf("    dir: %s\n", yamlString(svc.Dir)))
+		}
+		if svc.Name == defaultName && len(services) > 1 {
+			sb.WriteString("    default: true\n")
+		}
+		if len(svc.DependsOn) > 0 {
+			sb.WriteString(fmt.Sprintf("    depends_on: [%s]\n", strings.Join(svc.DependsOn, ", ")))
+		}
+		writeEnv(&sb, "    ", svc.Env)
+	}
+	return sb.String()
+}
+
+// writeEnv writes an env: block with sorted keys at the given indent
+func writeEnv(sb *strings.Builder, indent string, env map[string]string) {
+	if len(env) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb.WriteString(indent + "env:\n")
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("%s  %s: %s\n", indent, k, yamlString(env[k])))
+	}
+}
+
+// yamlString quotes a scalar if it contains characters YAML would misread
+func yamlString(s string) string {
+	if s == "" || strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`") || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?") {
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+	return s
+}
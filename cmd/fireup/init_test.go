@@ -0,0 +1,207 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeProjectFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectProject(t *testing.T) {
+	t.Run("rails app", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "Gemfile", "gem 'rails'")
+		writeProjectFile(t, root, "bin/rails", "#!/usr/bin/env ruby")
+
+		services, static, _ := detectProject(root)
+		if static {
+			t.Error("expected non-static project")
+		}
+		if len(services) != 1 || !strings.Contains(services[0].Command, "bin/rails server -p $PORT") {
+			t.Errorf("unexpected services: %+v", services)
+		}
+	})
+
+	t.Run("procfile takes precedence", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "bin/rails", "")
+		writeProjectFile(t, root, "Procfile.dev", "# comment\nweb: bin/rails s -p $PORT\ncss: bin/rails tailwindcss:watch\n")
+
+		services, _, _ := detectProject(root)
+		if len(services) != 2 {
+			t.Fatalf("expected 2 services from Procfile.dev, got %+v", services)
+		}
+		if services[0].Name != "web" || services[1].Name != "css" {
+			t.Errorf("unexpected service names: %+v", services)
+		}
+		if services[1].Command != "bin/rails tailwindcss:watch" {
+			t.Errorf("command with colon not preserved: %q", services[1].Command)
+		}
+	})
+
+	t.Run("vite dev script gets explicit port", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "package.json", `{"scripts":{"dev":"vite","build":"vite build"}}`)
+		writeProjectFile(t, root, "pnpm-lock.yaml", "")
+
+		services, _, _ := detectProject(root)
+		if len(services) != 1 {
+			t.Fatalf("expected 1 service, got %+v", services)
+		}
+		if services[0].Command != "pnpm run dev -- --port $PORT --strictPort" {
+			t.Errorf("unexpected command: %q", services[0].Command)
+		}
+	})
+
+	t.Run("backend plus node frontend", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "manage.py", "")
+		writeProjectFile(t, root, "package.json", `{"scripts":{"start":"react-scripts start"}}`)
+
+		services, _, _ := detectProject(root)
+		if len(services) != 2 {
+			t.Fatalf("expected 2 services, got %+v", services)
+		}
+		if services[0].Name != "web" || services[1].Name != "node" {
+			t.Errorf("unexpected service names: %+v", services)
+		}
+	})
+
+	t.Run("three stacks get unique names", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "bin/rails", "")
+		writeProjectFile(t, root, "manage.py", "")
+		writeProjectFile(t, root, "package.json", `{"scripts":{"dev":"vite"}}`)
+
+		services, _, _ := detectProject(root)
+		var names []string
+		for _, svc := range services {
+			names = append(names, svc.Name)
+		}
+		if got := strings.Join(names, " "); got != "web django node" {
+			t.Errorf("expected web django node, got %s", got)
+		}
+		if services[1].Source != "manage.py" {
+			t.Errorf("expected django to come from manage.py, got %+v", services[1])
+		}
+	})
+
+	t.Run("static site", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "index.html", "<h1>hi</h1>")
+
+		services, static, _ := detectProject(root)
+		if !static || len(services) != 0 {
+			t.Errorf("expected static site, got static=%v services=%+v", static, services)
+		}
+	})
+
+	t.Run("compose file produces a note", func(t *testing.T) {
+		root := t.TempDir()
+		writeProjectFile(t, root, "docker-compose.yml", "services: {}")
+
+		services, static, notes := detectProject(root)
+		if len(services) != 0 || static {
+			t.Errorf("expected nothing detected, got %+v", services)
+		}
+		if len(notes) != 1 || !strings.Contains(notes[0], "docker-compose.yml") {
+			t.Errorf("expected compose note, got %v", notes)
+		}
+	})
+}
+
+func TestRenderInitConfig(t *testing.T) {
+	t.Run("single service uses cmd shorthand", func(t *testing.T) {
+		out := renderInitConfig("blog", "/srv/blog", []detectedService{
+			{Name: "web", Command: "python manage.py runserver 127.0.0.1:$PORT"},
+		}, false)
+
+		var parsed struct {
+			Name    string `yaml:"name"`
+			Root    string `yaml:"root"`
+			Command string `yaml:"cmd"`
+		}
+		if err := yaml.Unmarshal([]byte(out), &parsed); err != nil {
+			t.Fatalf("generated YAML does not parse: %v\n%s", err, out)
+		}
+		if parsed.Name != "blog" || parsed.Root != "/srv/blog" {
+			t.Errorf("unexpected name/root: %+v", parsed)
+		}
+		if parsed.Command != "python manage.py runserver 127.0.0.1:$PORT" {
+			t.Errorf("unexpected cmd: %q", parsed.Command)
+		}
+	})
+
+	t.Run("multi-service marks web as default", func(t *testing.T) {
+		out := renderInitConfig("shop", "/srv/shop", []detectedService{
+			{Name: "worker", Command: "bundle exec sidekiq"},
+			{Name: "web", Command: "bin/rails s -p $PORT"},
+		}, false)
+
+		var parsed struct {
+			Services map[string]struct {
+				Command string `yaml:"cmd"`
+				Default bool   `yaml:"default"`
+			} `yaml:"services"`
+		}
+		if err := yaml.Unmarshal([]byte(out), &parsed); err != nil {
+			t.Fatalf("generated YAML does not parse: %v\n%s", err, out)
+		}
+		if !parsed.Services["web"].Default || parsed.Services["worker"].Default {
+			t.Errorf("expected only web to be default: %+v", parsed.Services)
+		}
+	})
+}
+
+func TestAppNameFromDir(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"/home/me/projects/MyApp", "myapp"},
+		{"/home/me/projects/my_app", "my-app"},
+		{"/home/me/projects/my app.v2", "my-app-v2"},
+	}
+	for _, tt := range tests {
+		if got := appNameFromDir(tt.dir); got != tt.want {
+			t.Errorf("appNameFromDir(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestRunInitDryRunAndWrite(t *testing.T) {
+	root := t.TempDir()
+	configDir := t.TempDir()
+	writeProjectFile(t, root, "go.mod", "module example.com/demo")
+
+	t.Setenv("FIREUP_YES", "1")
+	if err := runInit(root, initOptions{Name: "demo", ConfigDir: configDir}); err != nil {
+		t.Fatalf("runInit failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "demo.yml"))
+	if err != nil {
+		t.Fatalf("config not written: %v", err)
+	}
+	if !strings.Contains(string(data), "cmd: go run .") {
+		t.Errorf("unexpected config:\n%s", data)
+	}
+
+	// Writing again without --force should refuse to overwrite
+	if err := runInit(root, initOptions{Name: "demo", ConfigDir: configDir}); err == nil {
+		t.Error("expected error when config already exists")
+	}
+}
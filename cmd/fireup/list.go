@@ -3,7 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/panozzaj/fireup/internal/config"
 )
 
 // AppStatus represents the status of a single app from the API
@@ -17,6 +21,12 @@ type AppStatus struct {
 	Port        int         `json:"port,omitempty"`
 	Uptime      string      `json:"uptime,omitempty"`
 	Services    []SvcStatus `json:"services,omitempty"`
+	Profile     string      `json:"profile,omitempty"`
+	Profiles    []string    `json:"profiles,omitempty"`
+	Group       string      `json:"group,omitempty"`
+	Shared      bool        `json:"shared,omitempty"`
+	HeldBy      []string    `json:"held_by,omitempty"`
+	Paused      bool        `json:"paused,omitempty"`
 }
 
 // SvcStatus represents the status of a service within a multi-service app
@@ -27,6 +37,20 @@ type SvcStatus struct {
 	Uptime  string `json:"uptime,omitempty"`
 	URL     string `json:"url"`
 	Default bool   `json:"default,omitempty"`
+	Paused  bool   `json:"paused,omitempty"`
+
+	Instances []InstanceStatus `json:"instances,omitempty"` // Of a scaled service
+	TCP       int              `json:"tcp,omitempty"`       // Stable local TCP port
+}
+
+// InstanceStatus represents the status of one instance of a scaled service
+type InstanceStatus struct {
+	Name     string `json:"name"`
+	Running  bool   `json:"running"`
+	Starting bool   `json:"starting,omitempty"`
+	Failed   bool   `json:"failed,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Paused   bool   `json:"paused,omitempty"`
 }
 
 // cmdList handles the 'list' command (alias for status)
@@ -34,7 +58,7 @@ func cmdList(args []string) {
 	if checkHelpFlag(args, `fireup list - List configured apps and their status
 
 USAGE:
-    fireup list [--json]
+    fireup list [--json] [--group <name>]
 
 This command is an alias for 'fireup status'.
 Shows all configured apps, their running status, and URLs.
@@ -46,8 +70,10 @@ Use --json for machine-readable output.`) {
 	cmdStatus(args)
 }
 
-func listConfigFiles(configDir, tld string) error {
-	entries, err := os.ReadDir(configDir)
+// listConfigFiles lists the apps in configDir and its group directories
+// when the server is not running. group limits the list to one group.
+func listConfigFiles(configDir, tld, group string) error {
+	apps, err := configFileNames(configDir, "")
 	if err != nil {
 		if os.IsNotExist(err) {
 			fmt.Println("No apps configured.")
@@ -56,38 +82,87 @@ func listConfigFiles(configDir, tld string) error {
 		}
 		return err
 	}
+	if group != "" {
+		apps = filterGroup(apps, group)
+	}
+
+	if len(apps) == 0 {
+		if group != "" {
+			fmt.Printf("No apps in group %q.\n", group)
+			return nil
+		}
+		fmt.Println("No apps configured.")
+		fmt.Printf("Add configs to %s\n", configDir)
+		return nil
+	}
 
-	var apps []string
+	fmt.Println("Configured apps (server not running):")
+	fmt.Printf("%-20s %s\n", "APP", "URL")
+	fmt.Printf("%-20s %s\n", "---", "---")
+	for _, app := range apps {
+		url := fmt.Sprintf("http://%s.%s", app.Name, tld)
+		name := app.Name
+		if app.Group != "" {
+			name = app.Group + "/" + app.Name
+		}
+		fmt.Printf("%-20s %s\n", name, url)
+	}
+	fmt.Println("\nStart the server with: fireup serve")
+
+	return nil
+}
+
+// configFileNames returns the apps configured in dir, descending into group
+// directories (those with a .fireup-group marker)
+func configFileNames(dir, group string) ([]AppStatus, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []AppStatus
 	for _, entry := range entries {
 		name := entry.Name()
 		// Skip hidden files, config files, and directories
-		if strings.HasPrefix(name, ".") || name == "config.json" || name == "config-theme.json" {
+		if strings.HasPrefix(name, ".") || name == "config.json" || strings.HasPrefix(name, "config-") {
 			continue
 		}
 		// Skip certs directory
 		if entry.IsDir() && name == "certs" {
 			continue
 		}
+		if entry.IsDir() && config.IsGroupDir(filepath.Join(dir, name)) {
+			sub, err := configFileNames(filepath.Join(dir, name), filepath.ToSlash(filepath.Join(group, name)))
+			if err != nil {
+				return nil, err
+			}
+			apps = append(apps, sub...)
+			continue
+		}
 		// Remove .yml/.yaml extension for display
 		name = strings.TrimSuffix(name, ".yml")
 		name = strings.TrimSuffix(name, ".yaml")
-		apps = append(apps, name)
-	}
-
-	if len(apps) == 0 {
-		fmt.Println("No apps configured.")
-		fmt.Printf("Add configs to %s\n", configDir)
-		return nil
+		apps = append(apps, AppStatus{Name: name, Group: group})
 	}
+	return apps, nil
+}
 
-	fmt.Println("Configured apps (server not running):")
-	fmt.Printf("%-20s %s\n", "APP", "URL")
-	fmt.Printf("%-20s %s\n", "---", "---")
+// filterGroup returns the apps in group or one of its subgroups
+func filterGroup(apps []AppStatus, group string) []AppStatus {
+	group = strings.Trim(group, "/")
+	var result []AppStatus
 	for _, app := range apps {
-		url := fmt.Sprintf("http://%s.%s", app, tld)
-		fmt.Printf("%-20s %s\n", app, url)
+		if app.Group == group || strings.HasPrefix(app.Group, group+"/") {
+			result = append(result, app)
+		}
 	}
-	fmt.Println("\nStart the server with: fireup serve")
+	return result
+}
 
-	return nil
+// sortByGroup orders apps so that top-level apps come first, followed by
+// each group in order. Apps keep their order within a group.
+func sortByGroup(apps []AppStatus) {
+	sort.SliceStable(apps, func(i, j int) bool {
+		return apps[i].Group < apps[j].Group
+	})
 }
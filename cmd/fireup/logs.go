@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -84,14 +85,14 @@ Requires the fireup server to be running.`)
 
 // runLogsOnce fetches and prints logs once
 func runLogsOnce(tld, appName string, server bool, maxLines int) error {
-	var url string
+	var endpoint string
 	if server || appName == "" {
-		url = fmt.Sprintf("http://fireup.%s/api/server-logs", tld)
+		endpoint = fmt.Sprintf("http://fireup.%s/api/server-logs", tld)
 	} else {
-		url = fmt.Sprintf("http://fireup.%s/api/logs?name=%s", tld, appName)
+		endpoint = fmt.Sprintf("http://fireup.%s/api/logs?name=%s", tld, url.QueryEscape(appName))
 	}
 
-	resp, err := http.Get(url)
+	resp, err := http.Get(endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to connect to fireup: %v (is it running?)", err)
 	}
@@ -198,14 +199,14 @@ func runLogsFollow(tld, appName string, server bool, maxLines int) {
 			fmt.Println()
 			return
 		case <-ticker.C:
-			var url string
+			var endpoint string
 			if server || appName == "" {
-				url = fmt.Sprintf("http://fireup.%s/api/server-logs", tld)
+				endpoint = fmt.Sprintf("http://fireup.%s/api/server-logs", tld)
 			} else {
-				url = fmt.Sprintf("http://fireup.%s/api/logs?name=%s", tld, appName)
+				endpoint = fmt.Sprintf("http://fireup.%s/api/logs?name=%s", tld, url.QueryEscape(appName))
 			}
 
-			resp, err := http.Get(url)
+			resp, err := http.Get(endpoint)
 			if err != nil {
 				if firstRun {
 					fmt.Fprintf(os.Stderr, "Error: failed to connect to fireup: %v (is it running?)\n", err)
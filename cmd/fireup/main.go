@@ -8,6 +8,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -19,7 +20,6 @@ import (
 
 	"github.com/panozzaj/fireup/internal/config"
 	"github.com/panozzaj/fireup/internal/diff"
-	"github.com/panozzaj/fireup/internal/dns"
 	"github.com/panozzaj/fireup/internal/logo"
 	"github.com/panozzaj/fireup/internal/server"
 )
@@ -120,6 +120,10 @@ func main() {
 		cmdAppControl("stop", args)
 	case "restart":
 		cmdAppControl("restart", args)
+	case "pause":
+		cmdAppControl("pause", args)
+	case "resume":
+		cmdAppControl("resume", args)
 	case "setup":
 		cmdSetup(args)
 	case "teardown":
@@ -142,6 +146,14 @@ func main() {
 		cmdDocs(args)
 	case "logs":
 		cmdLogs(args)
+	case "requests":
+		cmdRequests(args)
+	case "init":
+		cmdInit(args)
+	case "add":
+		cmdAdd(args)
+	case "import":
+		cmdImport(args)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\nRun 'fireup help' for usage.\n", cmd)
 		os.Exit(1)
@@ -162,10 +174,18 @@ APP STATUS:
     list, ls          Alias for status
 
 APP CONTROL:
-    start <app>       Start an app
+    start <app>       Start an app (--profile <name> to switch profile)
     stop <app>        Stop an app
     restart <app>     Restart an app
+    pause <app>       Pause an app until its next request (SIGSTOP)
+    resume <app>      Resume a paused app
     logs [app]        View server or app logs (-f to follow)
+    requests <app>    Show requests captured with inspect: (-f to follow)
+
+APP CONFIG:
+    init              Detect this project's stack and create a config
+    add <dir>         Detect another directory's stack and create a config
+    import compose    Convert docker-compose services into a config
 
 SETUP:
     setup             Interactive setup wizard (ports + cert + service)
@@ -265,6 +285,7 @@ CONFIGURATION:
 		log.Printf("Warning: could not load config: %v", err)
 		globalCfg = &GlobalConfig{TLD: "test"}
 	}
+	fixedTLD := tld != ""
 	if tld == "" {
 		tld = globalCfg.TLD
 	}
@@ -302,8 +323,11 @@ CONFIGURATION:
 		HTTPSPort:     httpsPort,
 		URLPort:       urlPort,
 		TLD:           tld,
+		FixedTLD:      fixedTLD,
+		DNSPort:       dnsPort,
 		Ollama:        ollamaCfg,
 		ClaudeCommand: claudeCmd,
+		Version:       version,
 	}
 
 	// Create and start server
@@ -334,16 +358,6 @@ CONFIGURATION:
 		fmt.Printf("Dashboard at http://fireup.%s:%d\n", tld, urlPort)
 	}
 
-	// Start DNS server for custom TLDs
-	if tld != "localhost" {
-		dnsServer := dns.New(dnsPort, tld)
-		go func() {
-			if err := dnsServer.Start(); err != nil {
-				log.Printf("DNS server error: %v", err)
-			}
-		}()
-		fmt.Printf("DNS server on 127.0.0.1:%d for *.%s\n", dnsPort, tld)
-	}
 	fmt.Println()
 
 	// Warn if pf rules aren't set up but we're using port forwarding defaults
@@ -379,13 +393,41 @@ NAME FORMATS:
     myapp:worker          %s the 'worker' service (colon syntax)
     worker.myapp          %s the 'worker' service (dot syntax)
     worker                %s the service if name is unique across apps
-
-Requires the fireup server to be running.
 `, action, strings.Title(action), action, strings.Title(action), strings.Title(action), strings.Title(action), strings.Title(action))
+			if action == "start" {
+				fmt.Print(`
+OPTIONS:
+    --profile <name>      Switch the app to a profile before starting
+                          (use "default" for the base config)
+`)
+			}
+			fmt.Println("\nRequires the fireup server to be running.")
 			os.Exit(0)
 		}
 	}
 
+	// Extract --profile (start only)
+	var profile string
+	if action == "start" {
+		var rest []string
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == "--profile" || args[i] == "-profile":
+				if i+1 >= len(args) {
+					fmt.Fprintln(os.Stderr, "Error: --profile requires a value")
+					os.Exit(1)
+				}
+				profile = args[i+1]
+				i++
+			case strings.HasPrefix(args[i], "--profile="):
+				profile = strings.TrimPrefix(args[i], "--profile=")
+			default:
+				rest = append(rest, args[i])
+			}
+		}
+		args = rest
+	}
+
 	if len(args) < 1 {
 		if appName, found := resolveAppFromCwd(); found {
 			fmt.Fprintf(os.Stderr, "(detected %s from current directory)\n", appName)
@@ -396,7 +438,7 @@ Requires the fireup server to be running.
 		}
 	}
 
-	if err := runCommand(action, args[0]); err != nil {
+	if err := runCommand(action, args[0], profile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -544,6 +586,7 @@ DESCRIPTION:
 func cmdStatus(args []string) {
 	fs := flag.NewFlagSet("status", flag.ExitOnError)
 	jsonOutput := fs.Bool("json", false, "Output in JSON format")
+	group := fs.String("group", "", "Only show apps in this config group (e.g., work)")
 
 	fs.Usage = func() {
 		fmt.Println(`fireup status - Show status of configured apps
@@ -564,6 +607,7 @@ EXAMPLES:
     fireup status              # Show all apps
     fireup status family       # Filter to apps/services matching "family"
     fireup status --json api   # JSON output filtered to "api"
+    fireup status --group work # Apps configured in ~/.config/fireup/work/
 
 For setup component status (ports, cert, service), use:
     fireup setup status`)
@@ -585,7 +629,7 @@ For setup component status (ports, cert, service), use:
 		filter = fs.Arg(0)
 	}
 
-	if err := runStatus(*jsonOutput, filter); err != nil {
+	if err := runStatus(*jsonOutput, filter, *group); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -719,7 +763,8 @@ func levenshtein(a, b string) int {
 }
 
 // runStatus displays app status, optionally as JSON, with optional filter
-func runStatus(jsonOutput bool, filter string) error {
+// and group
+func runStatus(jsonOutput bool, filter, group string) error {
 	globalCfg, configDir := getConfigWithDefaults()
 
 	// Try to get status from running server
@@ -732,7 +777,7 @@ func runStatus(jsonOutput bool, filter string) error {
 			return nil
 		}
 		// Server not running - fall back to listing config files
-		return listConfigFiles(configDir, globalCfg.TLD)
+		return listConfigFiles(configDir, globalCfg.TLD, group)
 	}
 	defer resp.Body.Close()
 
@@ -741,7 +786,7 @@ func runStatus(jsonOutput bool, filter string) error {
 			fmt.Println("[]")
 			return nil
 		}
-		return listConfigFiles(configDir, globalCfg.TLD)
+		return listConfigFiles(configDir, globalCfg.TLD, group)
 	}
 
 	// Parse status
@@ -755,6 +800,18 @@ func runStatus(jsonOutput bool, filter string) error {
 		return fmt.Errorf("failed to parse status: %v", err)
 	}
 
+	if group != "" {
+		apps = filterGroup(apps, group)
+		if len(apps) == 0 {
+			if jsonOutput {
+				fmt.Println("[]")
+			} else {
+				fmt.Printf("No apps in group %q.\n", group)
+			}
+			return nil
+		}
+	}
+
 	// Apply filter if provided
 	if filter != "" {
 		apps = filterApps(apps, filter)
@@ -795,24 +852,38 @@ func runStatus(jsonOutput bool, filter string) error {
 	fmt.Printf("%-25s %-10s %s\n", "APP", "STATUS", "URL")
 	fmt.Printf("%-25s %-10s %s\n", strings.Repeat("-", 25), strings.Repeat("-", 10), strings.Repeat("-", 30))
 
+	sortByGroup(apps)
+	currentGroup := ""
 	for _, app := range apps {
+		if app.Group != currentGroup {
+			currentGroup = app.Group
+			fmt.Printf("%s%s/%s\n", colorGray, currentGroup, colorReset)
+		}
+
 		var status string
 		if app.Type == "multi-service" {
-			runningCount := 0
+			runningCount, pausedCount := 0, 0
 			for _, svc := range app.Services {
 				if svc.Running {
 					runningCount++
 				}
+				if svc.Paused {
+					pausedCount++
+				}
 			}
 			if runningCount == 0 {
 				status = "idle"
+			} else if pausedCount == runningCount {
+				status = "paused"
 			} else if runningCount == len(app.Services) {
 				status = "running"
 			} else {
 				status = fmt.Sprintf("%d/%d", runningCount, len(app.Services))
 			}
 		} else {
-			if app.Running {
+			if app.Paused {
+				status = "paused"
+			} else if app.Running {
 				status = "running"
 			} else {
 				status = "idle"
@@ -825,7 +896,7 @@ func runStatus(jsonOutput bool, filter string) error {
 			paddedStatus = colorGreen + paddedStatus + colorReset
 		case status == "idle":
 			paddedStatus = colorGray + paddedStatus + colorReset
-		case strings.Contains(status, "/"):
+		case status == "paused" || strings.Contains(status, "/"):
 			paddedStatus = colorYellow + paddedStatus + colorReset
 		}
 
@@ -833,7 +904,13 @@ func runStatus(jsonOutput bool, filter string) error {
 		if len(app.Aliases) > 0 {
 			name = fmt.Sprintf("%s (%s)", app.Name, strings.Join(app.Aliases, ", "))
 		}
+		if app.Profile != "" && app.Profile != "default" {
+			name = fmt.Sprintf("%s [%s]", name, app.Profile)
+		}
 		fmt.Printf("%-25s %s %s\n", name, paddedStatus, app.URL)
+		if len(app.HeldBy) > 0 {
+			fmt.Printf("  %sheld by %s%s\n", colorGray, strings.Join(app.HeldBy, ", "), colorReset)
+		}
 
 		// Print services for multi-service apps
 		if app.Type == "multi-service" && len(app.Services) > 0 {
@@ -846,7 +923,9 @@ func runStatus(jsonOutput bool, filter string) error {
 				}
 
 				var svcStatus string
-				if svc.Running {
+				if svc.Paused {
+					svcStatus = "paused"
+				} else if svc.Running {
 					svcStatus = "running"
 				} else {
 					svcStatus = "idle"
@@ -855,12 +934,15 @@ func runStatus(jsonOutput bool, filter string) error {
 				svcPaddedStatus := fmt.Sprintf("%-10s", svcStatus)
 				if svcStatus == "running" {
 					svcPaddedStatus = colorGreen + svcPaddedStatus + colorReset
+				} else if svcStatus == "paused" {
+					svcPaddedStatus = colorYellow + svcPaddedStatus + colorReset
 				} else {
 					svcPaddedStatus = colorGray + svcPaddedStatus + colorReset
 				}
 
 				svcName := fmt.Sprintf("%s %s", prefix, svc.Name)
-				fmt.Printf("  %-23s %s %s\n", svcName, svcPaddedStatus, svc.URL)
+				fmt.Printf("  %-23s %s %s%s\n", svcName, svcPaddedStatus, svc.URL, forwardedPorts(svc))
+				printInstances(svc.Instances, i == len(app.Services)-1)
 			}
 		}
 	}
@@ -868,29 +950,87 @@ func runStatus(jsonOutput bool, filter string) error {
 	return nil
 }
 
-func runCommand(cmd, appName string) error {
+// forwardedPorts describes the tcp: port of a service, e.g. " (tcp :5433)",
+// or returns "" if it has none
+func forwardedPorts(svc SvcStatus) string {
+	if svc.TCP == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %s(tcp :%d)%s", colorGray, svc.TCP, colorReset)
+}
+
+// printInstances prints the instances of a scaled service below it. last
+// is true for the app's last service, which has no tree line below.
+func printInstances(instances []InstanceStatus, last bool) {
+	indent := "│  "
+	if last {
+		indent = "   "
+	}
+	for _, inst := range instances {
+		status, color := "idle", colorGray
+		switch {
+		case inst.Failed:
+			status, color = "failed", colorRed
+		case inst.Paused:
+			status, color = "paused", colorYellow
+		case inst.Running:
+			status, color = "running", colorGreen
+		case inst.Starting:
+			status, color = "starting", colorYellow
+		}
+		name := inst.Name
+		if i := strings.LastIndex(name, "#"); i != -1 {
+			name = name[i:]
+		}
+		port := ""
+		if inst.Port != 0 {
+			port = fmt.Sprintf("port %d", inst.Port)
+		}
+		fmt.Printf("  %-23s %s%-10s%s %s\n", indent+name, color, status, colorReset, port)
+	}
+}
+
+// runCommand asks the fireup server to start, stop or restart an app.
+// profile, if set, switches the app's profile before starting.
+func runCommand(cmd, appName, profile string) error {
 	// Load config to get TLD
 	globalCfg, _ := getConfigWithDefaults()
 
 	// Show action in progress
 	switch cmd {
 	case "start":
-		fmt.Printf("Starting %s...\n", appName)
+		if profile != "" {
+			fmt.Printf("Starting %s (profile %s)...\n", appName, profile)
+		} else {
+			fmt.Printf("Starting %s...\n", appName)
+		}
 	case "stop":
 		fmt.Printf("Stopping %s...\n", appName)
 	case "restart":
 		fmt.Printf("Restarting %s...\n", appName)
+	case "pause":
+		fmt.Printf("Pausing %s...\n", appName)
+	case "resume":
+		fmt.Printf("Resuming %s...\n", appName)
 	}
 
 	// Make request to fireup API
-	url := fmt.Sprintf("http://fireup.%s/api/%s?name=%s", globalCfg.TLD, cmd, appName)
-	resp, err := http.Get(url)
+	query := url.Values{"name": {appName}}
+	if profile != "" {
+		query.Set("profile", profile)
+	}
+	apiURL := fmt.Sprintf("http://fireup.%s/api/%s?%s", globalCfg.TLD, cmd, query.Encode())
+	resp, err := http.Get(apiURL)
 	if err != nil {
 		return fmt.Errorf("failed to connect to fireup: %v (is it running?)", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
 		return fmt.Errorf("request failed with status %d", resp.StatusCode)
 	}
 
@@ -902,6 +1042,10 @@ func runCommand(cmd, appName string) error {
 		fmt.Printf("%s stopped\n", appName)
 	case "restart":
 		fmt.Printf("%s restarted\n", appName)
+	case "pause":
+		fmt.Printf("%s paused (the next request resumes it)\n", appName)
+	case "resume":
+		fmt.Printf("%s resumed\n", appName)
 	}
 	return nil
 }
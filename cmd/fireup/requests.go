@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/inspect"
+	"golang.org/x/term"
+)
+
+func cmdRequests(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "replay":
+			cmdRequestsReplay(args[1:])
+			return
+		case "export":
+			cmdRequestsExport(args[1:])
+			return
+		case "import":
+			cmdRequestsImport(args[1:])
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet("requests", flag.ExitOnError)
+
+	var (
+		follow  bool
+		verbose bool
+		count   int
+	)
+
+	fs.BoolVar(&follow, "f", false, "Follow new requests (poll for them)")
+	fs.BoolVar(&verbose, "v", false, "Show headers and bodies")
+	fs.IntVar(&count, "n", 0, "Number of requests to show (0 = all captured)")
+
+	fs.Usage = func() {
+		fmt.Println(`fireup requests - Show the recent requests to an app
+
+USAGE:
+    fireup requests [options] [app-name]
+    fireup requests replay [options] <id>
+    fireup requests export [-o file] [app-name]
+    fireup requests import <file.har> [app-name]
+
+COMMANDS:
+    replay        Send a captured request again, optionally edited
+    export        Save the captured requests as a HAR file
+    import        Replay the requests in a HAR file against an app
+
+OPTIONS:
+  -f            Follow new requests (poll for them)
+  -v            Show headers and bodies
+  -n int        Number of requests to show (0 = all captured)
+
+EXAMPLES:
+    fireup requests myapp        List captured requests to myapp
+    fireup requests -f -v myapp  Follow requests with headers and bodies
+    fireup requests api.myapp    Only requests to myapp's api service
+    fireup requests replay 12    Send request 12 again
+
+Requests are only captured for apps with inspect: true in their config.
+Requires the fireup server to be running.`)
+	}
+
+	// Check for help before parsing
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" || arg == "help" {
+			fs.Usage()
+			os.Exit(0)
+		}
+	}
+
+	fs.Parse(args)
+
+	globalCfg, _ := getConfigWithDefaults()
+	appName := fs.Arg(0)
+	if appName == "" {
+		resolved, found := resolveAppFromCwd()
+		if !found {
+			fs.Usage()
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "(detected %s from current directory)\n", resolved)
+		appName = resolved
+	}
+
+	colorize := term.IsTerminal(int(os.Stdout.Fd()))
+	exchanges, err := fetchRequests(globalCfg.TLD, appName, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if count > 0 && len(exchanges) > count {
+		exchanges = exchanges[len(exchanges)-count:]
+	}
+	var lastID int64
+	for _, e := range exchanges {
+		fmt.Print(formatExchange(e, verbose, colorize))
+		lastID = e.ID
+	}
+	if !follow {
+		return
+	}
+
+	// Handle Ctrl+C gracefully
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigCh:
+			fmt.Println()
+			return
+		case <-ticker.C:
+			exchanges, err := fetchRequests(globalCfg.TLD, appName, lastID)
+			if err != nil {
+				continue // Transient error, keep trying
+			}
+			for _, e := range exchanges {
+				fmt.Print(formatExchange(e, verbose, colorize))
+				lastID = e.ID
+			}
+		}
+	}
+}
+
+// fetchRequests returns the captured requests of an app with an ID above
+// since
+func fetchRequests(tld, appName string, since int64) ([]inspect.Exchange, error) {
+	resp, err := http.Get(fmt.Sprintf("http://fireup.%s/api/requests?name=%s&since=%d", tld, url.QueryEscape(appName), since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to fireup: %v (is it running?)", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+	var exchanges []inspect.Exchange
+	if err := json.NewDecoder(resp.Body).Decode(&exchanges); err != nil {
+		return nil, fmt.Errorf("failed to parse requests: %v", err)
+	}
+	return exchanges, nil
+}
+
+// headerFlags collects repeated -H "Name: value" flags
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(v)
+	return nil
+}
+
+// cmdRequestsReplay handles 'requests replay', sending a captured request
+// again through fireup
+func cmdRequestsReplay(args []string) {
+	fs := flag.NewFlagSet("requests replay", flag.ExitOnError)
+
+	var (
+		method  string
+		path    string
+		body    string
+		verbose bool
+	)
+	headers := headerFlags{}
+
+	fs.StringVar(&method, "X", "", "Method to send instead")
+	fs.StringVar(&path, "url", "", "Path and query to send instead")
+	fs.Var(headers, "H", `Header to set, as "Name: value" ("Name:" removes it)`)
+	fs.StringVar(&body, "d", "", "Body to send instead (@file reads a file)")
+	fs.BoolVar(&verbose, "v", false, "Show headers and bodies")
+
+	fs.Usage = func() {
+		fmt.Println(`fireup requests replay - Send a captured request again
+
+USAGE:
+    fireup requests replay [options] <id>
+
+OPTIONS:
+  -X method     Method to send instead
+  -url path     Path and query to send instead
+  -H header     Header to set, as "Name: value" ("Name:" removes it);
+                repeat for more
+  -d body       Body to send instead (@file reads a file)
+  -v            Show headers and bodies
+
+EXAMPLES:
+    fireup requests replay 12                     Send request 12 again
+    fireup requests replay -d @event.json 12      With another body
+    fireup requests replay -H "X-Debug: 1" 12     With an extra header
+
+The request goes to the app's current backend, with the headers it was
+captured with, including redacted ones. IDs are shown by 'fireup requests'.
+Requires the fireup server to be running.`)
+	}
+
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" || arg == "help" {
+			fs.Usage()
+			os.Exit(0)
+		}
+	}
+
+	fs.Parse(args)
+
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	edit := inspect.Edit{Method: method, URL: path}
+	if len(headers) > 0 {
+		edit.Headers = headers
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "d" {
+			edit.Body = &body
+		}
+	})
+	if file, ok := strings.CutPrefix(body, "@"); ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		text := string(data)
+		edit.Body = &text
+	}
+
+	globalCfg, _ := getConfigWithDefaults()
+	payload, _ := json.Marshal(edit)
+	var e inspect.Exchange
+	if err := postRequests(fmt.Sprintf("http://fireup.%s/api/requests/%d/replay", globalCfg.TLD, id), payload, &e); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(formatExchange(e, verbose, term.IsTerminal(int(os.Stdout.Fd()))))
+}
+
+// cmdRequestsExport handles 'requests export', saving an app's captured
+// requests as a HAR file
+func cmdRequestsExport(args []string) {
+	fs := flag.NewFlagSet("requests export", flag.ExitOnError)
+
+	var output string
+	fs.StringVar(&output, "o", "", "File to write (default: <app>.har, - for stdout)")
+
+	fs.Usage = func() {
+		fmt.Println(`fireup requests export - Save captured requests as a HAR file
+
+USAGE:
+    fireup requests export [-o file] [app-name]
+
+OPTIONS:
+  -o file       File to write (default: <app>.har, - for stdout)
+
+HAR files open in browser devtools and other HTTP tools. Redacted headers
+stay redacted. Requires the fireup server to be running.`)
+	}
+
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" || arg == "help" {
+			fs.Usage()
+			os.Exit(0)
+		}
+	}
+
+	fs.Parse(args)
+
+	globalCfg, _ := getConfigWithDefaults()
+	appName := fs.Arg(0)
+	if appName == "" {
+		resolved, found := resolveAppFromCwd()
+		if !found {
+			fs.Usage()
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "(detected %s from current directory)\n", resolved)
+		appName = resolved
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://fireup.%s/api/requests/har?name=%s", globalCfg.TLD, url.QueryEscape(appName)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to connect to fireup: %v (is it running?)\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", strings.TrimSpace(string(data)))
+		os.Exit(1)
+	}
+
+	if output == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if output == "" {
+		output = appName + ".har"
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved captured requests to %s\n", output)
+}
+
+// cmdRequestsImport handles 'requests import', replaying the requests of a
+// HAR file against an app
+func cmdRequestsImport(args []string) {
+	fs := flag.NewFlagSet("requests import", flag.ExitOnError)
+
+	var verbose bool
+	fs.BoolVar(&verbose, "v", false, "Show headers and bodies")
+
+	fs.Usage = func() {
+		fmt.Println(`fireup requests import - Replay the requests in a HAR file
+
+USAGE:
+    fireup requests import [-v] <file.har> [app-name]
+
+OPTIONS:
+  -v            Show headers and bodies
+
+The requests are sent to the app in order, whatever host they were
+recorded for. Redacted headers are left out. The app needs inspect: true
+in its config. Requires the fireup server to be running.`)
+	}
+
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" || arg == "help" {
+			fs.Usage()
+			os.Exit(0)
+		}
+	}
+
+	fs.Parse(args)
+
+	file := fs.Arg(0)
+	if file == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	globalCfg, _ := getConfigWithDefaults()
+	appName := fs.Arg(1)
+	if appName == "" {
+		resolved, found := resolveAppFromCwd()
+		if !found {
+			fs.Usage()
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "(detected %s from current directory)\n", resolved)
+		appName = resolved
+	}
+
+	var results []struct {
+		Method   string            `json:"method"`
+		URL      string            `json:"url"`
+		Exchange *inspect.Exchange `json:"exchange"`
+		Error    string            `json:"error"`
+	}
+	if err := postRequests(fmt.Sprintf("http://fireup.%s/api/requests/har?name=%s", globalCfg.TLD, url.QueryEscape(appName)), data, &results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	colorize := term.IsTerminal(int(os.Stdout.Fd()))
+	failed := 0
+	for _, result := range results {
+		if result.Exchange == nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", result.Method, result.URL, result.Error)
+			continue
+		}
+		fmt.Print(formatExchange(*result.Exchange, verbose, colorize))
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d requests couldn't be replayed\n", failed, len(results))
+		os.Exit(1)
+	}
+}
+
+// postRequests posts body to a requests API endpoint and decodes the JSON
+// response into result
+func postRequests(endpoint string, body []byte, result any) error {
+	resp, err := http.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to connect to fireup: %v (is it running?)", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(data)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+// formatExchange formats a captured request as a summary line, e.g.
+// "#12  15:04:05  POST /webhook  201  12.3ms", followed by its headers and
+// bodies if verbose
+func formatExchange(e inspect.Exchange, verbose, colorize bool) string {
+	color := func(c, s string) string {
+		if !colorize {
+			return s
+		}
+		return c + s + colorReset
+	}
+	statusColor := colorGreen
+	switch {
+	case e.Status >= 500:
+		statusColor = colorRed
+	case e.Status >= 400:
+		statusColor = colorYellow
+	case e.Status >= 300:
+		statusColor = colorCyan
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s  %s  %s %s  %s  %s",
+		color(colorGray, fmt.Sprintf("#%d", e.ID)),
+		color(colorGray, e.Time.Local().Format("15:04:05")),
+		e.Method, e.URL,
+		color(statusColor, fmt.Sprint(e.Status)),
+		color(colorDim, fmt.Sprintf("%.1fms", e.Duration)))
+	if e.ReplayOf != 0 {
+		sb.WriteString("  " + color(colorDim, fmt.Sprintf("(replay of #%d)", e.ReplayOf)))
+	}
+	if e.Error != "" {
+		sb.WriteString("  " + color(colorRed, e.Error))
+	}
+	sb.WriteString("\n")
+	if !verbose {
+		return sb.String()
+	}
+
+	writeHeaders := func(h http.Header) {
+		names := make([]string, 0, len(h))
+		for name := range h {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, value := range h[name] {
+				fmt.Fprintf(&sb, "    %s %s\n", color(colorCyan, name+":"), value)
+			}
+		}
+	}
+	writeBody := func(b inspect.Body) {
+		switch {
+		case b.Size == 0:
+			return
+		case b.Binary:
+			fmt.Fprintf(&sb, "    %s\n", color(colorDim, fmt.Sprintf("(%d bytes, binary)", b.Size)))
+			return
+		}
+		for _, line := range strings.Split(strings.TrimRight(b.Text, "\n"), "\n") {
+			fmt.Fprintf(&sb, "    %s\n", line)
+		}
+		if b.Truncated {
+			fmt.Fprintf(&sb, "    %s\n", color(colorDim, fmt.Sprintf("(%d of %d bytes)", len(b.Text), b.Size)))
+		}
+	}
+	fmt.Fprintf(&sb, "  %s\n", color(colorDim, "Request"))
+	writeHeaders(e.RequestHeaders)
+	writeBody(e.RequestBody)
+	fmt.Fprintf(&sb, "  %s\n", color(colorDim, "Response"))
+	writeHeaders(e.ResponseHeaders)
+	writeBody(e.ResponseBody)
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/inspect"
+)
+
+func TestFormatExchange(t *testing.T) {
+	e := inspect.Exchange{
+		ID:              12,
+		Time:            time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local),
+		Duration:        12.34,
+		Method:          "POST",
+		URL:             "/webhook?source=stripe",
+		RequestHeaders:  http.Header{"Content-Type": {"application/json"}, "Authorization": {inspect.Redacted}},
+		RequestBody:     inspect.Body{Text: `{"id":1}`, Size: 8},
+		Status:          201,
+		ResponseHeaders: http.Header{"Content-Length": {"2"}},
+		ResponseBody:    inspect.Body{Text: "ok", Size: 20, Truncated: true},
+	}
+
+	if got := formatExchange(e, false, false); got != "#12  15:04:05  POST /webhook?source=stripe  201  12.3ms\n" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	want := `#12  15:04:05  POST /webhook?source=stripe  201  12.3ms
+  Request
+    Authorization: [redacted]
+    Content-Type: application/json
+    {"id":1}
+  Response
+    Content-Length: 2
+    ok
+    (2 of 20 bytes)
+
+`
+	if got := formatExchange(e, true, false); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	e.Status, e.Error = 502, "connection refused"
+	if got := formatExchange(e, false, false); got != "#12  15:04:05  POST /webhook?source=stripe  502  12.3ms  connection refused\n" {
+		t.Errorf("expected the error in the summary, got %q", got)
+	}
+
+	e.Error, e.ReplayOf = "", 7
+	if got := formatExchange(e, false, false); got != "#12  15:04:05  POST /webhook?source=stripe  502  12.3ms  (replay of #7)\n" {
+		t.Errorf("expected the replayed ID in the summary, got %q", got)
+	}
+}
+
+func TestHeaderFlags(t *testing.T) {
+	h := headerFlags{}
+	for _, value := range []string{"X-Debug: 1", "Authorization:Bearer abc", "Cookie:"} {
+		if err := h.Set(value); err != nil {
+			t.Fatalf("Set(%q): %v", value, err)
+		}
+	}
+	want := map[string]string{"X-Debug": "1", "Authorization": "Bearer abc", "Cookie": ""}
+	if fmt.Sprint(map[string]string(h)) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, h)
+	}
+	if err := h.Set("no colon"); err == nil {
+		t.Error("expected an error for a header without a colon")
+	}
+}
@@ -105,8 +105,8 @@ func isSubdir(child, parent string) bool {
 
 // loadAppsForCLI creates an AppStore, loads configs, and returns all apps.
 func loadAppsForCLI() []*config.App {
-	configDir := getDefaultConfigDir()
-	cfg := &config.Config{Dir: configDir}
+	globalCfg, configDir := getConfigWithDefaults()
+	cfg := &config.Config{Dir: configDir, TLD: globalCfg.TLD}
 	store := config.NewAppStore(cfg)
 	if err := store.Load(); err != nil {
 		return nil
@@ -0,0 +1,86 @@
+// Package backend decides which loopback address fireup uses to reach the
+// apps it proxies to.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Host settings for reaching an app
+const (
+	IPv4 = "127.0.0.1"
+	IPv6 = "::1"
+	Auto = "auto" // Try IPv4, then IPv6 (e.g. Node 17+ binding localhost to ::1)
+)
+
+// autoHost stands for Auto in addresses. Processes get it as $HOST so they
+// bind wherever localhost resolves, and Dial tries both families for it.
+const autoHost = "localhost"
+
+// Valid returns true for the host settings fireup supports. Empty means Auto.
+func Valid(host string) bool {
+	switch host {
+	case "", IPv4, IPv6, Auto:
+		return true
+	}
+	return false
+}
+
+// EnvHost returns the $HOST value for processes with the given host setting
+func EnvHost(host string) string {
+	if host == "" || host == Auto {
+		return autoHost
+	}
+	return host
+}
+
+// Addr returns the address for an app's port, e.g. "127.0.0.1:3000" or
+// "[::1]:3000". With Auto it's "localhost:3000", which Dial resolves itself.
+func Addr(host string, port int) string {
+	return net.JoinHostPort(EnvHost(host), strconv.Itoa(port))
+}
+
+// candidates returns the addresses to try for addr, in order
+func candidates(addr string) []string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != autoHost {
+		return []string{addr}
+	}
+	return []string{net.JoinHostPort(IPv4, port), net.JoinHostPort(IPv6, port)}
+}
+
+// Dial connects to an address from Addr, trying both families for Auto.
+// It has the signature of http.Transport.DialContext.
+func Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	var firstErr error
+	for _, candidate := range candidates(addr) {
+		conn, err := dialer.DialContext(ctx, network, candidate)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// DialTimeout is Dial with a timeout for each attempt
+func DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	var firstErr error
+	for _, candidate := range candidates(addr) {
+		conn, err := net.DialTimeout("tcp", candidate, timeout)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, fmt.Errorf("connecting to %s: %w", addr, firstErr)
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAddr(t *testing.T) {
+	cases := map[string]string{
+		"":   "localhost:3000",
+		Auto: "localhost:3000",
+		IPv4: "127.0.0.1:3000",
+		IPv6: "[::1]:3000",
+	}
+	for host, want := range cases {
+		if got := Addr(host, 3000); got != want {
+			t.Errorf("Addr(%q): expected %s, got %s", host, want, got)
+		}
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback not available")
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	t.Run("auto finds an IPv6-only listener", func(t *testing.T) {
+		conn, err := DialTimeout(Addr(Auto, port), time.Second)
+		if err != nil {
+			t.Fatalf("expected to connect, got %v", err)
+		}
+		conn.Close()
+	})
+
+	t.Run("IPv4 does not fall back", func(t *testing.T) {
+		if conn, err := DialTimeout(Addr(IPv4, port), time.Second); err == nil {
+			conn.Close()
+			t.Error("expected 127.0.0.1 not to reach an IPv6-only listener")
+		}
+	})
+}
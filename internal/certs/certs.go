@@ -23,6 +23,7 @@ import (
 type Manager struct {
 	certsDir string
 	tld      string
+	tldMu    sync.RWMutex
 
 	caCert    *x509.Certificate
 	caKey     *ecdsa.PrivateKey
@@ -48,6 +49,24 @@ func NewManager(certsDir, tld string) (*Manager, error) {
 	return m, nil
 }
 
+// TLD returns the top-level domain certificates are issued for
+func (m *Manager) TLD() string {
+	m.tldMu.RLock()
+	defer m.tldMu.RUnlock()
+	return m.tld
+}
+
+// SetTLD changes the top-level domain and drops cached certificates
+func (m *Manager) SetTLD(tld string) {
+	m.tldMu.Lock()
+	m.tld = tld
+	m.tldMu.Unlock()
+
+	m.cacheMu.Lock()
+	m.cache = make(map[string]*tls.Certificate)
+	m.cacheMu.Unlock()
+}
+
 // CAExists checks if the CA certificate exists
 func CAExists(certsDir string) bool {
 	certPath := filepath.Join(certsDir, "ca.pem")
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AppDependencyPrefix marks depends_on entries that point at another app
+const AppDependencyPrefix = "app:"
+
+// AppDependency is a depends_on entry that points at another app
+// ("app:auth") or one of its services ("app:payments:api")
+type AppDependency struct {
+	App     string // App name or alias
+	Service string // Empty to depend on every service of the app
+}
+
+// String returns the dependency as written in depends_on
+func (d AppDependency) String() string {
+	if d.Service == "" {
+		return AppDependencyPrefix + d.App
+	}
+	return AppDependencyPrefix + d.App + ":" + d.Service
+}
+
+// parseAppDependency parses "app:name" or "app:name:service"
+func parseAppDependency(entry string) (AppDependency, error) {
+	parts := strings.Split(strings.TrimPrefix(entry, AppDependencyPrefix), ":")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+		return AppDependency{}, fmt.Errorf("invalid app dependency %q (use app:<name> or app:<name>:<service>)", entry)
+	}
+	dep := AppDependency{App: parts[0]}
+	if len(parts) == 2 {
+		dep.Service = parts[1]
+	}
+	return dep, nil
+}
+
+// splitDependsOn separates app: references from the names of services in
+// the same app. Errors are collected on vars.
+func splitDependsOn(entries []string, where string, vars *interpolator) ([]string, []AppDependency) {
+	var services []string
+	var apps []AppDependency
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry, AppDependencyPrefix) {
+			services = append(services, entry)
+			continue
+		}
+		dep, err := parseAppDependency(entry)
+		if err != nil {
+			vars.errs = append(vars.errs, fmt.Sprintf("%s: %v", where, err))
+			continue
+		}
+		apps = append(apps, dep)
+	}
+	return services, apps
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+
+	t.Run("service and app level", func(t *testing.T) {
+		write("storefront.yml", `
+root: /tmp
+depends_on: [app:auth]
+stop_dependencies: true
+services:
+  web:
+    cmd: rails s
+    depends_on: [worker, app:payments:api]
+  worker:
+    cmd: sidekiq
+`)
+		store := NewAppStore(&Config{Dir: tmpDir})
+		app, err := store.loadYAMLApp("storefront.yml", filepath.Join(tmpDir, "storefront.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(app.AppDeps, []AppDependency{{App: "auth"}}) {
+			t.Errorf("unexpected app dependencies: %+v", app.AppDeps)
+		}
+		if !app.StopDependencies {
+			t.Error("expected stop_dependencies to be set")
+		}
+		web := app.Services[len(app.Services)-1]
+		if web.Name != "web" {
+			t.Fatalf("expected web to sort after worker, got %+v", app.Services)
+		}
+		if !reflect.DeepEqual(web.DependsOn, []string{"worker"}) {
+			t.Errorf("expected only local services in DependsOn, got %v", web.DependsOn)
+		}
+		if !reflect.DeepEqual(web.AppDeps, []AppDependency{{App: "payments", Service: "api"}}) {
+			t.Errorf("unexpected service app dependencies: %+v", web.AppDeps)
+		}
+		if got := web.AppDeps[0].String(); got != "app:payments:api" {
+			t.Errorf("expected app:payments:api, got %s", got)
+		}
+	})
+
+	t.Run("single service", func(t *testing.T) {
+		write("admin.yml", `
+root: /tmp
+depends_on: [app:auth]
+services:
+  web:
+    cmd: rails s
+    depends_on: [app:payments]
+`)
+		store := NewAppStore(&Config{Dir: tmpDir})
+		app, err := store.loadYAMLApp("admin.yml", filepath.Join(tmpDir, "admin.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []AppDependency{{App: "auth"}, {App: "payments"}}
+		if app.Type != AppTypeCommand || !reflect.DeepEqual(app.AppDeps, want) {
+			t.Errorf("expected command app depending on %+v, got %+v", want, app.AppDeps)
+		}
+	})
+
+	t.Run("invalid entries", func(t *testing.T) {
+		tests := []struct {
+			yaml string
+			want string
+		}{
+			{"depends_on: [db]\ncmd: rails s", `"db" must be an app dependency`},
+			{"depends_on: [\"app:\"]\ncmd: rails s", `invalid app dependency "app:"`},
+			{"depends_on: [app:a:b:c]\ncmd: rails s", `invalid app dependency "app:a:b:c"`},
+			{"services:\n  web:\n    cmd: x\n    depends_on: [\"app:auth:\"]\n  api:\n    cmd: y", `services.web.depends_on: invalid app dependency`},
+			{"shared: true\nshared_grace: soon\ncmd: redis-server", `shared_grace: invalid duration "soon"`},
+			{"shared_grace: 1m\ncmd: redis-server", `shared_grace requires shared: true`},
+		}
+		store := NewAppStore(&Config{Dir: tmpDir})
+		for _, tt := range tests {
+			write("broken.yml", "root: /tmp\n"+tt.yaml+"\n")
+			_, err := store.loadYAMLApp("broken.yml", filepath.Join(tmpDir, "broken.yml"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%q: expected error containing %q, got %v", tt.yaml, tt.want, err)
+			}
+		}
+	})
+}
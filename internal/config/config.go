@@ -4,11 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/panozzaj/fireup/internal/backend"
+	"github.com/panozzaj/fireup/internal/inspect"
+	"github.com/panozzaj/fireup/internal/limits"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,8 +25,11 @@ type Config struct {
 	HTTPSPort     int
 	URLPort       int // Port to use in generated URLs (for pf forwarding)
 	TLD           string
+	FixedTLD      bool // TLD was set with --tld, so config.json changes don't override it
+	DNSPort       int  // Port for the built-in DNS server (0 to disable)
 	Ollama        *OllamaConfig
 	ClaudeCommand string // Command to run Claude Code (default: "claude")
+	Version       string // fireup's version, e.g. for exported HAR files
 }
 
 // OllamaConfig stores settings for local LLM error analysis
@@ -41,8 +50,59 @@ type App struct {
 	Dir         string    // Working directory
 	FilePath    string    // For static file serving
 	Services    []Service // For multi-service YAML configs
+	Routes      []Route   // Path prefixes served by specific services, longest first
 	Env         map[string]string
-	Hidden      bool // If true, hide from dashboard (still accessible via URL)
+	Hidden      bool   // If true, hide from dashboard (still accessible via URL)
+	Group       string // Group directory the config was loaded from (empty at the top level)
+	Profiles    map[string]*Profile
+	Profile     string   // Active profile name (empty for the default)
+	base        *App     // Configuration without the active profile applied
+	sources     []string // Config files this app was built from (YAML apps)
+
+	// RestartDependents restarts services that depend on a service whose
+	// config changed, e.g. so they pick up its new ${port:...}
+	RestartDependents bool
+
+	// AppDeps are other apps (or their services) to start before this app.
+	// Services can add their own with depends_on: [app:...].
+	AppDeps []AppDependency
+	// StopDependencies stops AppDeps again when this app stops, unless
+	// another running app still depends on them
+	StopDependencies bool
+
+	// Shared apps are started by their dependents and stopped SharedGrace
+	// after the last one stops (see process.Manager.Acquire)
+	Shared      bool
+	SharedGrace time.Duration
+
+	// PauseAfter pauses the app's processes (SIGSTOP) after this long
+	// without requests. The next request resumes them. 0 never pauses.
+	PauseAfter time.Duration
+
+	// PortAuto detects the port the command listens on, for commands that
+	// ignore $PORT. PortPattern optionally matches a log line announcing it.
+	PortAuto    bool
+	PortPattern string
+
+	// Host is the loopback address the app listens on: backend.IPv4,
+	// backend.IPv6, or backend.Auto (also when empty) to try both
+	Host string
+
+	// PIDFile is where a command that daemonizes writes the PID to supervise
+	// after it exits. LogFile is tailed into the logs. Both are absolute.
+	PIDFile string
+	LogFile string
+
+	// Limits caps the memory, CPU and open files of the command
+	Limits limits.Limits
+
+	// Inspect captures the app's recent requests and responses (inspect:),
+	// nil if off
+	Inspect *inspect.Options
+
+	// RestartStrategy is how the command restarts: RestartReplace (also
+	// when empty) or RestartOverlap
+	RestartStrategy string
 }
 
 // Service represents a service within a multi-service app
@@ -52,8 +112,30 @@ type Service struct {
 	Command   string
 	Port      int // Assigned dynamically
 	Env       map[string]string
-	Default   bool     // If true, this service handles requests to the base app URL
-	DependsOn []string // Names of services that must start first
+	Default   bool            // If true, this service handles requests to the base app URL
+	DependsOn []string        // Names of services that must start first
+	AppDeps   []AppDependency // Other apps or their services that must start first
+
+	PortAuto    bool   // Detect the port the command listens on (port: auto)
+	PortPattern string // Log line announcing the port, with the port as first group
+	Host        string // Loopback address, see App.Host
+	PIDFile     string // For commands that daemonize, see App.PIDFile
+	LogFile     string // File to tail into the logs
+
+	Limits limits.Limits // Includes the limits set at the app level
+
+	RestartStrategy string // See App.RestartStrategy; defaults to the app's
+
+	// Scale runs this many instances of the service, each on its own port,
+	// with requests spread across them as Balance says (BalanceRoundRobin
+	// when empty). 0 and 1 run a single process.
+	Scale   int
+	Balance string
+
+	// TCPListen is a stable local port (tcp: listen) relayed to the
+	// service's port, for clients that can't use its hostname or follow
+	// its port. 0 when not set.
+	TCPListen int
 }
 
 // AppType indicates how to handle the app
@@ -66,52 +148,89 @@ const (
 	AppTypeYAML                   // Multi-service YAML config
 )
 
-// AppStore manages loaded app configurations
+// AppStore manages loaded app configurations. Readers use the current
+// Snapshot without locking; writers build a new one and swap it in.
 type AppStore struct {
-	mu   sync.RWMutex
-	apps map[string]*App
-	cfg  *Config
+	mu       sync.Mutex // Serializes loads and other writers
+	snapshot atomic.Pointer[Snapshot]
+	cfg      *Config
+	active   map[string]string // App name → active profile
 }
 
 // NewAppStore creates a new app store
 func NewAppStore(cfg *Config) *AppStore {
-	return &AppStore{
-		apps: make(map[string]*App),
-		cfg:  cfg,
+	s := &AppStore{
+		cfg:    cfg,
+		active: make(map[string]string),
 	}
+	s.snapshot.Store(&Snapshot{apps: make(map[string]*App)})
+	return s
 }
 
 // Load reads all configurations from the config directory
 func (s *AppStore) Load() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	_, err := s.Reload()
+	return err
+}
 
-	entries, err := os.ReadDir(s.cfg.Dir)
-	if err != nil {
+// loadAll reads every app in the config directory into a new map.
+// Caller must hold s.mu.
+func (s *AppStore) loadAll() (map[string]*App, error) {
+	apps := make(map[string]*App)
+	if err := s.loadDir(s.cfg.Dir, "", apps); err != nil {
 		if os.IsNotExist(err) {
-			return nil
+			return apps, nil
 		}
-		return fmt.Errorf("reading config dir: %w", err)
+		return nil, fmt.Errorf("reading config dir: %w", err)
+	}
+	return apps, nil
+}
+
+// loadDir loads the apps in dir, then the apps in its group directories.
+// group is dir relative to the config directory. An app name that is
+// already taken by another group is skipped with a warning.
+func (s *AppStore) loadDir(dir, group string, apps map[string]*App) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
 	}
 
+	var groups []string
 	for _, entry := range entries {
 		name := entry.Name()
-		path := filepath.Join(s.cfg.Dir, name)
+		path := filepath.Join(dir, name)
 
 		// Skip hidden files, config files (config.json, config-*.json), and certs directory
 		if strings.HasPrefix(name, ".") || name == "config.json" || strings.HasPrefix(name, "config-") || name == "certs" {
 			continue
 		}
 
+		if entry.IsDir() && IsGroupDir(path) {
+			groups = append(groups, name)
+			continue
+		}
+
 		app, err := s.loadApp(name, path)
 		if err != nil {
-			fmt.Printf("Warning: failed to load %s: %v\n", name, err)
+			fmt.Printf("Warning: failed to load %s: %v\n", filepath.Join(group, name), err)
 			continue
 		}
+		app.Group = group
 
-		s.apps[app.Name] = app
+		if existing, ok := apps[app.Name]; ok && existing.Group != group {
+			fmt.Printf("Warning: skipping %s: app %q is already defined in %s\n",
+				filepath.Join(group, name), app.Name, groupLabel(existing.Group))
+			continue
+		}
+		apps[app.Name] = s.applyActiveProfile(app)
 	}
 
+	for _, name := range groups {
+		sub := filepath.ToSlash(filepath.Join(group, name))
+		if err := s.loadDir(filepath.Join(dir, name), sub, apps); err != nil {
+			fmt.Printf("Warning: failed to load group %s: %v\n", sub, err)
+		}
+	}
 	return nil
 }
 
@@ -133,6 +252,13 @@ func (s *AppStore) loadApp(name, path string) (*App, error) {
 			home, _ := os.UserHomeDir()
 			target = filepath.Join(home, target[1:])
 		}
+		// A symlink to a Procfile runs its processes as services
+		if isProcfile(filepath.Base(target)) {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			return s.loadProcfileApp(name, path, target)
+		}
 		return s.loadStaticApp(name, target)
 	}
 
@@ -164,33 +290,131 @@ func (s *AppStore) loadStaticApp(name, path string) (*App, error) {
 	}, nil
 }
 
+// yamlService is the YAML form of a service
+type yamlService struct {
+	Dir       string            `yaml:"dir"`
+	Command   string            `yaml:"cmd"`
+	Env       map[string]string `yaml:"env"`
+	Default   bool              `yaml:"default"`
+	DependsOn []string          `yaml:"depends_on"`
+
+	Port        string     `yaml:"port"`         // Only "auto"
+	PortPattern string     `yaml:"port_pattern"` // Log line announcing the port
+	Host        string     `yaml:"host"`         // Overrides the app's host
+	PIDFile     string     `yaml:"pidfile"`      // Relative to the service's dir
+	LogFile     string     `yaml:"logfile"`      // Relative to the service's dir
+	Limits      yamlLimits `yaml:"limits"`       // Overrides the app's limits one by one
+
+	RestartStrategy string `yaml:"restart_strategy"` // Overrides the app's restart_strategy
+
+	Scale   int    `yaml:"scale"`   // Instances to run
+	Balance string `yaml:"balance"` // How requests are spread across them
+
+	TCP *yamlForward `yaml:"tcp"` // Stable local TCP port
+
+	appDeps []AppDependency // app: entries split off from DependsOn
+	limits  limits.Limits   // Parsed Limits, with the app's as defaults
+}
+
+// yamlForward is the YAML form of tcp:
+type yamlForward struct {
+	Listen int `yaml:"listen"` // Local port relayed to the service's port
+}
+
+// yamlLimits is the YAML form of resource limits
+type yamlLimits struct {
+	Memory  string `yaml:"memory"`   // e.g. 2G
+	CPU     string `yaml:"cpu"`      // Percent of one core, e.g. 150%
+	CPUTime string `yaml:"cpu_time"` // Processor time of each process, e.g. 10m
+	NoFile  uint64 `yaml:"nofile"`
+}
+
+// yamlInspect is the YAML form of request capture: true, or a mapping of
+// its settings
+type yamlInspect struct {
+	Enabled     bool     `yaml:"-"`
+	MaxRequests int      `yaml:"max_requests"`
+	MaxBody     int      `yaml:"max_body"` // Bytes
+	Redact      []string `yaml:"redact"`   // Replaces inspect.DefaultRedact
+}
+
+// UnmarshalYAML accepts inspect: true as well as a mapping
+func (y *yamlInspect) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&y.Enabled)
+	}
+	type plain yamlInspect
+	y.Enabled = true
+	return node.Decode((*plain)(y))
+}
+
+// options returns the capture options, or nil if capture is off
+func (y yamlInspect) options() *inspect.Options {
+	if !y.Enabled {
+		return nil
+	}
+	return &inspect.Options{MaxRequests: y.MaxRequests, MaxBody: y.MaxBody, Redact: y.Redact}
+}
+
 // loadYAMLApp loads a YAML configuration (single or multi-service)
 func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
-	data, err := os.ReadFile(path)
+	doc, sources, err := s.readYAMLConfig(path)
 	if err != nil {
 		return nil, err
 	}
 
+	app, err := s.buildYAMLApp(name, doc)
+	if err != nil {
+		return nil, err
+	}
+	app.sources = append(sources, app.sources...)
+	return app, nil
+}
+
+// buildYAMLApp creates an app from a merged YAML config
+func (s *AppStore) buildYAMLApp(name string, doc *yaml.Node) (*App, error) {
+
 	var yamlCfg struct {
-		Name        string            `yaml:"name"`
-		Description string            `yaml:"description"`
-		Aliases     []string          `yaml:"aliases"`
-		Alias       string            `yaml:"alias"` // Single alias shorthand
-		Root        string            `yaml:"root"`
-		Static      bool              `yaml:"static"` // Serve static files from root
-		Command     string            `yaml:"cmd"`    // For single-service shorthand
-		Env         map[string]string `yaml:"env"`    // For single-service shorthand
-		Hidden      bool              `yaml:"hidden"` // Hide from dashboard
-		Services    map[string]struct {
-			Dir       string            `yaml:"dir"`
-			Command   string            `yaml:"cmd"`
-			Env       map[string]string `yaml:"env"`
-			Default   bool              `yaml:"default"`
-			DependsOn []string          `yaml:"depends_on"`
-		} `yaml:"services"`
-	}
-
-	if err := yaml.Unmarshal(data, &yamlCfg); err != nil {
+		Name        string                 `yaml:"name"`
+		Description string                 `yaml:"description"`
+		Aliases     []string               `yaml:"aliases"`
+		Alias       string                 `yaml:"alias"` // Single alias shorthand
+		Root        string                 `yaml:"root"`
+		Static      bool                   `yaml:"static"`   // Serve static files from root
+		Command     string                 `yaml:"cmd"`      // For single-service shorthand
+		Env         map[string]string      `yaml:"env"`      // For single-service shorthand
+		Hidden      bool                   `yaml:"hidden"`   // Hide from dashboard
+		Procfile    string                 `yaml:"procfile"` // Load services from a Procfile
+		Port        string                 `yaml:"port"`     // Fixed port (proxy only, or with cmd), or "auto"
+		Services    map[string]yamlService `yaml:"services"`
+		Profiles    map[string]yamlProfile `yaml:"profiles"`
+
+		Routes []yamlRoute `yaml:"routes"` // Path prefixes handled by other services than the default
+
+		RestartDependents bool `yaml:"restart_dependents"` // Restart dependents of changed services
+		StopDependencies  bool `yaml:"stop_dependencies"`  // Stop app dependencies along with this app
+
+		DependsOn []string `yaml:"depends_on"` // Other apps to start first (app:...)
+
+		Shared      bool   `yaml:"shared"`       // Lifetime follows the apps that depend on it
+		SharedGrace string `yaml:"shared_grace"` // How long to keep running after the last dependent, e.g. "1m"
+
+		PauseAfter string `yaml:"pause_after"` // Pause processes after this long without requests, e.g. "10m"
+
+		PortPattern string `yaml:"port_pattern"` // With port: auto, log line announcing the port
+		Host        string `yaml:"host"`         // 127.0.0.1, ::1 or auto
+
+		PIDFile string `yaml:"pidfile"` // Supervise the PID written here after cmd daemonizes
+		LogFile string `yaml:"logfile"` // Tail this file into the logs
+
+		Limits yamlLimits `yaml:"limits"` // Memory, CPU and open files limits
+
+		Inspect yamlInspect `yaml:"inspect"` // Capture recent requests and responses
+
+		RestartStrategy string `yaml:"restart_strategy"` // replace or overlap
+	}
+
+	if err := doc.Decode(&yamlCfg); err != nil {
 		return nil, fmt.Errorf("parsing YAML: %w", err)
 	}
 
@@ -200,12 +424,225 @@ func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
 		appName = strings.TrimSuffix(name, filepath.Ext(name))
 	}
 
-	// Expand ~ in root
-	root := yamlCfg.Root
+	vars := s.newInterpolator(appName, nil)
+
+	// Expand variables and ~ in root
+	root := vars.expand(yamlCfg.Root, "root")
 	if strings.HasPrefix(root, "~") {
 		home, _ := os.UserHomeDir()
 		root = filepath.Join(home, root[1:])
 	}
+	vars.vars["ROOT"] = root
+
+	// Services from a Procfile
+	var sources []string
+	if yamlCfg.Procfile != "" {
+		if yamlCfg.Command != "" {
+			return nil, fmt.Errorf("procfile and cmd cannot be combined")
+		}
+		if yamlCfg.Services == nil {
+			yamlCfg.Services = make(map[string]yamlService)
+		}
+		procfilePath, err := addProcfileServices(yamlCfg.Services, vars.expand(yamlCfg.Procfile, "procfile"), root)
+		if err != nil {
+			return nil, err
+		}
+		if root == "" {
+			root = filepath.Dir(procfilePath)
+			vars.vars["ROOT"] = root
+		}
+		sources = append(sources, procfilePath)
+	}
+
+	// Services that ${url:...} and ${port:...} may reference
+	if len(yamlCfg.Services) > 1 {
+		for svcName := range yamlCfg.Services {
+			vars.services[svcName] = true
+		}
+	}
+
+	// port is a fixed port, or auto to detect the port a command picks itself
+	var port int
+	portAuto := yamlCfg.Port == PortAuto
+	if yamlCfg.Port != "" && !portAuto {
+		var err error
+		port, err = strconv.Atoi(yamlCfg.Port)
+		if err != nil || port < 1 || port > 65535 {
+			vars.errs = append(vars.errs, fmt.Sprintf("port: %s is not a valid port", yamlCfg.Port))
+		}
+		if yamlCfg.Static {
+			vars.errs = append(vars.errs, "port cannot be combined with static: true")
+		}
+		if len(yamlCfg.Services) > 1 {
+			vars.errs = append(vars.errs, "port requires a single-service app (each service gets its own port)")
+		}
+	}
+	if portAuto && (yamlCfg.Static || (yamlCfg.Command == "" && len(yamlCfg.Services) != 1)) {
+		vars.errs = append(vars.errs, "port: auto requires cmd (set it per service in multi-service apps)")
+	}
+	checkPortPattern(portAuto, yamlCfg.PortPattern, "", vars)
+	checkHost(yamlCfg.Host, "", vars)
+	if (yamlCfg.PIDFile != "" || yamlCfg.LogFile != "") && (yamlCfg.Static || (yamlCfg.Command == "" && len(yamlCfg.Services) != 1)) {
+		vars.errs = append(vars.errs, "pidfile and logfile require cmd (set them per service in multi-service apps)")
+	}
+
+	// Shared apps outlive their last dependent by a grace period
+	var sharedGrace time.Duration
+	if yamlCfg.SharedGrace != "" {
+		grace, err := time.ParseDuration(yamlCfg.SharedGrace)
+		if err != nil || grace < 0 {
+			vars.errs = append(vars.errs, fmt.Sprintf("shared_grace: invalid duration %q", yamlCfg.SharedGrace))
+		}
+		sharedGrace = grace
+		if !yamlCfg.Shared {
+			vars.errs = append(vars.errs, "shared_grace requires shared: true")
+		}
+	}
+
+	// Idle processes are paused rather than stopped
+	var pauseAfter time.Duration
+	if yamlCfg.PauseAfter != "" {
+		d, err := time.ParseDuration(yamlCfg.PauseAfter)
+		if err != nil || d <= 0 {
+			vars.errs = append(vars.errs, fmt.Sprintf("pause_after: invalid duration %q", yamlCfg.PauseAfter))
+		}
+		pauseAfter = d
+		if yamlCfg.Static || (yamlCfg.Command == "" && len(yamlCfg.Services) == 0) {
+			vars.errs = append(vars.errs, "pause_after requires cmd or services")
+		}
+	}
+
+	// Root-level limits are the defaults for every service
+	appLimits := parseLimits(yamlCfg.Limits, "", vars)
+	if !appLimits.IsZero() && (yamlCfg.Static || (yamlCfg.Command == "" && len(yamlCfg.Services) == 0)) {
+		vars.errs = append(vars.errs, "limits requires cmd or services")
+	}
+
+	// Overlapping instances need their own ports and processes
+	checkRestartStrategy(yamlCfg.RestartStrategy, "", vars)
+	if yamlCfg.RestartStrategy != "" && (yamlCfg.Static || (yamlCfg.Command == "" && len(yamlCfg.Services) == 0)) {
+		vars.errs = append(vars.errs, "restart_strategy requires cmd or services")
+	}
+	if yamlCfg.RestartStrategy == RestartOverlap && port != 0 {
+		vars.errs = append(vars.errs, "restart_strategy: overlap can't be combined with a fixed port (the new instance needs its own)")
+	}
+	if yamlCfg.RestartStrategy == RestartOverlap && yamlCfg.PIDFile != "" {
+		vars.errs = append(vars.errs, "restart_strategy: overlap can't be combined with pidfile")
+	}
+
+	// Captured requests are only those fireup proxies
+	appInspect := yamlCfg.Inspect.options()
+	if appInspect != nil {
+		if yamlCfg.Static {
+			vars.errs = append(vars.errs, "inspect requires cmd, port or services")
+		}
+		if appInspect.MaxRequests < 0 || appInspect.MaxBody < 0 {
+			vars.errs = append(vars.errs, "inspect: max_requests and max_body can't be negative")
+		}
+	}
+
+	// Top-level depends_on can only point at other apps
+	localDeps, appDeps := splitDependsOn(yamlCfg.DependsOn, "depends_on", vars)
+	for _, dep := range localDeps {
+		vars.errs = append(vars.errs, fmt.Sprintf("depends_on: %q must be an app dependency (app:<name>); list services under services.<name>.depends_on", dep))
+	}
+
+	// Expand variables in commands, dirs and env values
+	yamlCfg.Command = vars.expand(yamlCfg.Command, "cmd")
+	yamlCfg.PIDFile = vars.expand(yamlCfg.PIDFile, "pidfile")
+	yamlCfg.LogFile = vars.expand(yamlCfg.LogFile, "logfile")
+	yamlCfg.Env = vars.expandMap(yamlCfg.Env, "env")
+	for svcName, svcCfg := range yamlCfg.Services {
+		where := "services." + svcName
+		svcCfg.Command = vars.expand(svcCfg.Command, where+".cmd")
+		svcCfg.Dir = vars.expand(svcCfg.Dir, where+".dir")
+		svcCfg.PIDFile = vars.expand(svcCfg.PIDFile, where+".pidfile")
+		svcCfg.LogFile = vars.expand(svcCfg.LogFile, where+".logfile")
+		svcCfg.Env = vars.expandMap(svcCfg.Env, where+".env")
+		svcCfg.DependsOn, svcCfg.appDeps = splitDependsOn(svcCfg.DependsOn, where+".depends_on", vars)
+		if svcCfg.Port != "" && svcCfg.Port != PortAuto {
+			vars.errs = append(vars.errs, fmt.Sprintf("%s.port: only auto is supported (services get their own port)", where))
+		}
+		checkPortPattern(svcCfg.Port == PortAuto, svcCfg.PortPattern, where+".", vars)
+		checkHost(svcCfg.Host, where+".", vars)
+		if svcCfg.Host == "" {
+			svcCfg.Host = yamlCfg.Host
+		}
+		svcCfg.limits = parseLimits(svcCfg.Limits, where+".", vars).Or(appLimits)
+		checkRestartStrategy(svcCfg.RestartStrategy, where+".", vars)
+		if svcCfg.RestartStrategy == RestartOverlap && (port != 0 || svcCfg.PIDFile != "") {
+			vars.errs = append(vars.errs, where+".restart_strategy: overlap can't be combined with a fixed port or pidfile")
+		}
+		if svcCfg.RestartStrategy == "" {
+			svcCfg.RestartStrategy = yamlCfg.RestartStrategy
+		}
+		checkScale(svcCfg, port, where+".", vars)
+		checkForward(svcCfg.TCP, where+".tcp.", vars)
+
+		// ${port:...} is resolved at process start, so the service must start after its target
+		var refs []string
+		refs = append(refs, PortRefs(svcCfg.Command)...)
+		for _, v := range svcCfg.Env {
+			refs = append(refs, PortRefs(v)...)
+		}
+		for _, ref := range refs {
+			if !containsString(svcCfg.DependsOn, ref) {
+				vars.errs = append(vars.errs, fmt.Sprintf("${port:%s} in %s requires %q in depends_on", ref, where, ref))
+			}
+		}
+		yamlCfg.Services[svcName] = svcCfg
+	}
+
+	// Overlapping restarts move a service to a new port, which ${port:...}
+	// resolved at start can't follow, and scaled services have one port per
+	// instance
+	for svcName, svcCfg := range yamlCfg.Services {
+		refs := PortRefs(svcCfg.Command)
+		for _, v := range svcCfg.Env {
+			refs = append(refs, PortRefs(v)...)
+		}
+		for _, ref := range refs {
+			target, ok := yamlCfg.Services[ref]
+			if ok && target.RestartStrategy == RestartOverlap {
+				vars.errs = append(vars.errs, fmt.Sprintf("${port:%s} in services.%s can't follow %s's restart_strategy: overlap, which changes its port; use ${url:%s}", ref, svcName, ref, ref))
+			}
+			if ok && target.Scale > 1 {
+				vars.errs = append(vars.errs, fmt.Sprintf("${port:%s} in services.%s is ambiguous with %s's scale: %d; use ${url:%s}", ref, svcName, ref, target.Scale, ref))
+			}
+		}
+	}
+
+	// Two services can't relay the same local port
+	svcNames := make([]string, 0, len(yamlCfg.Services))
+	for svcName := range yamlCfg.Services {
+		svcNames = append(svcNames, svcName)
+	}
+	sort.Strings(svcNames)
+	forwarded := make(map[int]string)
+	for _, svcName := range svcNames {
+		listen := yamlCfg.Services[svcName].tcpListen()
+		if listen == 0 {
+			continue
+		}
+		if other, taken := forwarded[listen]; taken {
+			vars.errs = append(vars.errs, fmt.Sprintf("services.%s.tcp: listen: %d is already used by services.%s", svcName, listen, other))
+		}
+		forwarded[listen] = svcName
+	}
+
+	// Profiles may only reference existing services
+	serviceDeps := make(map[string][]string)
+	for svcName, svcCfg := range yamlCfg.Services {
+		serviceDeps[svcName] = svcCfg.DependsOn
+	}
+	profiles := buildProfiles(yamlCfg.Profiles, serviceDeps, vars)
+	routes := buildRoutes(yamlCfg.Routes, serviceDeps, vars)
+	if len(yamlCfg.Routes) > 0 && len(yamlCfg.Services) < 2 {
+		vars.errs = append(vars.errs, "routes requires a multi-service app")
+	}
+	if err := vars.err(); err != nil {
+		return nil, err
+	}
 
 	// Merge alias and aliases
 	aliases := yamlCfg.Aliases
@@ -215,6 +652,15 @@ func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
 
 	// Static file serving: static: true
 	if yamlCfg.Static {
+		if len(profiles) > 0 {
+			return nil, fmt.Errorf("profiles are not supported with static: true")
+		}
+		if len(appDeps) > 0 {
+			return nil, fmt.Errorf("depends_on is not supported with static: true")
+		}
+		if yamlCfg.Shared {
+			return nil, fmt.Errorf("shared is not supported with static: true")
+		}
 		if root == "" {
 			return nil, fmt.Errorf("static: true requires root to be set")
 		}
@@ -229,6 +675,7 @@ func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
 			Type:        AppTypeStatic,
 			FilePath:    root,
 			Hidden:      yamlCfg.Hidden,
+			sources:     sources,
 		}, nil
 	}
 
@@ -239,33 +686,100 @@ func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
 			Description: yamlCfg.Description,
 			Aliases:     aliases,
 			Type:        AppTypeCommand,
-			Command:     yamlCfg.Command,
+			Port:        port,
+			Command:     unescapePorts(yamlCfg.Command),
 			Dir:         root,
-			Env:         yamlCfg.Env,
+			Env:         unescapePortsMap(yamlCfg.Env),
 			Hidden:      yamlCfg.Hidden,
+			sources:     sources,
+			Profiles:    unescapeProfilePorts(profiles),
+
+			AppDeps:          appDeps,
+			StopDependencies: yamlCfg.StopDependencies,
+			Shared:           yamlCfg.Shared,
+			SharedGrace:      sharedGrace,
+			PauseAfter:       pauseAfter,
+			PortAuto:         portAuto,
+			PortPattern:      yamlCfg.PortPattern,
+			Host:             yamlCfg.Host,
+			PIDFile:          resolvePath(root, yamlCfg.PIDFile),
+			LogFile:          resolvePath(root, yamlCfg.LogFile),
+			Limits:           appLimits,
+			Inspect:          appInspect,
+			RestartStrategy:  yamlCfg.RestartStrategy,
 		}, nil
 	}
 
-	// Single service in services map → treat as simple command
+	// Single service in services map → treat as simple command, unless
+	// it's scaled or forwards a port
 	if len(yamlCfg.Services) == 1 {
-		for _, svcCfg := range yamlCfg.Services {
+		for svcName, svcCfg := range yamlCfg.Services {
+			if svcCfg.Scale > 1 || svcCfg.TCP != nil {
+				break
+			}
 			svcDir := root
 			if svcCfg.Dir != "" {
 				svcDir = filepath.Join(root, svcCfg.Dir)
 			}
+			portPattern := yamlCfg.PortPattern
+			if svcCfg.PortPattern != "" {
+				portPattern = svcCfg.PortPattern
+			}
+			pidFile, logFile := resolvePath(root, yamlCfg.PIDFile), resolvePath(root, yamlCfg.LogFile)
+			if svcCfg.PIDFile != "" {
+				pidFile = resolvePath(svcDir, svcCfg.PIDFile)
+			}
+			if svcCfg.LogFile != "" {
+				logFile = resolvePath(svcDir, svcCfg.LogFile)
+			}
 			return &App{
 				Name:        appName,
 				Description: yamlCfg.Description,
 				Aliases:     aliases,
 				Type:        AppTypeCommand,
-				Command:     svcCfg.Command,
+				Port:        port,
+				Command:     unescapePorts(svcCfg.Command),
 				Dir:         svcDir,
-				Env:         svcCfg.Env,
+				Env:         unescapePortsMap(svcCfg.Env),
 				Hidden:      yamlCfg.Hidden,
+				sources:     sources,
+				Profiles:    unescapeProfilePorts(flattenProfiles(profiles, svcName)),
+
+				AppDeps:          append(appDeps, svcCfg.appDeps...),
+				StopDependencies: yamlCfg.StopDependencies,
+				Shared:           yamlCfg.Shared,
+				SharedGrace:      sharedGrace,
+				PauseAfter:       pauseAfter,
+				PortAuto:         portAuto || svcCfg.Port == PortAuto,
+				PortPattern:      portPattern,
+				Host:             svcCfg.Host,
+				PIDFile:          pidFile,
+				LogFile:          logFile,
+				Limits:           svcCfg.limits,
+				Inspect:          appInspect,
+				RestartStrategy:  svcCfg.RestartStrategy,
 			}, nil
 		}
 	}
 
+	// Fixed port without a command: proxy to whatever listens there
+	if port != 0 && len(yamlCfg.Services) == 0 {
+		if len(profiles) > 0 || len(appDeps) > 0 || yamlCfg.Shared {
+			return nil, fmt.Errorf("port without cmd only proxies; profiles, depends_on and shared need cmd")
+		}
+		return &App{
+			Name:        appName,
+			Description: yamlCfg.Description,
+			Aliases:     aliases,
+			Type:        AppTypePort,
+			Port:        port,
+			Hidden:      yamlCfg.Hidden,
+			sources:     sources,
+			Host:        yamlCfg.Host,
+			Inspect:     appInspect,
+		}, nil
+	}
+
 	// Multi-service
 	var services []Service
 	for svcName, svcCfg := range yamlCfg.Services {
@@ -287,6 +801,20 @@ func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
 			Env:       svcCfg.Env,
 			Default:   svcCfg.Default,
 			DependsOn: svcCfg.DependsOn,
+			AppDeps:   svcCfg.appDeps,
+
+			PortAuto:    svcCfg.Port == PortAuto,
+			PortPattern: svcCfg.PortPattern,
+			Host:        svcCfg.Host,
+			PIDFile:     resolvePath(svcDir, svcCfg.PIDFile),
+			LogFile:     resolvePath(svcDir, svcCfg.LogFile),
+			Limits:      svcCfg.limits,
+
+			RestartStrategy: svcCfg.RestartStrategy,
+			Scale:           svcCfg.Scale,
+			Balance:         svcCfg.Balance,
+
+			TCPListen: svcCfg.tcpListen(),
 		})
 	}
 
@@ -300,10 +828,152 @@ func (s *AppStore) loadYAMLApp(name, path string) (*App, error) {
 		Type:        AppTypeYAML,
 		Dir:         root,
 		Services:    services,
+		Routes:      routes,
 		Hidden:      yamlCfg.Hidden,
+		sources:     sources,
+		Profiles:    profiles,
+
+		RestartDependents: yamlCfg.RestartDependents,
+		AppDeps:           appDeps,
+		StopDependencies:  yamlCfg.StopDependencies,
+		Shared:            yamlCfg.Shared,
+		SharedGrace:       sharedGrace,
+		PauseAfter:        pauseAfter,
+		Inspect:           appInspect,
 	}, nil
 }
 
+// PortAuto is the port setting for commands that ignore $PORT
+const PortAuto = "auto"
+
+// Restart strategies (restart_strategy:)
+const (
+	RestartReplace = "replace" // Stop the process, then start the new one
+	RestartOverlap = "overlap" // Start the new one and stop the old one once it's ready
+)
+
+// checkRestartStrategy validates a restart_strategy setting. prefix
+// locates it in errors.
+func checkRestartStrategy(strategy, prefix string, vars *interpolator) {
+	if strategy != "" && strategy != RestartReplace && strategy != RestartOverlap {
+		vars.errs = append(vars.errs, fmt.Sprintf("%srestart_strategy: %q must be %s or %s", prefix, strategy, RestartReplace, RestartOverlap))
+	}
+}
+
+// Balancing policies of scaled services (balance:)
+const (
+	BalanceRoundRobin       = "round-robin"       // Each instance in turn
+	BalanceLeastConnections = "least-connections" // The instance with the fewest requests in flight
+	BalanceSticky           = "sticky"            // The same instance for each client, with a cookie
+)
+
+// checkScale validates the scale and balance settings of a service. port
+// is the app's fixed port, if any. prefix locates them in errors.
+func checkScale(svc yamlService, port int, prefix string, vars *interpolator) {
+	switch svc.Balance {
+	case "", BalanceRoundRobin, BalanceLeastConnections, BalanceSticky:
+	default:
+		vars.errs = append(vars.errs, fmt.Sprintf("%sbalance: %q must be %s, %s or %s", prefix, svc.Balance, BalanceRoundRobin, BalanceLeastConnections, BalanceSticky))
+	}
+	if svc.Scale < 0 {
+		vars.errs = append(vars.errs, fmt.Sprintf("%sscale: %d must be at least 1", prefix, svc.Scale))
+	}
+	if svc.Scale <= 1 {
+		if svc.Balance != "" {
+			vars.errs = append(vars.errs, prefix+"balance requires scale")
+		}
+		return
+	}
+	// Each instance needs its own port and process
+	if port != 0 || svc.PIDFile != "" {
+		vars.errs = append(vars.errs, prefix+"scale can't be combined with a fixed port or pidfile")
+	}
+	if svc.RestartStrategy == RestartOverlap {
+		vars.errs = append(vars.errs, prefix+"scale can't be combined with restart_strategy: overlap")
+	}
+	if svc.TCP != nil {
+		vars.errs = append(vars.errs, prefix+"scale can't be combined with tcp")
+	}
+}
+
+// checkForward validates tcp: of a service. prefix locates the
+// setting in errors.
+func checkForward(fwd *yamlForward, prefix string, vars *interpolator) {
+	if fwd != nil && (fwd.Listen < 1 || fwd.Listen > 65535) {
+		vars.errs = append(vars.errs, fmt.Sprintf("%slisten: %d must be a port between 1 and 65535", prefix, fwd.Listen))
+	}
+}
+
+// tcpListen returns the local port of tcp:, or 0
+func (svc yamlService) tcpListen() int {
+	if svc.TCP == nil {
+		return 0
+	}
+	return svc.TCP.Listen
+}
+
+// checkPortPattern validates port_pattern, which needs port: auto and a
+// capture group for the port. prefix locates the setting in errors.
+func checkPortPattern(auto bool, pattern, prefix string, vars *interpolator) {
+	if pattern == "" {
+		return
+	}
+	if !auto {
+		vars.errs = append(vars.errs, prefix+"port_pattern requires port: auto")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		vars.errs = append(vars.errs, fmt.Sprintf("%sport_pattern: %v", prefix, err))
+	} else if re.NumSubexp() < 1 {
+		vars.errs = append(vars.errs, prefix+"port_pattern needs a group capturing the port, e.g. (\\d+)")
+	}
+}
+
+// resolvePath makes a path from the config absolute, relative to dir.
+// Supports ~. Empty stays empty.
+func resolvePath(dir, path string) string {
+	if path == "" {
+		return ""
+	}
+	if strings.HasPrefix(path, "~") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// checkHost validates a host setting. prefix locates it in errors.
+func checkHost(host, prefix string, vars *interpolator) {
+	if !backend.Valid(host) {
+		vars.errs = append(vars.errs, fmt.Sprintf("%shost: %q must be %s, %s or %s", prefix, host, backend.IPv4, backend.IPv6, backend.Auto))
+	}
+}
+
+// parseLimits parses resource limits. prefix locates them in errors.
+func parseLimits(y yamlLimits, prefix string, vars *interpolator) limits.Limits {
+	l := limits.Limits{NoFile: y.NoFile}
+	var err error
+	if y.Memory != "" {
+		if l.Memory, err = limits.ParseMemory(y.Memory); err != nil {
+			vars.errs = append(vars.errs, fmt.Sprintf("%slimits.memory: %v", prefix, err))
+		}
+	}
+	if y.CPU != "" {
+		if l.CPU, err = limits.ParseCPU(y.CPU); err != nil {
+			vars.errs = append(vars.errs, fmt.Sprintf("%slimits.cpu: %v", prefix, err))
+		}
+	}
+	if y.CPUTime != "" {
+		if l.CPUTime, err = limits.ParseCPUTime(y.CPUTime); err != nil {
+			vars.errs = append(vars.errs, fmt.Sprintf("%slimits.cpu_time: %v", prefix, err))
+		}
+	}
+	return l
+}
+
 // loadSimpleApp loads a simple config file (port number, command, or path)
 func (s *AppStore) loadSimpleApp(name, path string) (*App, error) {
 	data, err := os.ReadFile(path)
@@ -347,98 +1017,85 @@ func (s *AppStore) loadSimpleApp(name, path string) (*App, error) {
 	}, nil
 }
 
+// Snapshot returns the current set of apps
+func (s *AppStore) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
 // Get returns an app by name
 func (s *AppStore) Get(name string) (*App, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	app, ok := s.apps[name]
-	return app, ok
+	return s.Snapshot().Get(name)
 }
 
 // GetByNameOrAlias returns an app by name or alias
 func (s *AppStore) GetByNameOrAlias(nameOrAlias string) (*App, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	// Try direct name first
-	if app, ok := s.apps[nameOrAlias]; ok {
-		return app, true
-	}
-
-	// Search aliases
-	for _, app := range s.apps {
-		for _, alias := range app.Aliases {
-			if alias == nameOrAlias {
-				return app, true
-			}
-		}
-	}
-
-	return nil, false
+	return s.Snapshot().GetByNameOrAlias(nameOrAlias)
 }
 
 // GetService returns a specific service from a multi-service app
 func (s *AppStore) GetService(appName, serviceName string) (*App, *Service, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	// Try exact match first
-	app, ok := s.apps[appName]
-
-	// If not found, try aliases
-	if !ok {
-		for _, a := range s.apps {
-			for _, alias := range a.Aliases {
-				if alias == appName {
-					app = a
-					ok = true
-					break
-				}
-			}
-			if ok {
-				break
-			}
-		}
-	}
-
-	if !ok || app.Type != AppTypeYAML {
-		return nil, nil, false
-	}
-
-	for i := range app.Services {
-		if app.Services[i].Name == serviceName {
-			return app, &app.Services[i], true
-		}
-	}
-
-	return app, nil, false
+	return s.Snapshot().GetService(appName, serviceName)
 }
 
 // All returns all loaded apps sorted alphabetically
 func (s *AppStore) All() []*App {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	return s.Snapshot().All()
+}
 
-	apps := make([]*App, 0, len(s.apps))
-	for _, app := range s.apps {
-		apps = append(apps, app)
-	}
+// SetTLD changes the TLD used when expanding ${TLD} and ${url:...}.
+// Call Reload afterwards to apply it to loaded apps.
+func (s *AppStore) SetTLD(tld string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.TLD = tld
+}
 
-	sort.Slice(apps, func(i, j int) bool {
-		return apps[i].Name < apps[j].Name
-	})
+// Reload rereads all configurations and swaps them in as a new snapshot.
+// Lookups keep seeing the previous apps until the new set is complete, and
+// a failed reload leaves them in place. Returns what changed.
+func (s *AppStore) Reload() (Diff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reload()
+}
 
-	return apps
+// reload is Reload for callers that hold s.mu
+func (s *AppStore) reload() (Diff, error) {
+	apps, err := s.loadAll()
+	if err != nil {
+		return Diff{}, err
+	}
+	old := s.snapshot.Load()
+	return diffSnapshots(old, s.publish(apps, time.Now())), nil
 }
 
-// Reload refreshes the app configurations
-func (s *AppStore) Reload() error {
-	// Clear existing
+// ReloadOnMiss reloads after a lookup found no app, at most once per
+// MissReloadInterval, so repeated requests for an unknown host don't reread
+// the config directory each time. Returns true if the apps changed.
+func (s *AppStore) ReloadOnMiss() bool {
+	if time.Since(s.Snapshot().LoadedAt) < MissReloadInterval {
+		return false
+	}
+
 	s.mu.Lock()
-	s.apps = make(map[string]*App)
-	s.mu.Unlock()
+	defer s.mu.Unlock()
 
-	return s.Load()
+	// Another caller may have reloaded while we waited
+	if time.Since(s.snapshot.Load().LoadedAt) < MissReloadInterval {
+		return false
+	}
+	diff, err := s.reload()
+	return err == nil && !diff.Empty()
+}
+
+// containsString returns true if list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 // topologicalSort orders services so dependencies come before dependents
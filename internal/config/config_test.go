@@ -3,7 +3,13 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/inspect"
+	"github.com/panozzaj/fireup/internal/limits"
 )
 
 func TestLoadSimpleApp(t *testing.T) {
@@ -182,6 +188,391 @@ env:
 			t.Errorf("expected RAILS_ENV=development, got %s", app.Env["RAILS_ENV"])
 		}
 	})
+
+	t.Run("parses fixed port with cmd", func(t *testing.T) {
+		yaml := `
+root: /tmp/legacy
+port: 3000
+cmd: npm start
+`
+		path := filepath.Join(tmpDir, "legacy.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+
+		app, err := store.loadYAMLApp("legacy.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Type != AppTypeCommand || app.Port != 3000 {
+			t.Errorf("expected command app on port 3000, got type %v port %d", app.Type, app.Port)
+		}
+	})
+
+	t.Run("parses fixed port without cmd", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "external.yml")
+		os.WriteFile(path, []byte("port: 8080\n"), 0644)
+
+		app, err := store.loadYAMLApp("external.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Type != AppTypePort || app.Port != 8080 {
+			t.Errorf("expected port app on 8080, got type %v port %d", app.Type, app.Port)
+		}
+	})
+
+	t.Run("rejects fixed port for multi-service apps", func(t *testing.T) {
+		yaml := `
+port: 3000
+services:
+  web:
+    cmd: rails server
+  worker:
+    cmd: sidekiq
+`
+		path := filepath.Join(tmpDir, "badport.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+
+		if _, err := store.loadYAMLApp("badport.yml", path); err == nil || !strings.Contains(err.Error(), "single-service") {
+			t.Errorf("expected single-service error, got %v", err)
+		}
+	})
+
+	t.Run("parses port: auto", func(t *testing.T) {
+		yaml := `
+root: /tmp/vite
+port: auto
+port_pattern: 'Local:\s+http://localhost:(\d+)'
+cmd: npx vite
+`
+		path := filepath.Join(tmpDir, "vite.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+
+		app, err := store.loadYAMLApp("vite.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Type != AppTypeCommand || !app.PortAuto || app.Port != 0 {
+			t.Errorf("expected command app with port: auto, got type %v auto %v port %d", app.Type, app.PortAuto, app.Port)
+		}
+		if app.PortPattern != `Local:\s+http://localhost:(\d+)` {
+			t.Errorf("unexpected port_pattern %q", app.PortPattern)
+		}
+	})
+
+	t.Run("parses port: auto per service", func(t *testing.T) {
+		yaml := `
+services:
+  api:
+    cmd: rails server
+  web:
+    cmd: npx vite
+    port: auto
+`
+		path := filepath.Join(tmpDir, "autosvc.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+
+		app, err := store.loadYAMLApp("autosvc.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, svc := range app.Services {
+			if svc.PortAuto != (svc.Name == "web") {
+				t.Errorf("service %s: unexpected port: auto = %v", svc.Name, svc.PortAuto)
+			}
+		}
+	})
+
+	t.Run("parses host", func(t *testing.T) {
+		yaml := `
+host: "::1"
+services:
+  web:
+    cmd: npm run dev
+  api:
+    cmd: rails server
+    host: 127.0.0.1
+`
+		path := filepath.Join(tmpDir, "hosts.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+
+		app, err := store.loadYAMLApp("hosts.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, svc := range app.Services {
+			want := map[string]string{"web": "::1", "api": "127.0.0.1"}[svc.Name]
+			if svc.Host != want {
+				t.Errorf("service %s: expected host %s, got %q", svc.Name, want, svc.Host)
+			}
+		}
+
+		os.WriteFile(path, []byte("host: 0.0.0.0\ncmd: npm start\n"), 0644)
+		if _, err := store.loadYAMLApp("hosts.yml", path); err == nil || !strings.Contains(err.Error(), "host") {
+			t.Errorf("expected host error, got %v", err)
+		}
+	})
+
+	t.Run("parses pidfile and logfile", func(t *testing.T) {
+		yaml := `
+root: /tmp/legacy
+cmd: bundle exec unicorn -D -c config/unicorn.rb
+pidfile: tmp/pids/unicorn.pid
+logfile: /var/log/unicorn.log
+`
+		path := filepath.Join(tmpDir, "unicorn.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+
+		app, err := store.loadYAMLApp("unicorn.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.PIDFile != "/tmp/legacy/tmp/pids/unicorn.pid" {
+			t.Errorf("expected pidfile relative to root, got %q", app.PIDFile)
+		}
+		if app.LogFile != "/var/log/unicorn.log" {
+			t.Errorf("expected absolute logfile, got %q", app.LogFile)
+		}
+
+		os.WriteFile(path, []byte("port: 3000\npidfile: app.pid\n"), 0644)
+		if _, err := store.loadYAMLApp("unicorn.yml", path); err == nil || !strings.Contains(err.Error(), "require cmd") {
+			t.Errorf("expected pidfile without cmd to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("parses pause_after", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "sleepy.yml")
+		os.WriteFile(path, []byte("pause_after: 10m\nservices:\n  web:\n    cmd: rails s\n  css:\n    cmd: yarn watch\n"), 0644)
+		app, err := store.loadYAMLApp("sleepy.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.PauseAfter != 10*time.Minute {
+			t.Errorf("expected pause_after of 10m, got %v", app.PauseAfter)
+		}
+
+		cases := map[string]string{
+			"pause_after: later\ncmd: rails s\n": `pause_after: invalid duration "later"`,
+			"pause_after: 0s\ncmd: rails s\n":    `pause_after: invalid duration "0s"`,
+			"pause_after: 1m\nport: 3000\n":      "pause_after requires cmd or services",
+		}
+		for yaml, want := range cases {
+			os.WriteFile(path, []byte(yaml), 0644)
+			if _, err := store.loadYAMLApp("sleepy.yml", path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+
+	t.Run("parses limits", func(t *testing.T) {
+		yaml := `
+root: /tmp
+limits:
+  memory: 2G
+  nofile: 4096
+services:
+  web:
+    cmd: rails s
+  webpack:
+    cmd: bin/webpack --watch
+    limits:
+      memory: 1G
+      cpu: 150%
+      cpu_time: 10m
+`
+		path := filepath.Join(tmpDir, "limited.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+		app, err := store.loadYAMLApp("limited.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]limits.Limits{
+			"web":     {Memory: 2 << 30, NoFile: 4096},
+			"webpack": {Memory: 1 << 30, CPU: 150, CPUTime: 10 * time.Minute, NoFile: 4096},
+		}
+		for _, svc := range app.Services {
+			if svc.Limits != want[svc.Name] {
+				t.Errorf("%s: expected limits %+v, got %+v", svc.Name, want[svc.Name], svc.Limits)
+			}
+		}
+
+		cases := map[string]string{
+			"cmd: rails s\nlimits:\n  memory: lots\n":                      `limits.memory: invalid memory size "lots"`,
+			"services:\n  web:\n    cmd: x\n    limits:\n      cpu: 1.5\n": `services.web.limits.cpu: invalid cpu limit "1.5"`,
+			"cmd: rails s\nlimits:\n  cpu_time: 1d\n":                      `limits.cpu_time: invalid cpu time "1d"`,
+			"port: 3000\nlimits:\n  nofile: 1024\n":                        "limits requires cmd or services",
+		}
+		for yaml, want := range cases {
+			os.WriteFile(path, []byte(yaml), 0644)
+			if _, err := store.loadYAMLApp("limited.yml", path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+
+	t.Run("parses inspect", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "hooks.yml")
+		cases := map[string]*inspect.Options{
+			"cmd: rails s\n":                 nil,
+			"cmd: rails s\ninspect: false\n": nil,
+			"cmd: rails s\ninspect: true\n":  {},
+			"port: 3000\ninspect:\n  max_requests: 10\n  redact: [X-Api-Key]\n": {
+				MaxRequests: 10, Redact: []string{"X-Api-Key"},
+			},
+		}
+		for yaml, want := range cases {
+			os.WriteFile(path, []byte(yaml), 0644)
+			app, err := store.loadYAMLApp("hooks.yml", path)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", yaml, err)
+			}
+			if !reflect.DeepEqual(app.Inspect, want) {
+				t.Errorf("%q: expected %+v, got %+v", yaml, want, app.Inspect)
+			}
+		}
+
+		os.WriteFile(path, []byte("root: /tmp\nstatic: true\ninspect: true\n"), 0644)
+		if _, err := store.loadYAMLApp("hooks.yml", path); err == nil || !strings.Contains(err.Error(), "inspect requires cmd, port or services") {
+			t.Errorf("expected an error for a static app, got %v", err)
+		}
+	})
+
+	t.Run("parses restart_strategy", func(t *testing.T) {
+		yaml := `
+restart_strategy: overlap
+services:
+  web:
+    cmd: rails s
+  worker:
+    cmd: sidekiq
+    restart_strategy: replace
+`
+		path := filepath.Join(tmpDir, "overlap.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+		app, err := store.loadYAMLApp("overlap.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]string{"web": RestartOverlap, "worker": RestartReplace}
+		for _, svc := range app.Services {
+			if svc.RestartStrategy != want[svc.Name] {
+				t.Errorf("%s: expected %q, got %q", svc.Name, want[svc.Name], svc.RestartStrategy)
+			}
+		}
+
+		cases := map[string]string{
+			"cmd: rails s\nrestart_strategy: blue-green\n":                                                                      `restart_strategy: "blue-green" must be replace or overlap`,
+			"cmd: rails s\nport: 3000\nrestart_strategy: overlap\n":                                                             "can't be combined with a fixed port",
+			"cmd: rails s\npidfile: x.pid\nrestart_strategy: overlap\n":                                                         "can't be combined with pidfile",
+			"port: 3000\nrestart_strategy: overlap\n":                                                                           "restart_strategy requires cmd or services",
+			"restart_strategy: overlap\nservices:\n  api:\n    cmd: x\n  web:\n    cmd: y ${port:api}\n    depends_on: [api]\n": "${port:api} in services.web can't follow api's restart_strategy: overlap",
+		}
+		for yaml, want := range cases {
+			os.WriteFile(path, []byte(yaml), 0644)
+			if _, err := store.loadYAMLApp("overlap.yml", path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+
+	t.Run("parses scale", func(t *testing.T) {
+		yaml := `
+services:
+  web:
+    cmd: rails s
+    scale: 3
+    balance: sticky
+`
+		path := filepath.Join(tmpDir, "scaled.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+		app, err := store.loadYAMLApp("scaled.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// A single scaled service isn't turned into a command app
+		if app.Type != AppTypeYAML || len(app.Services) != 1 {
+			t.Fatalf("expected a multi-service app with one service, got %+v", app)
+		}
+		if svc := app.Services[0]; svc.Scale != 3 || svc.Balance != BalanceSticky {
+			t.Errorf("expected scale 3 with sticky balance, got %d %q", svc.Scale, svc.Balance)
+		}
+
+		cases := map[string]string{
+			"services:\n  web:\n    cmd: x\n    scale: -1\n":                                                       "scale: -1 must be at least 1",
+			"services:\n  web:\n    cmd: x\n    scale: 2\n    balance: random\n":                                   `balance: "random" must be round-robin, least-connections or sticky`,
+			"services:\n  web:\n    cmd: x\n    balance: sticky\n":                                                 "services.web.balance requires scale",
+			"port: 3000\nservices:\n  web:\n    cmd: x\n    scale: 2\n":                                            "scale can't be combined with a fixed port or pidfile",
+			"services:\n  web:\n    cmd: x\n    scale: 2\n    restart_strategy: overlap\n":                         "scale can't be combined with restart_strategy: overlap",
+			"services:\n  api:\n    cmd: x\n    scale: 2\n  web:\n    cmd: y ${port:api}\n    depends_on: [api]\n": "${port:api} in services.web is ambiguous with api's scale: 2",
+		}
+		for yaml, want := range cases {
+			os.WriteFile(path, []byte(yaml), 0644)
+			if _, err := store.loadYAMLApp("scaled.yml", path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+
+	t.Run("parses tcp forwarding", func(t *testing.T) {
+		yaml := `
+services:
+  db:
+    cmd: postgres -p $PORT
+    tcp:
+      listen: 5433
+  cache:
+    cmd: redis-server --port $PORT
+`
+		path := filepath.Join(tmpDir, "forward.yml")
+		os.WriteFile(path, []byte(yaml), 0644)
+		app, err := store.loadYAMLApp("forward.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		listens := make(map[string]int)
+		for _, svc := range app.Services {
+			listens[svc.Name] = svc.TCPListen
+		}
+		if listens["db"] != 5433 || listens["cache"] != 0 {
+			t.Errorf("expected only db on tcp 5433, got %v", listens)
+		}
+
+		// A single forwarding service isn't turned into a command app
+		os.WriteFile(path, []byte("services:\n  db:\n    cmd: x\n    tcp: { listen: 5433 }\n"), 0644)
+		if app, err := store.loadYAMLApp("forward.yml", path); err != nil || app.Type != AppTypeYAML {
+			t.Errorf("expected a multi-service app, got %+v, %v", app, err)
+		}
+
+		cases := map[string]string{
+			"services:\n  db:\n    cmd: x\n    tcp: {}\n":                                                           "services.db.tcp.listen: 0 must be a port between 1 and 65535",
+			"services:\n  db:\n    cmd: x\n    tcp: { listen: 70000 }\n":                                            "services.db.tcp.listen: 70000 must be",
+			"services:\n  a:\n    cmd: x\n    tcp: { listen: 5433 }\n  b:\n    cmd: y\n    tcp: { listen: 5433 }\n": "services.b.tcp: listen: 5433 is already used by services.a",
+			"services:\n  db:\n    cmd: x\n    scale: 2\n    tcp: { listen: 5433 }\n":                               "scale can't be combined with tcp",
+		}
+		for yaml, want := range cases {
+			os.WriteFile(path, []byte(yaml), 0644)
+			if _, err := store.loadYAMLApp("forward.yml", path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+
+	t.Run("rejects invalid port settings", func(t *testing.T) {
+		cases := map[string]string{
+			"port: http\ncmd: npm start\n": "not a valid port",
+			"port: auto\n":                 "port: auto requires cmd",
+			"port: 3000\nport_pattern: 'port (\\d+)'\ncmd: npm start\n":                         "port_pattern requires port: auto",
+			"port: auto\nport_pattern: 'port \\d+'\ncmd: npm start\n":                           "needs a group",
+			"port: auto\nport_pattern: 'port ((\\d+'\ncmd: npm start\n":                         "port_pattern: error parsing regexp",
+			"services:\n  web:\n    cmd: npm start\n    port: 3000\n  api:\n    cmd: rails s\n": "only auto is supported",
+		}
+		for yaml, want := range cases {
+			path := filepath.Join(tmpDir, "badauto.yml")
+			os.WriteFile(path, []byte(yaml), 0644)
+			if _, err := store.loadYAMLApp("badauto.yml", path); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
 }
 
 func TestAppStore(t *testing.T) {
@@ -243,14 +634,14 @@ func TestAppStore(t *testing.T) {
 		}
 	})
 
-	t.Run("Reload clears and reloads apps", func(t *testing.T) {
+	t.Run("Reload picks up new apps", func(t *testing.T) {
 		store := NewAppStore(cfg)
 		store.Load()
 
 		// Add a new app
 		os.WriteFile(filepath.Join(tmpDir, "app3"), []byte("4000"), 0644)
 
-		err := store.Reload()
+		_, err := store.Reload()
 		if err != nil {
 			t.Fatalf("Reload failed: %v", err)
 		}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// GlobalFile is the name of the global settings file in the config directory
+const GlobalFile = "config.json"
+
+// GlobalSettings are the settings from config.json that can change while
+// the server is running
+type GlobalSettings struct {
+	TLD           string
+	Ollama        *OllamaConfig // nil when disabled
+	ClaudeCommand string
+}
+
+// validTLD matches a single DNS label
+var validTLD = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// ReadGlobalSettings reads and validates config.json in dir, applying defaults
+// for unset values
+func ReadGlobalSettings(dir string) (*GlobalSettings, error) {
+	data, err := os.ReadFile(filepath.Join(dir, GlobalFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		TLD    string `json:"tld"`
+		Ollama *struct {
+			Enabled bool   `json:"enabled"`
+			URL     string `json:"url"`
+			Model   string `json:"model"`
+		} `json:"ollama"`
+		ClaudeCommand string `json:"claude_command"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", GlobalFile, err)
+	}
+
+	settings := &GlobalSettings{
+		TLD:           raw.TLD,
+		ClaudeCommand: raw.ClaudeCommand,
+	}
+	if settings.TLD == "" {
+		settings.TLD = "test"
+	}
+	if !validTLD.MatchString(settings.TLD) {
+		return nil, fmt.Errorf("invalid tld %q (use lowercase letters, digits and dashes)", settings.TLD)
+	}
+	if settings.ClaudeCommand == "" {
+		settings.ClaudeCommand = "claude"
+	}
+
+	if raw.Ollama != nil && raw.Ollama.Enabled {
+		if raw.Ollama.URL == "" || raw.Ollama.Model == "" {
+			return nil, fmt.Errorf("ollama is enabled but url or model is missing")
+		}
+		settings.Ollama = &OllamaConfig{
+			Enabled: true,
+			URL:     raw.Ollama.URL,
+			Model:   raw.Ollama.Model,
+		}
+	}
+
+	return settings, nil
+}
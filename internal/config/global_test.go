@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadGlobalSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+		check   func(t *testing.T, s *GlobalSettings)
+	}{
+		{
+			name:    "defaults",
+			content: `{}`,
+			check: func(t *testing.T, s *GlobalSettings) {
+				if s.TLD != "test" || s.ClaudeCommand != "claude" || s.Ollama != nil {
+					t.Errorf("unexpected defaults: %+v", s)
+				}
+			},
+		},
+		{
+			name:    "all settings",
+			content: `{"tld": "dev", "claude_command": "claude --continue", "ollama": {"enabled": true, "url": "http://localhost:11434", "model": "llama3"}}`,
+			check: func(t *testing.T, s *GlobalSettings) {
+				if s.TLD != "dev" || s.ClaudeCommand != "claude --continue" {
+					t.Errorf("unexpected settings: %+v", s)
+				}
+				if s.Ollama == nil || s.Ollama.Model != "llama3" {
+					t.Errorf("expected Ollama to be enabled, got %+v", s.Ollama)
+				}
+			},
+		},
+		{
+			name:    "disabled ollama",
+			content: `{"ollama": {"enabled": false, "model": "llama3"}}`,
+			check: func(t *testing.T, s *GlobalSettings) {
+				if s.Ollama != nil {
+					t.Errorf("expected Ollama to be disabled, got %+v", s.Ollama)
+				}
+			},
+		},
+		{name: "invalid tld", content: `{"tld": "My.TLD"}`, wantErr: "invalid tld"},
+		{name: "ollama without model", content: `{"ollama": {"enabled": true, "url": "http://localhost:11434"}}`, wantErr: "model is missing"},
+		{name: "invalid JSON", content: `{"tld": `, wantErr: "parsing config.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, GlobalFile), []byte(tt.content), 0644)
+
+			settings, err := ReadGlobalSettings(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, settings)
+		})
+	}
+}
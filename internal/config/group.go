@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GroupMarker is the file that marks a subdirectory of the config directory
+// as a group of app configs, e.g. work/.fireup-group. Other subdirectories
+// are served as static sites.
+const GroupMarker = ".fireup-group"
+
+// IsGroupDir returns true if dir contains a GroupMarker
+func IsGroupDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, GroupMarker))
+	return err == nil
+}
+
+// GroupDirs returns root and every group directory below it
+func GroupDirs(root string) []string {
+	dirs := []string{root}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && IsGroupDir(path) {
+			dirs = append(dirs, GroupDirs(path)...)
+		}
+	}
+	return dirs
+}
+
+// groupLabel describes where an app was loaded from, for messages
+func groupLabel(group string) string {
+	if group == "" {
+		return "the top level"
+	}
+	return group + "/"
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(tmpDir, path)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, []byte(content), 0644)
+	}
+	write("blog", "3000")
+	write("work/.fireup-group", "")
+	write("work/shop.yml", "root: /tmp\ncmd: rails s\n")
+	write("work/blog", "4000") // Name taken at the top level
+	write("work/clients/.fireup-group", "")
+	write("work/clients/acme.yml", "root: /tmp\ncmd: npm start\n")
+	write("site/index.html", "<h1>hi</h1>") // No marker: a static site
+
+	store := NewAppStore(&Config{Dir: tmpDir})
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		typ   AppType
+	}{
+		{"blog", "", AppTypePort},
+		{"shop", "work", AppTypeCommand},
+		{"acme", "work/clients", AppTypeCommand},
+		{"site", "", AppTypeStatic},
+	}
+	for _, tt := range tests {
+		app, ok := store.Get(tt.name)
+		if !ok {
+			t.Errorf("expected %s to be loaded", tt.name)
+			continue
+		}
+		if app.Group != tt.group || app.Type != tt.typ {
+			t.Errorf("%s: got group %q type %v, want %q %v", tt.name, app.Group, app.Type, tt.group, tt.typ)
+		}
+	}
+
+	if blog, _ := store.Get("blog"); blog.Port != 3000 {
+		t.Errorf("expected the top-level blog to win, got port %d", blog.Port)
+	}
+	if len(store.All()) != 4 {
+		t.Errorf("expected 4 apps, got %d", len(store.All()))
+	}
+
+	dirs := GroupDirs(tmpDir)
+	if len(dirs) != 3 {
+		t.Errorf("expected root and two group dirs, got %v", dirs)
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultsFile holds YAML settings applied to every YAML app in the config directory
+const DefaultsFile = "config-defaults.yml"
+
+// identityKeys are never inherited from defaults or extended configs
+var identityKeys = []string{"name", "alias", "aliases", "hidden"}
+
+// readYAMLConfig reads a YAML app config with config-defaults.yml and any
+// extends: chain merged in. Maps merge deeply and lists are replaced, with
+// the extending file taking precedence. It returns the merged mapping and
+// the paths of every file it was built from.
+func (s *AppStore) readYAMLConfig(path string) (*yaml.Node, []string, error) {
+	doc, sources, err := s.readExtends(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.applyDefaults(doc, sources)
+}
+
+// applyDefaults merges config-defaults.yml, if present, under doc and adds
+// it to sources
+func (s *AppStore) applyDefaults(doc *yaml.Node, sources []string) (*yaml.Node, []string, error) {
+	defaultsPath := filepath.Join(s.cfg.Dir, DefaultsFile)
+	if _, err := os.Stat(defaultsPath); err != nil {
+		return doc, sources, nil
+	}
+	defaults, defaultSources, err := s.readExtends(defaultsPath, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", DefaultsFile, err)
+	}
+	return mergeYAML(withoutIdentity(defaults), doc), append(sources, defaultSources...), nil
+}
+
+// readExtends reads path and recursively merges the config it extends.
+// chain holds the files already visited, to detect cycles.
+func (s *AppStore) readExtends(path string, chain []string) (*yaml.Node, []string, error) {
+	if containsString(chain, path) {
+		return nil, nil, fmt.Errorf("extends cycle: %s -> %s", strings.Join(chain, " -> "), path)
+	}
+	chain = append(chain, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var file yaml.Node
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(file.Content) > 0 {
+		doc = file.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("parsing YAML: expected a mapping at the top level")
+	}
+	sources := []string{path}
+
+	ref := removeKey(doc, "extends")
+	if ref == nil {
+		return doc, sources, nil
+	}
+	if ref.Kind != yaml.ScalarNode || ref.Value == "" {
+		return nil, nil, fmt.Errorf("extends must be an app name or file path")
+	}
+	basePath, err := s.resolveExtends(ref.Value, filepath.Dir(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	base, baseSources, err := s.readExtends(basePath, chain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extends %s: %w", ref.Value, err)
+	}
+	return mergeYAML(withoutIdentity(base), doc), append(sources, baseSources...), nil
+}
+
+// resolveExtends finds the file referenced by extends:.
+// A bare name refers to another app's config (<name>.yml or <name>.yaml);
+// anything with a .yml/.yaml extension is a path relative to dir.
+func (s *AppStore) resolveExtends(ref, dir string) (string, error) {
+	if strings.HasPrefix(ref, "~") {
+		home, _ := os.UserHomeDir()
+		ref = filepath.Join(home, ref[1:])
+	}
+
+	ext := filepath.Ext(ref)
+	if ext == ".yml" || ext == ".yaml" {
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(dir, ref)
+		}
+		if _, err := os.Stat(ref); err != nil {
+			return "", fmt.Errorf("extends: %w", err)
+		}
+		return ref, nil
+	}
+
+	for _, candidate := range []string{ref + ".yml", ref + ".yaml"} {
+		path := filepath.Join(dir, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("extends: no config found for %q", ref)
+}
+
+// mergeYAML deep-merges two mapping nodes. Maps merge recursively; lists and
+// scalars in override replace those in base. Neither input is modified.
+func mergeYAML(base, override *yaml.Node) *yaml.Node {
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	result.Content = append(result.Content, base.Content...)
+
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		idx := findKey(result, key.Value)
+		switch {
+		case idx == -1:
+			result.Content = append(result.Content, key, value)
+		case result.Content[idx+1].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			result.Content[idx+1] = mergeYAML(result.Content[idx+1], value)
+		default:
+			result.Content[idx+1] = value
+		}
+	}
+	return result
+}
+
+// withoutIdentity returns a copy of doc without the keys that identify an app
+func withoutIdentity(doc *yaml.Node) *yaml.Node {
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if !containsString(identityKeys, doc.Content[i].Value) {
+			result.Content = append(result.Content, doc.Content[i], doc.Content[i+1])
+		}
+	}
+	return result
+}
+
+// findKey returns the index of key in a mapping node's content, or -1
+func findKey(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeKey deletes key from a mapping node and returns its value, or nil
+func removeKey(mapping *yaml.Node, key string) *yaml.Node {
+	idx := findKey(mapping, key)
+	if idx == -1 {
+		return nil
+	}
+	value := mapping.Content[idx+1]
+	mapping.Content = append(mapping.Content[:idx], mapping.Content[idx+2:]...)
+	return value
+}
+
+// DependentApps returns the names of apps built from any of the given config
+// files, including files they inherit from via extends: or config-defaults.yml
+func (s *AppStore) DependentApps(paths []string) []string {
+	var names []string
+	for name, app := range s.Snapshot().apps {
+		for _, source := range app.sources {
+			if containsString(paths, source) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// SourceFiles returns the config files the loaded apps were built from,
+// including files they inherit from and the Procfiles they read
+func (s *AppStore) SourceFiles() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, app := range s.Snapshot().apps {
+		for _, source := range app.sources {
+			if !seen[source] {
+				seen[source] = true
+				paths = append(paths, source)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
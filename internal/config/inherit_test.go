@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestYAMLInheritance(t *testing.T) {
+	write := func(t *testing.T, dir, name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("extends merges maps deeply and replaces lists", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir})
+		write(t, dir, "config-rails.yml", `
+name: rails-template
+alias: rt
+services:
+  web:
+    cmd: bin/rails s -p $PORT
+    env:
+      RAILS_ENV: development
+      RAILS_LOG_TO_STDOUT: "1"
+  worker:
+    cmd: bundle exec sidekiq
+    depends_on: [web]
+`)
+		path := write(t, dir, "shop.yml", `
+extends: config-rails.yml
+services:
+  web:
+    env:
+      RAILS_ENV: test
+  worker:
+    depends_on: []
+`)
+
+		app, err := store.loadYAMLApp("shop.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Name != "shop" || len(app.Aliases) != 0 {
+			t.Errorf("identity should not be inherited: name=%q aliases=%v", app.Name, app.Aliases)
+		}
+
+		web := findTestService(app, "web")
+		if web == nil || web.Command != "bin/rails s -p $PORT" {
+			t.Fatalf("expected inherited web cmd, got %+v", web)
+		}
+		if web.Env["RAILS_ENV"] != "test" || web.Env["RAILS_LOG_TO_STDOUT"] != "1" {
+			t.Errorf("expected deep-merged env, got %v", web.Env)
+		}
+		if worker := findTestService(app, "worker"); worker == nil || len(worker.DependsOn) != 0 {
+			t.Errorf("expected depends_on list to be replaced, got %+v", worker)
+		}
+	})
+
+	t.Run("extends another app by name", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir})
+		write(t, dir, "api.yml", "cmd: bin/api -p $PORT\nenv:\n  LOG: debug\n")
+		path := write(t, dir, "api-staging.yml", "extends: api\nenv:\n  API_ENV: staging\n")
+
+		app, err := store.loadYAMLApp("api-staging.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Command != "bin/api -p $PORT" || app.Env["LOG"] != "debug" || app.Env["API_ENV"] != "staging" {
+			t.Errorf("unexpected app: %+v", app)
+		}
+	})
+
+	t.Run("config-defaults.yml applies to every YAML app", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir})
+		write(t, dir, DefaultsFile, "env:\n  EDITOR: vim\n  ZIP: 01234\n")
+		write(t, dir, "one.yml", "cmd: npm start\n")
+		write(t, dir, "two.yml", "cmd: npm start\nenv:\n  EDITOR: nano\n")
+		if err := store.Load(); err != nil {
+			t.Fatal(err)
+		}
+
+		one, ok := store.Get("one")
+		if !ok || one.Env["EDITOR"] != "vim" {
+			t.Errorf("expected default env on one, got %+v", one)
+		}
+		if one.Env["ZIP"] != "01234" {
+			t.Errorf("expected scalar to be preserved as written, got %q", one.Env["ZIP"])
+		}
+		two, _ := store.Get("two")
+		if two.Env["EDITOR"] != "nano" {
+			t.Errorf("expected app env to override defaults, got %v", two.Env)
+		}
+		if _, ok := store.Get("config-defaults"); ok {
+			t.Error("config-defaults.yml should not be loaded as an app")
+		}
+	})
+
+	t.Run("variables expand in the extending app's context", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir, TLD: "test"})
+		write(t, dir, DefaultsFile, "env:\n  HOST: ${APP_NAME}.${TLD}\n")
+		path := write(t, dir, "blog.yml", "cmd: npm start\n")
+
+		app, err := store.loadYAMLApp("blog.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Env["HOST"] != "blog.test" {
+			t.Errorf("unexpected HOST: %q", app.Env["HOST"])
+		}
+	})
+
+	t.Run("rejects cycles and missing bases", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir})
+		write(t, dir, "a.yml", "extends: b\ncmd: a\n")
+		pathB := write(t, dir, "b.yml", "extends: a\ncmd: b\n")
+		pathC := write(t, dir, "c.yml", "extends: missing\ncmd: c\n")
+
+		if _, err := store.loadYAMLApp("b.yml", pathB); err == nil || !strings.Contains(err.Error(), "cycle") {
+			t.Errorf("expected cycle error, got %v", err)
+		}
+		if _, err := store.loadYAMLApp("c.yml", pathC); err == nil || !strings.Contains(err.Error(), "no config found") {
+			t.Errorf("expected missing base error, got %v", err)
+		}
+	})
+}
+
+func TestDependentApps(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		DefaultsFile:       "env:\n  A: b\n",
+		"config-rails.yml": "cmd: bin/rails s\n",
+		"shop.yml":         "extends: config-rails.yml\n",
+		"blog.yml":         "extends: config-rails.yml\n",
+		"docs.yml":         "cmd: npm start\n",
+		"legacy":           "3000",
+	} {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	store := NewAppStore(&Config{Dir: dir})
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"config-rails.yml", []string{"blog", "shop"}},
+		{DefaultsFile, []string{"blog", "docs", "shop"}},
+		{"docs.yml", []string{"docs"}},
+		{"legacy", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := store.DependentApps([]string{filepath.Join(dir, tt.file)})
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("DependentApps(%s) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("source files", func(t *testing.T) {
+		var got []string
+		for _, path := range store.SourceFiles() {
+			got = append(got, filepath.Base(path))
+		}
+		if want := "blog.yml,config-defaults.yml,config-rails.yml,docs.yml,shop.yml"; strings.Join(got, ",") != want {
+			t.Errorf("SourceFiles() = %v, want %s", got, want)
+		}
+	})
+}
+
+func findTestService(app *App, name string) *Service {
+	for i := range app.Services {
+		if app.Services[i].Name == name {
+			return &app.Services[i]
+		}
+	}
+	return nil
+}
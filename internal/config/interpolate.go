@@ -0,0 +1,277 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Variable interpolation for YAML configs.
+//
+// Load-time variables:
+//
+//	${TLD}            Configured top-level domain (e.g., "test")
+//	${APP_NAME}       Name of the app being loaded
+//	${ROOT}           Expanded root directory of the app
+//	${HOME}           User's home directory
+//	${env:VAR}        Environment variable from fireup's environment
+//	${url:service}    URL of another service in the same app
+//
+// Process-start variables (resolved when the service starts):
+//
+//	${port:service}   Port assigned to another service in the same app
+//
+// Any reference may supply a default with ${ref:-default}. Write $${...} to
+// get a literal ${...}. The variables fireup sets for processes (e.g.,
+// ${PORT}, see shellVars) are left for the shell to expand; any other name
+// is an error.
+
+// shellVars are the variables fireup sets in the environment of processes,
+// which ${NAME} references leave for the shell
+var shellVars = map[string]bool{
+	"PORT":            true,
+	"HOST":            true,
+	"FORCE_COLOR":     true,
+	"FIREUP_INSTANCE": true,
+}
+
+// interpolator expands load-time variables and collects unresolved references
+type interpolator struct {
+	vars     map[string]string
+	services map[string]bool
+	urlFor   func(service string) string
+	errs     []string
+}
+
+// newInterpolator creates an interpolator for an app.
+// services lists the names of services that ${url:...} and ${port:...} may reference.
+func (s *AppStore) newInterpolator(appName string, services []string) *interpolator {
+	tld := s.cfg.TLD
+	if tld == "" {
+		tld = "test"
+	}
+	home, _ := os.UserHomeDir()
+
+	in := &interpolator{
+		vars: map[string]string{
+			"TLD":      tld,
+			"APP_NAME": appName,
+			"HOME":     home,
+		},
+		services: make(map[string]bool),
+	}
+	for _, name := range services {
+		in.services[name] = true
+	}
+
+	in.urlFor = func(service string) string {
+		host := fmt.Sprintf("%s-%s.%s", strings.ToLower(strings.ReplaceAll(service, " ", "-")), appName, tld)
+		if s.cfg.URLPort == 0 || s.cfg.URLPort == 80 {
+			return "http://" + host
+		}
+		return fmt.Sprintf("http://%s:%d", host, s.cfg.URLPort)
+	}
+
+	return in
+}
+
+// expand replaces load-time variables in value. where describes the field
+// (e.g., "services.web.cmd") for error messages.
+func (in *interpolator) expand(value, where string) string {
+	return scanVars(value, true, func(ref string) (string, bool) {
+		name, def, hasDefault := splitDefault(ref)
+
+		resolved, ok, keep := in.lookup(name)
+		if !ok && !keep && !in.known(name) {
+			in.errs = append(in.errs, fmt.Sprintf("unknown variable ${%s} in %s (use ${env:%s} for fireup's environment, or $${%s} to leave it to the shell)", name, where, name, name))
+			return "${" + ref + "}", true
+		}
+		if keep {
+			return "${" + ref + "}", true
+		}
+		if ok {
+			return resolved, true
+		}
+		if hasDefault {
+			return def, true
+		}
+		in.errs = append(in.errs, fmt.Sprintf("unresolved ${%s} in %s", ref, where))
+		return "${" + ref + "}", true
+	})
+}
+
+// expandMap expands every value of an env map
+func (in *interpolator) expandMap(values map[string]string, where string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		result[k] = in.expand(v, where+"."+k)
+	}
+	return result
+}
+
+// lookup resolves a single reference.
+// keep is true for references that are resolved later (process-start or shell variables).
+func (in *interpolator) lookup(name string) (value string, ok bool, keep bool) {
+	switch {
+	case strings.HasPrefix(name, "env:"):
+		value, ok = os.LookupEnv(strings.TrimPrefix(name, "env:"))
+		return value, ok, false
+
+	case strings.HasPrefix(name, "url:"):
+		svc := strings.TrimPrefix(name, "url:")
+		if !in.services[svc] {
+			return "", false, false
+		}
+		return in.urlFor(svc), true, false
+
+	case strings.HasPrefix(name, "port:"):
+		svc := strings.TrimPrefix(name, "port:")
+		if !in.services[svc] {
+			return "", false, false
+		}
+		return "", false, true
+	}
+
+	if value, ok := in.vars[name]; ok {
+		return value, true, false
+	}
+	// Set by fireup when the process starts - leave it for the shell
+	return "", false, shellVars[name]
+}
+
+// known returns true if name is a variable fireup knows of, even if it
+// can't resolve it (e.g., ${url:...} of a missing service)
+func (in *interpolator) known(name string) bool {
+	for _, prefix := range []string{"env:", "url:", "port:"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	_, ok := in.vars[name]
+	return ok || shellVars[name]
+}
+
+// err returns the collected interpolation errors, if any
+func (in *interpolator) err() error {
+	if len(in.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(in.errs, "; "))
+}
+
+// PortRefs returns the service names referenced by ${port:...} in value
+func PortRefs(value string) []string {
+	var refs []string
+	scanVars(value, false, func(ref string) (string, bool) {
+		name, _, _ := splitDefault(ref)
+		if strings.HasPrefix(name, "port:") {
+			refs = append(refs, strings.TrimPrefix(name, "port:"))
+		}
+		return "", false
+	})
+	return refs
+}
+
+// ExpandPorts resolves ${port:service} references at process start.
+// portOf returns the port assigned to a service in the same app.
+func ExpandPorts(value string, portOf func(service string) (int, bool)) (string, error) {
+	var errs []string
+	result := scanVars(value, false, func(ref string) (string, bool) {
+		name, def, hasDefault := splitDefault(ref)
+		if !strings.HasPrefix(name, "port:") {
+			return "", false
+		}
+		svc := strings.TrimPrefix(name, "port:")
+		if port, ok := portOf(svc); ok {
+			return strconv.Itoa(port), true
+		}
+		if hasDefault {
+			return def, true
+		}
+		errs = append(errs, fmt.Sprintf("service %q has no port yet", svc))
+		return "${" + ref + "}", true
+	})
+	// Unescape $${port:...} now that port references are resolved
+	result = unescapePorts(result)
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return result, nil
+}
+
+// unescapePorts turns $${port:...} escapes into a literal ${port:...}.
+// Command apps have no ports to resolve and never go through ExpandPorts,
+// so theirs are unescaped when they're loaded.
+func unescapePorts(value string) string {
+	return strings.ReplaceAll(value, "$${port:", "${port:")
+}
+
+// unescapePortsMap is unescapePorts for env values
+func unescapePortsMap(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	result := make(map[string]string, len(env))
+	for k, v := range env {
+		result[k] = unescapePorts(v)
+	}
+	return result
+}
+
+// scanVars walks value and calls replace for each ${...} reference.
+// If replace returns false, the reference is copied through unchanged.
+// When loadTime is true, $${ escapes are turned into literal ${, except for
+// $${port: which is kept escaped until ExpandPorts runs.
+func scanVars(value string, loadTime bool, replace func(ref string) (string, bool)) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); {
+		if strings.HasPrefix(value[i:], "$${") {
+			if loadTime && !strings.HasPrefix(value[i+3:], "port:") {
+				sb.WriteString("${")
+			} else {
+				sb.WriteString("$${")
+			}
+			i += 3
+			// Copy the escaped reference through untouched
+			if end := strings.Index(value[i:], "}"); end != -1 {
+				sb.WriteString(value[i : i+end+1])
+				i += end + 1
+			}
+			continue
+		}
+		if strings.HasPrefix(value[i:], "${") {
+			end := strings.Index(value[i+2:], "}")
+			if end == -1 {
+				sb.WriteString(value[i:])
+				break
+			}
+			ref := value[i+2 : i+2+end]
+			if replacement, ok := replace(ref); ok {
+				sb.WriteString(replacement)
+			} else {
+				sb.WriteString(value[i : i+2+end+1])
+			}
+			i += 2 + end + 1
+			continue
+		}
+		sb.WriteByte(value[i])
+		i++
+	}
+	return sb.String()
+}
+
+// splitDefault splits "name:-default" into its parts
+func splitDefault(ref string) (name, def string, hasDefault bool) {
+	if idx := strings.Index(ref, ":-"); idx != -1 {
+		return ref[:idx], ref[idx+2:], true
+	}
+	return ref, "", false
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYAMLInterpolation(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &Config{Dir: tmpDir, TLD: "dev"}
+	store := NewAppStore(cfg)
+	home, _ := os.UserHomeDir()
+
+	load := func(t *testing.T, name, content string) (*App, error) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		return store.loadYAMLApp(name, path)
+	}
+
+	t.Run("expands built-in variables", func(t *testing.T) {
+		app, err := load(t, "vars.yml", `
+root: ${HOME}/projects/${APP_NAME}
+cmd: bin/server --host ${APP_NAME}.${TLD} --root ${ROOT}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		wantRoot := filepath.Join(home, "projects", "vars")
+		if app.Dir != wantRoot {
+			t.Errorf("expected root %q, got %q", wantRoot, app.Dir)
+		}
+		want := "bin/server --host vars.dev --root " + wantRoot
+		if app.Command != want {
+			t.Errorf("expected command %q, got %q", want, app.Command)
+		}
+	})
+
+	t.Run("expands env variables with defaults", func(t *testing.T) {
+		t.Setenv("FIREUP_TEST_DB", "postgres://db")
+		app, err := load(t, "envvars.yml", `
+cmd: rails s
+env:
+  DATABASE_URL: ${env:FIREUP_TEST_DB}
+  REDIS_URL: ${env:FIREUP_TEST_MISSING:-redis://localhost}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Env["DATABASE_URL"] != "postgres://db" {
+			t.Errorf("unexpected DATABASE_URL: %q", app.Env["DATABASE_URL"])
+		}
+		if app.Env["REDIS_URL"] != "redis://localhost" {
+			t.Errorf("unexpected REDIS_URL: %q", app.Env["REDIS_URL"])
+		}
+	})
+
+	t.Run("leaves shell variables and escapes alone", func(t *testing.T) {
+		app, err := load(t, "shell.yml", `
+cmd: server --port ${PORT} --name $${APP_NAME} --fallback ${PORT:-3000}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "server --port ${PORT} --name ${APP_NAME} --fallback ${PORT:-3000}"
+		if app.Command != want {
+			t.Errorf("expected %q, got %q", want, app.Command)
+		}
+	})
+
+	t.Run("unescapes port references of command apps", func(t *testing.T) {
+		app, err := load(t, "literal.yml", `
+cmd: echo $${port:api}
+env:
+  TEMPLATE: http://localhost:$${port:api}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Command != "echo ${port:api}" || app.Env["TEMPLATE"] != "http://localhost:${port:api}" {
+			t.Errorf("expected literal ${port:api}, got %q and %q", app.Command, app.Env["TEMPLATE"])
+		}
+
+		app, err = load(t, "literal.yml", `
+services:
+  web:
+    cmd: echo $${port:api}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Type != AppTypeCommand || app.Command != "echo ${port:api}" {
+			t.Errorf("expected a command app running echo ${port:api}, got %q", app.Command)
+		}
+
+		app, err = load(t, "literal.yml", `
+cmd: echo
+profiles:
+  docs:
+    cmd: echo $${port:api}
+    env:
+      TEMPLATE: $${port:api}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p := app.Profiles["docs"]; p.Command != "echo ${port:api}" || p.Env["TEMPLATE"] != "${port:api}" {
+			t.Errorf("expected literal ${port:api} in the profile, got %q and %q", p.Command, p.Env["TEMPLATE"])
+		}
+	})
+
+	t.Run("expands service urls and keeps port references", func(t *testing.T) {
+		app, err := load(t, "shop.yml", `
+services:
+  api:
+    cmd: bin/api
+  web:
+    cmd: npm start
+    depends_on: [api]
+    env:
+      API_URL: ${url:api}
+      API_PORT: ${port:api}
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var web *Service
+		for i := range app.Services {
+			if app.Services[i].Name == "web" {
+				web = &app.Services[i]
+			}
+		}
+		if web == nil {
+			t.Fatal("web service not found")
+		}
+		if web.Env["API_URL"] != "http://api-shop.dev" {
+			t.Errorf("unexpected API_URL: %q", web.Env["API_URL"])
+		}
+		if web.Env["API_PORT"] != "${port:api}" {
+			t.Errorf("expected port reference to be kept for process start, got %q", web.Env["API_PORT"])
+		}
+	})
+
+	t.Run("reports unresolved references", func(t *testing.T) {
+		_, err := load(t, "broken.yml", `
+services:
+  api:
+    cmd: bin/api
+  web:
+    cmd: npm start --api ${url:nope}
+    env:
+      TOKEN: ${env:FIREUP_TEST_MISSING}
+      API_PORT: ${port:api}
+`)
+		if err == nil {
+			t.Fatal("expected validation error")
+		}
+		for _, want := range []string{"${url:nope}", "${env:FIREUP_TEST_MISSING}", `requires "api" in depends_on`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected error to mention %q, got: %v", want, err)
+			}
+		}
+	})
+
+	t.Run("reports unknown variables", func(t *testing.T) {
+		cases := map[string]string{
+			"cmd: serve --tld ${TDL}\n":                        "unknown variable ${TDL} in cmd",
+			"cmd: serve\nenv:\n  MODE: ${RAILS_ENV:-dev}\n":    "unknown variable ${RAILS_ENV} in env.MODE",
+			"services:\n  web:\n    cmd: open ${url:wbe}\n":    "unresolved ${url:wbe} in services.web.cmd",
+			"cmd: serve\nprofiles:\n  x:\n    cmd: a ${TDL}\n": "unknown variable ${TDL} in profiles.x.cmd",
+		}
+		for yaml, want := range cases {
+			if _, err := load(t, "typo.yml", yaml); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+}
+
+func TestExpandPorts(t *testing.T) {
+	portOf := func(svc string) (int, bool) {
+		if svc == "api" {
+			return 51234, true
+		}
+		return 0, false
+	}
+
+	t.Run("resolves known ports", func(t *testing.T) {
+		got, err := ExpandPorts("http://127.0.0.1:${port:api}/v1", portOf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "http://127.0.0.1:51234/v1" {
+			t.Errorf("unexpected result: %q", got)
+		}
+	})
+
+	t.Run("uses default when port unknown", func(t *testing.T) {
+		got, err := ExpandPorts("${port:worker:-9000}", portOf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "9000" {
+			t.Errorf("unexpected result: %q", got)
+		}
+	})
+
+	t.Run("errors when port unknown", func(t *testing.T) {
+		if _, err := ExpandPorts("${port:worker}", portOf); err == nil {
+			t.Error("expected error for unknown port")
+		}
+	})
+
+	t.Run("unescapes literal port references", func(t *testing.T) {
+		got, _ := ExpandPorts("echo $${port:api} ${PORT}", portOf)
+		if got != "echo ${port:api} ${PORT}" {
+			t.Errorf("unexpected result: %q", got)
+		}
+	})
+}
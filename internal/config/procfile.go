@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProcfileEntry is a process declared in a Procfile
+type ProcfileEntry struct {
+	Name    string
+	Command string
+}
+
+// ParseProcfile reads "name: command" lines from a Procfile, in file order
+func ParseProcfile(path string) ([]ProcfileEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ProcfileEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+		name := strings.TrimSpace(line[:idx])
+		command := strings.TrimSpace(line[idx+1:])
+		if command == "" || strings.Contains(name, " ") {
+			continue
+		}
+		entries = append(entries, ProcfileEntry{Name: name, Command: command})
+	}
+	return entries, scanner.Err()
+}
+
+// isProcfile returns true for file names like Procfile or Procfile.dev
+func isProcfile(name string) bool {
+	return name == "Procfile" || strings.HasPrefix(name, "Procfile.")
+}
+
+// loadProcfileApp loads link, a symlink to the Procfile at path, as an app
+// rooted at the Procfile's directory
+func (s *AppStore) loadProcfileApp(name, link, path string) (*App, error) {
+	doc := &yaml.Node{}
+	if err := doc.Encode(map[string]string{"root": filepath.Dir(path), "procfile": path}); err != nil {
+		return nil, err
+	}
+	doc, sources, err := s.applyDefaults(doc, []string{link})
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := s.buildYAMLApp(name, doc)
+	if err != nil {
+		return nil, err
+	}
+	app.sources = append(sources, app.sources...)
+	return app, nil
+}
+
+// addProcfileServices merges Procfile processes into services. Entries in
+// services: take precedence, so a config can add env or depends_on to a
+// Procfile process or override its command. Returns the Procfile path.
+func addProcfileServices(services map[string]yamlService, procfile, root string) (string, error) {
+	path := procfile
+	if !filepath.IsAbs(path) {
+		if root == "" {
+			return "", fmt.Errorf("procfile: relative path %q requires root to be set", procfile)
+		}
+		path = filepath.Join(root, path)
+	}
+
+	entries, err := ParseProcfile(path)
+	if err != nil {
+		return "", fmt.Errorf("procfile: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("procfile: no processes found in %s", path)
+	}
+
+	hasDefault := false
+	for _, svc := range services {
+		hasDefault = hasDefault || svc.Default
+	}
+
+	for _, entry := range entries {
+		svc := services[entry.Name]
+		if svc.Command == "" {
+			svc.Command = entry.Command
+		}
+		// By Procfile convention, web serves HTTP
+		if entry.Name == "web" && !hasDefault {
+			svc.Default = true
+		}
+		services[entry.Name] = svc
+	}
+	return path, nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProcfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Procfile.dev")
+	os.WriteFile(path, []byte("# comment\n\nweb: bin/rails s -p $PORT\ncss: bin/rails tailwindcss:watch\nbad line\nspace name: x\n"), 0644)
+
+	entries, err := ParseProcfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if entries[0].Name != "web" || entries[1].Command != "bin/rails tailwindcss:watch" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestProcfileApps(t *testing.T) {
+	project := t.TempDir()
+	procfile := filepath.Join(project, "Procfile.dev")
+	os.WriteFile(procfile, []byte("web: bin/rails s -p $PORT\nworker: bundle exec sidekiq\n"), 0644)
+
+	t.Run("procfile reference in YAML", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir})
+		path := filepath.Join(dir, "shop.yml")
+		os.WriteFile(path, []byte(`
+root: `+project+`
+procfile: Procfile.dev
+services:
+  worker:
+    env:
+      QUEUE: default
+    depends_on: [web]
+`), 0644)
+
+		app, err := store.loadYAMLApp("shop.yml", path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if app.Type != AppTypeYAML || len(app.Services) != 2 {
+			t.Fatalf("expected multi-service app, got %+v", app)
+		}
+		// Dependencies come first after sorting
+		web, worker := app.Services[0], app.Services[1]
+		if web.Name != "web" || !web.Default || web.Command != "bin/rails s -p $PORT" {
+			t.Errorf("unexpected web service: %+v", web)
+		}
+		if worker.Command != "bundle exec sidekiq" || worker.Env["QUEUE"] != "default" {
+			t.Errorf("expected services: entry to extend the Procfile process, got %+v", worker)
+		}
+		if web.Dir != project {
+			t.Errorf("expected dir %q, got %q", project, web.Dir)
+		}
+
+		// The Procfile is watched, and editing it reloads the app
+		if deps := store.DependentApps([]string{procfile}); len(deps) != 0 {
+			t.Errorf("app is not in the store yet, got %v", deps)
+		}
+		store.publish(map[string]*App{app.Name: app}, time.Now())
+		if deps := store.DependentApps([]string{procfile}); len(deps) != 1 {
+			t.Errorf("expected shop to depend on the Procfile, got %v", deps)
+		}
+		if !containsString(store.SourceFiles(), procfile) {
+			t.Errorf("expected the Procfile among the files to watch, got %v", store.SourceFiles())
+		}
+	})
+
+	t.Run("relative procfile requires root", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewAppStore(&Config{Dir: dir})
+		path := filepath.Join(dir, "bad.yml")
+		os.WriteFile(path, []byte("procfile: Procfile.dev\n"), 0644)
+
+		if _, err := store.loadYAMLApp("bad.yml", path); err == nil || !strings.Contains(err.Error(), "requires root") {
+			t.Errorf("expected root error, got %v", err)
+		}
+	})
+
+	t.Run("symlink to a Procfile", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.Symlink(procfile, filepath.Join(dir, "shop")); err != nil {
+			t.Fatal(err)
+		}
+		store := NewAppStore(&Config{Dir: dir})
+		if err := store.Load(); err != nil {
+			t.Fatal(err)
+		}
+
+		app, ok := store.Get("shop")
+		if !ok {
+			t.Fatal("expected shop to be loaded")
+		}
+		if app.Type != AppTypeYAML || len(app.Services) != 2 || app.Dir != project {
+			t.Errorf("unexpected app: %+v", app)
+		}
+	})
+}
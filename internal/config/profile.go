@@ -0,0 +1,267 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultProfile is the name used for an app's base configuration
+const DefaultProfile = "default"
+
+// Profile overrides parts of an app's configuration when selected
+type Profile struct {
+	Command  string                    // Replaces cmd (single-service apps)
+	Env      map[string]string         // Merged into the env of every service
+	Only     []string                  // If set, only these services run
+	Services map[string]ProfileService // Per-service overrides
+}
+
+// ProfileService overrides a single service within a profile
+type ProfileService struct {
+	Command string
+	Env     map[string]string
+}
+
+// yamlProfile is the YAML form of a profile
+type yamlProfile struct {
+	Command  string            `yaml:"cmd"`
+	Env      map[string]string `yaml:"env"`
+	Only     []string          `yaml:"only"`
+	Services map[string]struct {
+		Command string            `yaml:"cmd"`
+		Env     map[string]string `yaml:"env"`
+	} `yaml:"services"`
+}
+
+// buildProfiles expands and validates profiles from YAML.
+// services maps each service name to its depends_on list. Errors are
+// collected on vars.
+func buildProfiles(raw map[string]yamlProfile, services map[string][]string, vars *interpolator) map[string]*Profile {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	profiles := make(map[string]*Profile, len(raw))
+	for name, p := range raw {
+		where := "profiles." + name
+		if name == DefaultProfile {
+			vars.errs = append(vars.errs, fmt.Sprintf("%s: %q is reserved for the base config", where, DefaultProfile))
+			continue
+		}
+		if p.Command != "" && len(services) > 1 {
+			vars.errs = append(vars.errs, fmt.Sprintf("%s.cmd requires a single-service app (use %s.services.<name>.cmd)", where, where))
+		}
+		for _, svcName := range p.Only {
+			if _, ok := services[svcName]; !ok {
+				vars.errs = append(vars.errs, fmt.Sprintf("%s.only: unknown service %q", where, svcName))
+			}
+		}
+
+		profile := &Profile{
+			Command: vars.expand(p.Command, where+".cmd"),
+			Env:     vars.expandMap(p.Env, where+".env"),
+			Only:    p.Only,
+		}
+		for svcName, svcCfg := range p.Services {
+			svcWhere := where + ".services." + svcName
+			deps, ok := services[svcName]
+			if !ok {
+				vars.errs = append(vars.errs, fmt.Sprintf("%s: unknown service %q", svcWhere, svcName))
+				continue
+			}
+			override := ProfileService{
+				Command: vars.expand(svcCfg.Command, svcWhere+".cmd"),
+				Env:     vars.expandMap(svcCfg.Env, svcWhere+".env"),
+			}
+
+			refs := PortRefs(override.Command)
+			for _, v := range override.Env {
+				refs = append(refs, PortRefs(v)...)
+			}
+			for _, ref := range refs {
+				if !containsString(deps, ref) {
+					vars.errs = append(vars.errs, fmt.Sprintf("${port:%s} in %s requires %q in depends_on", ref, svcWhere, ref))
+				}
+			}
+
+			if profile.Services == nil {
+				profile.Services = make(map[string]ProfileService)
+			}
+			profile.Services[svcName] = override
+		}
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// flattenProfiles folds per-service overrides into the top level for apps
+// defined by a single entry in services:
+func flattenProfiles(profiles map[string]*Profile, svcName string) map[string]*Profile {
+	for _, p := range profiles {
+		if override, ok := p.Services[svcName]; ok {
+			if override.Command != "" {
+				p.Command = override.Command
+			}
+			p.Env = mergeEnv(p.Env, override.Env)
+			p.Services = nil
+		}
+		p.Only = nil
+	}
+	return profiles
+}
+
+// ProfileNames returns the names of the app's profiles, sorted
+func (a *App) ProfileNames() []string {
+	names := make([]string, 0, len(a.Profiles))
+	for name := range a.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile returns a copy of the app with the named profile applied.
+// An empty name or DefaultProfile returns the base configuration.
+func (a *App) WithProfile(name string) (*App, error) {
+	base := a
+	if a.base != nil {
+		base = a.base
+	}
+	if name == "" || name == DefaultProfile {
+		return base, nil
+	}
+
+	p, ok := base.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("app %q has no profile %q", base.Name, name)
+	}
+
+	app := *base
+	app.base = base
+	app.Profile = name
+	if p.Command != "" {
+		app.Command = p.Command
+	}
+	app.Env = mergeEnv(base.Env, p.Env)
+
+	if base.Type == AppTypeYAML {
+		app.Services = nil
+		for _, svc := range base.Services {
+			if len(p.Only) > 0 && !containsString(p.Only, svc.Name) {
+				continue
+			}
+			override := p.Services[svc.Name]
+			if override.Command != "" {
+				svc.Command = override.Command
+			}
+			svc.Env = mergeEnv(svc.Env, p.Env, override.Env)
+			// Drop dependencies on services the profile excludes
+			var deps []string
+			for _, dep := range svc.DependsOn {
+				if len(p.Only) == 0 || containsString(p.Only, dep) {
+					deps = append(deps, dep)
+				}
+			}
+			svc.DependsOn = deps
+			app.Services = append(app.Services, svc)
+		}
+	}
+
+	return &app, nil
+}
+
+// SetProfile switches the active profile of an app and returns the updated app
+func (s *AppStore) SetProfile(appName, profile string) (*App, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.snapshot.Load()
+	app, ok := current.apps[appName]
+	if !ok {
+		return nil, fmt.Errorf("app not found: %s", appName)
+	}
+	updated, err := app.WithProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	apps := current.copyApps()
+	apps[appName] = updated
+	s.publish(apps, current.LoadedAt)
+
+	if updated.Profile == "" {
+		delete(s.active, appName)
+	} else {
+		s.active[appName] = updated.Profile
+	}
+	return updated, nil
+}
+
+// SetActiveProfiles restores remembered profiles (app name → profile name).
+// Profiles that no longer exist are dropped with a warning.
+func (s *AppStore) SetActiveProfiles(profiles map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active = make(map[string]string, len(profiles))
+	for appName, profile := range profiles {
+		s.active[appName] = profile
+	}
+	current := s.snapshot.Load()
+	apps := current.copyApps()
+	for name, app := range apps {
+		apps[name] = s.applyActiveProfile(app)
+	}
+	s.publish(apps, current.LoadedAt)
+}
+
+// ActiveProfiles returns the active profile of each app that has one
+func (s *AppStore) ActiveProfiles() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]string, len(s.active))
+	for appName, profile := range s.active {
+		result[appName] = profile
+	}
+	return result
+}
+
+// applyActiveProfile applies the remembered profile for app, if any.
+// Caller must hold s.mu.
+func (s *AppStore) applyActiveProfile(app *App) *App {
+	profile, ok := s.active[app.Name]
+	if !ok {
+		return app
+	}
+	updated, err := app.WithProfile(profile)
+	if err != nil {
+		fmt.Printf("Warning: %v, using default profile\n", err)
+		delete(s.active, app.Name)
+		return app
+	}
+	return updated
+}
+
+// unescapeProfilePorts unescapes $${port:...} in the cmd and env overrides
+// of a command app's profiles, like its own cmd and env (see unescapePorts)
+func unescapeProfilePorts(profiles map[string]*Profile) map[string]*Profile {
+	for _, p := range profiles {
+		p.Command = unescapePorts(p.Command)
+		p.Env = unescapePortsMap(p.Env)
+	}
+	return profiles
+}
+
+// mergeEnv merges env maps, with later maps taking precedence
+func mergeEnv(maps ...map[string]string) map[string]string {
+	var result map[string]string
+	for _, m := range maps {
+		for k, v := range m {
+			if result == nil {
+				result = make(map[string]string)
+			}
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYAMLProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &Config{Dir: tmpDir, TLD: "test"}
+	store := NewAppStore(cfg)
+
+	load := func(t *testing.T, name, content string) (*App, error) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		return store.loadYAMLApp(name, path)
+	}
+
+	t.Run("single-service profile overrides cmd and merges env", func(t *testing.T) {
+		app, err := load(t, "blog.yml", `
+cmd: bin/rails s -p $PORT
+env:
+  RAILS_ENV: development
+  LOG_LEVEL: debug
+profiles:
+  test:
+    cmd: bin/rails s -p $PORT -e test
+    env:
+      RAILS_ENV: test
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if names := app.ProfileNames(); len(names) != 1 || names[0] != "test" {
+			t.Fatalf("unexpected profiles: %v", names)
+		}
+
+		eff, err := app.WithProfile("test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if eff.Profile != "test" || eff.Command != "bin/rails s -p $PORT -e test" {
+			t.Errorf("unexpected profile app: %+v", eff)
+		}
+		if eff.Env["RAILS_ENV"] != "test" || eff.Env["LOG_LEVEL"] != "debug" {
+			t.Errorf("unexpected env: %v", eff.Env)
+		}
+		if app.Env["RAILS_ENV"] != "development" {
+			t.Errorf("base env was modified: %v", app.Env)
+		}
+
+		// Switching back to default returns the base config
+		base, err := eff.WithProfile(DefaultProfile)
+		if err != nil || base != app {
+			t.Errorf("expected base app, got %+v (err %v)", base, err)
+		}
+	})
+
+	t.Run("multi-service profile filters and overrides services", func(t *testing.T) {
+		app, err := load(t, "shop.yml", `
+services:
+  db:
+    cmd: postgres
+  api:
+    cmd: bin/api
+    depends_on: [db]
+  web:
+    cmd: npm run dev
+    depends_on: [api]
+profiles:
+  staging-api:
+    only: [web]
+    env:
+      STAGE: staging
+    services:
+      web:
+        env:
+          API_URL: https://api.staging.example.com
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		eff, err := app.WithProfile("staging-api")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(eff.Services) != 1 || eff.Services[0].Name != "web" {
+			t.Fatalf("expected only web, got %+v", eff.Services)
+		}
+		web := eff.Services[0]
+		if web.Env["STAGE"] != "staging" || web.Env["API_URL"] != "https://api.staging.example.com" {
+			t.Errorf("unexpected web env: %v", web.Env)
+		}
+		if len(web.DependsOn) != 0 {
+			t.Errorf("expected excluded dependencies to be dropped, got %v", web.DependsOn)
+		}
+		if len(app.Services) != 3 {
+			t.Errorf("base services were modified: %+v", app.Services)
+		}
+	})
+
+	t.Run("single entry in services map", func(t *testing.T) {
+		app, err := load(t, "one.yml", `
+services:
+  web:
+    cmd: npm start
+profiles:
+  prod:
+    services:
+      web:
+        cmd: npm run start:prod
+`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		eff, err := app.WithProfile("prod")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if eff.Command != "npm run start:prod" {
+			t.Errorf("unexpected command: %q", eff.Command)
+		}
+	})
+
+	t.Run("rejects invalid profiles", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			content string
+			wantErr string
+		}{
+			{"unknown only service", `
+services:
+  api: {cmd: bin/api}
+  web: {cmd: npm start}
+profiles:
+  x: {only: [worker]}
+`, `unknown service "worker"`},
+			{"cmd on multi-service app", `
+services:
+  api: {cmd: bin/api}
+  web: {cmd: npm start}
+profiles:
+  x: {cmd: other}
+`, "requires a single-service app"},
+			{"reserved name", `
+cmd: npm start
+profiles:
+  default: {cmd: other}
+`, "reserved"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := load(t, "bad.yml", tt.content)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+			})
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		app, _ := load(t, "plain.yml", "cmd: npm start\n")
+		if _, err := app.WithProfile("nope"); err == nil {
+			t.Error("expected error for unknown profile")
+		}
+	})
+}
+
+func TestAppStoreActiveProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "myapp.yml"), []byte(`
+cmd: npm start
+profiles:
+  staging:
+    env: {API: staging}
+`), 0644)
+
+	store := NewAppStore(&Config{Dir: tmpDir})
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := store.SetProfile("myapp", "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if app.Env["API"] != "staging" {
+		t.Errorf("profile not applied: %v", app.Env)
+	}
+
+	// The active profile survives a reload
+	if _, err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	app, _ = store.Get("myapp")
+	if app.Profile != "staging" {
+		t.Errorf("expected profile to survive reload, got %q", app.Profile)
+	}
+
+	if _, err := store.SetProfile("myapp", DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.ActiveProfiles()) != 0 {
+		t.Errorf("expected no active profiles, got %v", store.ActiveProfiles())
+	}
+
+	// Remembered profiles that no longer exist are dropped
+	store.SetActiveProfiles(map[string]string{"myapp": "gone"})
+	app, _ = store.Get("myapp")
+	if app.Profile != "" || len(store.ActiveProfiles()) != 0 {
+		t.Errorf("expected stale profile to be dropped, got %q", app.Profile)
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Route sends requests whose path starts with Path to a service of a
+// multi-service app, e.g. /api to the api service
+type Route struct {
+	Path        string // Prefix without a trailing slash, or "/"
+	Service     string
+	StripPrefix bool // Remove Path before passing the request on
+}
+
+// Matches returns true if path is the route's prefix or below it. "/api"
+// matches "/api" and "/api/users" but not "/apiary".
+func (r Route) Matches(path string) bool {
+	if r.Path == "/" {
+		return true
+	}
+	return path == r.Path || strings.HasPrefix(path, r.Path+"/")
+}
+
+// Strip returns path without the route's prefix, e.g. "/users" for
+// "/api/users"
+func (r Route) Strip(path string) string {
+	if r.Path == "/" {
+		return path
+	}
+	rest := strings.TrimPrefix(path, r.Path)
+	if rest == "" {
+		return "/"
+	}
+	return rest
+}
+
+// yamlRoute is the YAML form of a route
+type yamlRoute struct {
+	Path        string `yaml:"path"`
+	Service     string `yaml:"service"`
+	StripPrefix bool   `yaml:"strip_prefix"`
+}
+
+// buildRoutes validates routes against the app's services and sorts them
+// longest path first, so the first match is the longest prefix. Errors are
+// collected on vars.
+func buildRoutes(raw []yamlRoute, services map[string][]string, vars *interpolator) []Route {
+	var routes []Route
+	seen := make(map[string]bool)
+	for i, r := range raw {
+		where := fmt.Sprintf("routes[%d]", i)
+		path := r.Path
+		if path != "/" {
+			path = strings.TrimSuffix(path, "/")
+		}
+		switch {
+		case !strings.HasPrefix(path, "/"):
+			vars.errs = append(vars.errs, fmt.Sprintf("%s: path %q must start with /", where, r.Path))
+			continue
+		case seen[path]:
+			vars.errs = append(vars.errs, fmt.Sprintf("%s: path %q is routed twice", where, r.Path))
+			continue
+		}
+		if _, ok := services[r.Service]; !ok {
+			vars.errs = append(vars.errs, fmt.Sprintf("%s: unknown service %q", where, r.Service))
+			continue
+		}
+		seen[path] = true
+		routes = append(routes, Route{Path: path, Service: r.Service, StripPrefix: r.StripPrefix})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Path) > len(routes[j].Path)
+	})
+	return routes
+}
+
+// RouteFor returns the route with the longest prefix matching path, or nil
+func (a *App) RouteFor(path string) *Route {
+	for i := range a.Routes {
+		if a.Routes[i].Matches(path) {
+			return &a.Routes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewAppStore(&Config{Dir: tmpDir})
+	path := filepath.Join(tmpDir, "shop.yml")
+	load := func(yaml string) (*App, error) {
+		os.WriteFile(path, []byte("root: /tmp\n"+yaml), 0644)
+		return store.loadYAMLApp("shop.yml", path)
+	}
+
+	app, err := load(`
+services:
+  web:
+    cmd: npm run dev
+    default: true
+  api:
+    cmd: rails s
+  admin:
+    cmd: rails s -e admin
+routes:
+  - path: /api/
+    service: api
+    strip_prefix: true
+  - path: /api/admin
+    service: admin
+  - path: /
+    service: web
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("matches the longest prefix", func(t *testing.T) {
+		cases := map[string]string{
+			"/":                "web",
+			"/products":        "web",
+			"/api":             "api",
+			"/api/users":       "api",
+			"/apiary":          "web",
+			"/api/admin/users": "admin",
+		}
+		for path, want := range cases {
+			route := app.RouteFor(path)
+			if route == nil || route.Service != want {
+				t.Errorf("%s: expected service %s, got %+v", path, want, route)
+			}
+		}
+	})
+
+	t.Run("strips the prefix", func(t *testing.T) {
+		route := app.RouteFor("/api/users")
+		if !route.StripPrefix {
+			t.Fatalf("expected strip_prefix on %+v", route)
+		}
+		for path, want := range map[string]string{"/api/users": "/users", "/api": "/", "/api/": "/"} {
+			if got := route.Strip(path); got != want {
+				t.Errorf("%s: expected %s, got %s", path, want, got)
+			}
+		}
+	})
+
+	t.Run("no match without a / route", func(t *testing.T) {
+		app, err := load("services:\n  web:\n    cmd: x\n  api:\n    cmd: y\nroutes:\n  - path: /api\n    service: api\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if route := app.RouteFor("/products"); route != nil {
+			t.Errorf("expected no route, got %+v", route)
+		}
+	})
+
+	t.Run("invalid routes", func(t *testing.T) {
+		services := "services:\n  web:\n    cmd: x\n  api:\n    cmd: y\n"
+		cases := map[string]string{
+			services + "routes:\n  - path: api\n    service: api\n":                                     `routes[0]: path "api" must start with /`,
+			services + "routes:\n  - path: /api\n    service: worker\n":                                 `routes[0]: unknown service "worker"`,
+			services + "routes:\n  - path: /api\n    service: api\n  - path: /api/\n    service: web\n": `routes[1]: path "/api/" is routed twice`,
+			"cmd: rails s\nroutes:\n  - path: /api\n    service: web\n":                                 "routes requires a multi-service app",
+		}
+		for yaml, want := range cases {
+			if _, err := load(yaml); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%q: expected error containing %q, got %v", yaml, want, err)
+			}
+		}
+	})
+}
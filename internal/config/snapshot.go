@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MissReloadInterval is the minimum time between reloads triggered by
+// lookups of unknown apps
+const MissReloadInterval = 2 * time.Second
+
+// Snapshot is an immutable set of loaded apps. A new snapshot with a higher
+// Version replaces it whenever configs are reloaded or a profile changes.
+type Snapshot struct {
+	Version  uint64
+	LoadedAt time.Time // When the configs were last read from disk
+	apps     map[string]*App
+}
+
+// publish swaps in a new snapshot of apps. Caller must hold s.mu.
+func (s *AppStore) publish(apps map[string]*App, loadedAt time.Time) *Snapshot {
+	next := &Snapshot{
+		Version:  s.snapshot.Load().Version + 1,
+		LoadedAt: loadedAt,
+		apps:     apps,
+	}
+	s.snapshot.Store(next)
+	return next
+}
+
+// copyApps returns a copy of the app map for building the next snapshot
+func (sn *Snapshot) copyApps() map[string]*App {
+	apps := make(map[string]*App, len(sn.apps))
+	for name, app := range sn.apps {
+		apps[name] = app
+	}
+	return apps
+}
+
+// Get returns an app by name
+func (sn *Snapshot) Get(name string) (*App, bool) {
+	app, ok := sn.apps[name]
+	return app, ok
+}
+
+// GetByNameOrAlias returns an app by name or alias
+func (sn *Snapshot) GetByNameOrAlias(nameOrAlias string) (*App, bool) {
+	// Try direct name first
+	if app, ok := sn.apps[nameOrAlias]; ok {
+		return app, true
+	}
+
+	// Search aliases
+	for _, app := range sn.apps {
+		for _, alias := range app.Aliases {
+			if alias == nameOrAlias {
+				return app, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// GetService returns a specific service from a multi-service app
+func (sn *Snapshot) GetService(appName, serviceName string) (*App, *Service, bool) {
+	app, ok := sn.GetByNameOrAlias(appName)
+	if !ok || app.Type != AppTypeYAML {
+		return nil, nil, false
+	}
+
+	for i := range app.Services {
+		if app.Services[i].Name == serviceName {
+			return app, &app.Services[i], true
+		}
+	}
+
+	return app, nil, false
+}
+
+// All returns all apps sorted alphabetically
+func (sn *Snapshot) All() []*App {
+	apps := make([]*App, 0, len(sn.apps))
+	for _, app := range sn.apps {
+		apps = append(apps, app)
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+
+	return apps
+}
+
+// Diff describes how the apps changed between two snapshots
+type Diff struct {
+	Added   []string    // Apps that are new
+	Removed []string    // Apps that are no longer configured
+	Changed []AppChange // Apps whose configuration differs
+}
+
+// AppChange describes a changed app. Fields are named by their YAML keys.
+type AppChange struct {
+	Name            string
+	Fields          []string // App settings that changed
+	AddedServices   []string
+	RemovedServices []string
+	ChangedServices []ServiceChange
+}
+
+// ServiceChange describes a changed service of a multi-service app
+type ServiceChange struct {
+	Name   string
+	Fields []string // Service settings that changed
+}
+
+// restartFields are the settings a running process depends on. Changes to
+// other settings (description, aliases, default, ...) only affect routing
+// and the dashboard.
+var restartFields = map[string]bool{
+	"type":        true,
+	"port":        true,
+	"cmd":         true,
+	"dir":         true,
+	"static":      true,
+	"host":        true,
+	"pidfile":     true,
+	"logfile":     true,
+	"limits":      true,
+	"env":         true,
+	"depends_on":  true,
+	"scale":       true,
+	"pause_after": true,
+	"tcp":         true,
+}
+
+// RestartReasons returns the changed app settings that require restarting
+// the app's processes
+func (c AppChange) RestartReasons() []string {
+	return restartReasons(c.Fields)
+}
+
+// RestartReasons returns the changed service settings that require
+// restarting the service
+func (c ServiceChange) RestartReasons() []string {
+	return restartReasons(c.Fields)
+}
+
+// Has returns true if the app setting changed
+func (c AppChange) Has(field string) bool {
+	return containsString(c.Fields, field)
+}
+
+func restartReasons(fields []string) []string {
+	var reasons []string
+	for _, field := range fields {
+		if restartFields[field] {
+			reasons = append(reasons, field)
+		}
+	}
+	return reasons
+}
+
+// Empty returns true if nothing changed
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String summarizes the diff for logs, e.g.
+// "added blog; changed shop (web: cmd, worker added)"
+func (d Diff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+	var parts []string
+	if len(d.Added) > 0 {
+		parts = append(parts, "added "+strings.Join(d.Added, ", "))
+	}
+	if len(d.Removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(d.Removed, ", "))
+	}
+	if len(d.Changed) > 0 {
+		changed := make([]string, len(d.Changed))
+		for i, c := range d.Changed {
+			changed[i] = c.String()
+		}
+		parts = append(parts, "changed "+strings.Join(changed, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// String returns the app name with what changed, e.g.
+// "shop (description, web: cmd, worker added)"
+func (c AppChange) String() string {
+	details := append([]string(nil), c.Fields...)
+	for _, svc := range c.ChangedServices {
+		details = append(details, svc.Name+": "+strings.Join(svc.Fields, ", "))
+	}
+	for _, name := range c.AddedServices {
+		details = append(details, name+" added")
+	}
+	for _, name := range c.RemovedServices {
+		details = append(details, name+" removed")
+	}
+	if len(details) == 0 {
+		return c.Name
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, strings.Join(details, ", "))
+}
+
+// diffSnapshots compares the apps in old and next
+func diffSnapshots(old, next *Snapshot) Diff {
+	var diff Diff
+	for name, app := range next.apps {
+		prev, ok := old.apps[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		if change, changed := diffApp(prev, app); changed {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for name := range old.apps {
+		if _, ok := next.apps[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Name < diff.Changed[j].Name
+	})
+	return diff
+}
+
+// diffApp compares two versions of an app setting by setting. Returns false
+// if nothing that fireup uses changed.
+func diffApp(old, next *App) (AppChange, bool) {
+	change := AppChange{Name: next.Name}
+	field := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			change.Fields = append(change.Fields, name)
+		}
+	}
+	field("type", old.Type, next.Type)
+	field("port", []interface{}{old.Port, old.PortAuto, old.PortPattern}, []interface{}{next.Port, next.PortAuto, next.PortPattern})
+	field("host", old.Host, next.Host)
+	field("pidfile", old.PIDFile, next.PIDFile)
+	field("logfile", old.LogFile, next.LogFile)
+	field("limits", old.Limits, next.Limits)
+	field("cmd", old.Command, next.Command)
+	field("dir", old.Dir, next.Dir)
+	field("static", old.FilePath, next.FilePath)
+	field("env", old.Env, next.Env)
+	field("description", old.Description, next.Description)
+	field("aliases", old.Aliases, next.Aliases)
+	field("hidden", old.Hidden, next.Hidden)
+	field("group", old.Group, next.Group)
+	field("profiles", old.Profiles, next.Profiles)
+	field("routes", old.Routes, next.Routes)
+	field("restart_dependents", old.RestartDependents, next.RestartDependents)
+	field("app_depends_on", old.AppDeps, next.AppDeps)
+	field("stop_dependencies", old.StopDependencies, next.StopDependencies)
+	field("shared", old.Shared, next.Shared)
+	field("shared_grace", old.SharedGrace, next.SharedGrace)
+	field("pause_after", old.PauseAfter, next.PauseAfter)
+	field("inspect", old.Inspect, next.Inspect)
+	field("restart_strategy", old.RestartStrategy, next.RestartStrategy)
+
+	oldServices := make(map[string]Service, len(old.Services))
+	for _, svc := range old.Services {
+		oldServices[svc.Name] = svc
+	}
+	for _, svc := range next.Services {
+		prev, ok := oldServices[svc.Name]
+		delete(oldServices, svc.Name)
+		if !ok {
+			change.AddedServices = append(change.AddedServices, svc.Name)
+			continue
+		}
+		if fields := diffService(prev, svc); len(fields) > 0 {
+			change.ChangedServices = append(change.ChangedServices, ServiceChange{Name: svc.Name, Fields: fields})
+		}
+	}
+	for name := range oldServices {
+		change.RemovedServices = append(change.RemovedServices, name)
+	}
+
+	sort.Strings(change.AddedServices)
+	sort.Strings(change.RemovedServices)
+	sort.Slice(change.ChangedServices, func(i, j int) bool {
+		return change.ChangedServices[i].Name < change.ChangedServices[j].Name
+	})
+
+	changed := len(change.Fields) > 0 || len(change.AddedServices) > 0 ||
+		len(change.RemovedServices) > 0 || len(change.ChangedServices) > 0
+	return change, changed
+}
+
+// diffService returns the settings that differ between two versions of a service
+func diffService(old, next Service) []string {
+	var fields []string
+	field := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			fields = append(fields, name)
+		}
+	}
+	field("cmd", old.Command, next.Command)
+	field("dir", old.Dir, next.Dir)
+	field("env", old.Env, next.Env)
+	field("port", []interface{}{old.PortAuto, old.PortPattern}, []interface{}{next.PortAuto, next.PortPattern})
+	field("host", old.Host, next.Host)
+	field("pidfile", old.PIDFile, next.PIDFile)
+	field("logfile", old.LogFile, next.LogFile)
+	field("limits", old.Limits, next.Limits)
+	field("depends_on", []interface{}{old.DependsOn, old.AppDeps}, []interface{}{next.DependsOn, next.AppDeps})
+	field("default", old.Default, next.Default)
+	field("restart_strategy", old.RestartStrategy, next.RestartStrategy)
+	field("scale", old.Scale, next.Scale)
+	field("balance", old.Balance, next.Balance)
+	field("tcp", old.TCPListen, next.TCPListen)
+	return fields
+}
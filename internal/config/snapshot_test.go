@@ -0,0 +1,216 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloadDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+	write("blog", "3000")
+	write("old", "4000")
+	write("shop.yml", `
+root: /tmp
+services:
+  web:
+    cmd: rails s
+  worker:
+    cmd: sidekiq
+  cron:
+    cmd: clockwork
+`)
+
+	store := NewAppStore(&Config{Dir: tmpDir})
+	diff, err := store.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 3 || store.Snapshot().Version != 1 {
+		t.Fatalf("expected 3 added apps in version 1, got %+v (v%d)", diff, store.Snapshot().Version)
+	}
+
+	write("blog", "3001")
+	os.Remove(filepath.Join(tmpDir, "old"))
+	write("new", "5000")
+	write("shop.yml", `
+root: /tmp
+services:
+  web:
+    cmd: rails s -p $PORT
+  worker:
+    cmd: sidekiq
+  mailer:
+    cmd: mailcatcher
+`)
+
+	diff, err = store.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := diff.String(); got != "added new; removed old; changed blog (port), shop (web: cmd, mailer added, cron removed)" {
+		t.Errorf("unexpected diff: %s", got)
+	}
+	if store.Snapshot().Version != 2 {
+		t.Errorf("expected version 2, got %d", store.Snapshot().Version)
+	}
+
+	diff, _ = store.Reload()
+	if !diff.Empty() {
+		t.Errorf("expected no changes, got %s", diff)
+	}
+
+	write("shop.yml", `
+description: Online shop
+root: /tmp
+services:
+  web:
+    cmd: rails s -p $PORT
+    default: true
+  worker:
+    cmd: sidekiq
+  mailer:
+    cmd: mailcatcher
+`)
+	diff, _ = store.Reload()
+	if got := diff.String(); got != "changed shop (description, web: default)" {
+		t.Errorf("unexpected diff: %s", got)
+	}
+	change := diff.Changed[0]
+	if len(change.RestartReasons()) != 0 || len(change.ChangedServices[0].RestartReasons()) != 0 {
+		t.Errorf("expected no restart reasons, got %+v", change)
+	}
+
+	// The pause timer is armed when the process starts
+	write("shop.yml", `
+description: Online shop
+root: /tmp
+pause_after: 10m
+services:
+  web:
+    cmd: rails s -p $PORT
+    default: true
+  worker:
+    cmd: sidekiq
+  mailer:
+    cmd: mailcatcher
+`)
+	diff, _ = store.Reload()
+	if got := diff.Changed[0].RestartReasons(); len(got) != 1 || got[0] != "pause_after" {
+		t.Errorf("expected pause_after to restart the app, got %v", got)
+	}
+
+	write("shop.yml", `
+description: Online shop
+root: /tmp
+pause_after: 10m
+services:
+  web:
+    cmd: rails s -p $PORT
+    default: true
+  worker:
+    cmd: sidekiq
+  mailer:
+    cmd: mailcatcher
+    tcp: { listen: 1025 }
+`)
+	diff, _ = store.Reload()
+	if got := diff.Changed[0].ChangedServices[0].RestartReasons(); len(got) != 1 || got[0] != "tcp" {
+		t.Errorf("expected tcp to restart the service, got %v", got)
+	}
+
+	// A new app dependency is started, not restarted into
+	write("shop.yml", `
+description: Online shop
+root: /tmp
+pause_after: 10m
+depends_on: [app:blog]
+services:
+  web:
+    cmd: rails s -p $PORT
+    default: true
+  worker:
+    cmd: sidekiq
+  mailer:
+    cmd: mailcatcher
+    tcp: { listen: 1025 }
+`)
+	diff, _ = store.Reload()
+	change = diff.Changed[0]
+	if got := change.String(); got != "shop (app_depends_on)" {
+		t.Errorf("unexpected diff: %s", got)
+	}
+	if !change.Has("app_depends_on") || len(change.RestartReasons()) != 0 {
+		t.Errorf("expected app_depends_on without restart reasons, got %+v", change)
+	}
+}
+
+func TestReloadIsAtomic(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte("3000"), 0644)
+	}
+	store := NewAppStore(&Config{Dir: tmpDir})
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, ok := store.Get("d"); !ok {
+				t.Error("app disappeared during reload")
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		store.Reload()
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestReloadOnMiss(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewAppStore(&Config{Dir: tmpDir})
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "myapp"), []byte("3000"), 0644)
+
+	if store.ReloadOnMiss() {
+		t.Error("expected no reload right after loading")
+	}
+	if _, ok := store.Get("myapp"); ok {
+		t.Error("expected myapp not to be loaded yet")
+	}
+
+	// Pretend the last load was a while ago
+	current := store.Snapshot()
+	store.publish(current.apps, current.LoadedAt.Add(-MissReloadInterval))
+
+	if !store.ReloadOnMiss() {
+		t.Error("expected a reload once the interval has passed")
+	}
+	if _, ok := store.Get("myapp"); !ok {
+		t.Error("expected myapp to be loaded")
+	}
+	if time.Since(store.Snapshot().LoadedAt) > time.Second {
+		t.Error("expected LoadedAt to be updated")
+	}
+}
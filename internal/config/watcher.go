@@ -2,7 +2,9 @@ package config
 
 import (
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,13 +18,21 @@ type Watcher struct {
 	onChange func(changedFiles []string)
 	done     chan struct{}
 
+	mu       sync.Mutex
+	watched  map[string]bool // Directories of the config tree being watched
+	files    map[string]bool // Files outside the config tree to watch, see WatchFiles
+	fileDirs map[string]bool // Directories watched for files
+
 	// Track changed files during debounce window
 	pendingMu    sync.Mutex
 	pendingFiles map[string]bool
 }
 
-// NewWatcher creates a new config directory watcher
-// The onChange callback receives a list of changed filenames (base names, not full paths)
+// NewWatcher creates a new config directory watcher. Group directories are
+// watched too, and followed as they are created or removed.
+// The onChange callback receives a list of changed files relative to dir
+// (e.g. "shop.yml" or "work/shop.yml"), and the absolute paths of changed
+// files added with WatchFiles
 func NewWatcher(dir string, onChange func(changedFiles []string)) (*Watcher, error) {
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -34,13 +44,97 @@ func NewWatcher(dir string, onChange func(changedFiles []string)) (*Watcher, err
 		return nil, err
 	}
 
-	return &Watcher{
+	watcher := &Watcher{
 		watcher:      w,
 		dir:          dir,
 		onChange:     onChange,
 		done:         make(chan struct{}),
+		watched:      map[string]bool{dir: true},
+		files:        make(map[string]bool),
+		fileDirs:     make(map[string]bool),
 		pendingFiles: make(map[string]bool),
-	}, nil
+	}
+	watcher.syncDirs()
+	return watcher, nil
+}
+
+// syncDirs watches every group directory plus the plain subdirectories of
+// the config and group directories, so that a new GroupMarker is noticed.
+// Directories that are gone are dropped.
+func (w *Watcher) syncDirs() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	want := make(map[string]bool)
+	for _, dir := range GroupDirs(w.dir) {
+		want[dir] = true
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != "certs" {
+				want[filepath.Join(dir, entry.Name())] = true
+			}
+		}
+	}
+
+	for dir := range want {
+		if !w.watched[dir] {
+			if err := w.watcher.Add(dir); err == nil {
+				w.watched[dir] = true
+			}
+		}
+	}
+	for dir := range w.watched {
+		if !want[dir] {
+			if !w.fileDirs[dir] {
+				w.watcher.Remove(dir)
+			}
+			delete(w.watched, dir)
+		}
+	}
+}
+
+// WatchFiles sets the files outside the config tree to watch, replacing
+// the previous ones: files apps extend and Procfiles they read. Their
+// directories are watched rather than the files, so editors that replace
+// a file on save are followed.
+func (w *Watcher) WatchFiles(paths []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if w.watched[dir] {
+			continue // Reported as part of the config tree
+		}
+		files[path] = true
+		dirs[dir] = true
+	}
+
+	for dir := range dirs {
+		if !w.fileDirs[dir] {
+			if err := w.watcher.Add(dir); err != nil {
+				log.Printf("Config watcher: can't watch %s: %v", dir, err)
+				continue
+			}
+			w.fileDirs[dir] = true
+		}
+	}
+	for dir := range w.fileDirs {
+		if !dirs[dir] {
+			if !w.watched[dir] {
+				w.watcher.Remove(dir)
+			}
+			delete(w.fileDirs, dir)
+		}
+	}
+	w.files = files
+}
+
+// watchedFile returns true if path was added with WatchFiles
+func (w *Watcher) watchedFile(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.files[path]
 }
 
 // Start begins watching for changes
@@ -54,6 +148,25 @@ func (w *Watcher) Stop() {
 	w.watcher.Close()
 }
 
+// changedName returns how onChange reports a changed file, or false if
+// the change doesn't matter
+func (w *Watcher) changedName(path string) (string, bool) {
+	if w.watchedFile(path) {
+		return path, true
+	}
+	// Inside plain directories (static sites), only a new group marker
+	// matters. Other files in the directories of watched files don't.
+	dir := filepath.Dir(path)
+	if dir != w.dir && !IsGroupDir(dir) && filepath.Base(path) != GroupMarker {
+		return "", false
+	}
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
 func (w *Watcher) run() {
 	// Debounce timer - wait for rapid changes to settle
 	var debounceTimer *time.Timer
@@ -74,9 +187,18 @@ func (w *Watcher) run() {
 
 			// Only react to relevant events
 			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				// Follow directories as they come and go
+				if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+					w.syncDirs()
+				}
+
 				// Track this changed file
+				name, ok := w.changedName(event.Name)
+				if !ok {
+					continue
+				}
 				w.pendingMu.Lock()
-				w.pendingFiles[filepath.Base(event.Name)] = true
+				w.pendingFiles[name] = true
 				w.pendingMu.Unlock()
 
 				// Debounce: reset timer on each event
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -183,6 +185,95 @@ func TestWatcher(t *testing.T) {
 		}
 	})
 
+	t.Run("follows new group directories", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		var mu sync.Mutex
+		var seen []string
+		w, err := NewWatcher(tmpDir, func(changedFiles []string) {
+			mu.Lock()
+			seen = append(seen, changedFiles...)
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("failed to create watcher: %v", err)
+		}
+		w.Start()
+		defer w.Stop()
+
+		time.Sleep(50 * time.Millisecond)
+
+		// Create a group, then a config inside it
+		groupDir := filepath.Join(tmpDir, "work")
+		os.Mkdir(groupDir, 0755)
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(filepath.Join(groupDir, GroupMarker), nil, 0644)
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(filepath.Join(groupDir, "shop.yml"), []byte("cmd: x"), 0644)
+
+		// Files in a plain directory (a static site) are ignored
+		siteDir := filepath.Join(tmpDir, "site")
+		os.Mkdir(siteDir, 0755)
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(filepath.Join(siteDir, "index.html"), []byte("hi"), 0644)
+
+		time.Sleep(400 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		joined := strings.Join(seen, ",")
+		if !strings.Contains(joined, "work/shop.yml") {
+			t.Errorf("expected work/shop.yml to be reported, got %v", seen)
+		}
+		if strings.Contains(joined, "index.html") {
+			t.Errorf("expected static site files to be ignored, got %v", seen)
+		}
+	})
+
+	t.Run("watches files outside the config directory", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		project := t.TempDir()
+		base := filepath.Join(project, "base.yml")
+		os.WriteFile(base, []byte("cmd: x"), 0644)
+
+		var mu sync.Mutex
+		var seen []string
+		w, err := NewWatcher(tmpDir, func(changedFiles []string) {
+			mu.Lock()
+			seen = append(seen, changedFiles...)
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("failed to create watcher: %v", err)
+		}
+		w.WatchFiles([]string{base, filepath.Join(tmpDir, "shop.yml")})
+		w.Start()
+		defer w.Stop()
+
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(base, []byte("cmd: y"), 0644)
+		// Other files next to it don't matter
+		os.WriteFile(filepath.Join(project, "main.go"), []byte("package main"), 0644)
+		time.Sleep(400 * time.Millisecond)
+
+		mu.Lock()
+		if strings.Join(seen, ",") != base {
+			t.Errorf("expected only %s to be reported, got %v", base, seen)
+		}
+		seen = nil
+		mu.Unlock()
+
+		// Files no longer watched aren't reported
+		w.WatchFiles(nil)
+		os.WriteFile(base, []byte("cmd: z"), 0644)
+		time.Sleep(400 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(seen) != 0 {
+			t.Errorf("expected no changes once unwatched, got %v", seen)
+		}
+	})
+
 	t.Run("handles non-existent directory", func(t *testing.T) {
 		_, err := NewWatcher("/nonexistent/path/12345", func(changedFiles []string) {})
 		if err == nil {
@@ -24,14 +24,17 @@ func New(port int, tld string) *Server {
 
 // Start starts the DNS server
 func (s *Server) Start() error {
+	// Use a dedicated mux so a restarted server doesn't share handlers
+	mux := dns.NewServeMux()
+	mux.HandleFunc(s.tld+".", s.handleQuery)
+	mux.HandleFunc(".", s.handleQuery) // Handle all queries
+
 	s.server = &dns.Server{
-		Addr: fmt.Sprintf("127.0.0.1:%d", s.port),
-		Net:  "udp",
+		Addr:    fmt.Sprintf("127.0.0.1:%d", s.port),
+		Net:     "udp",
+		Handler: mux,
 	}
 
-	dns.HandleFunc(s.tld+".", s.handleQuery)
-	dns.HandleFunc(".", s.handleQuery) // Handle all queries
-
 	return s.server.ListenAndServe()
 }
 
@@ -0,0 +1,219 @@
+package inspect
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAR is an HTTP Archive 1.2 file, as read and written by browser devtools
+// (http://www.softwareishard.com/blog/har-12-spec/)
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR file
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the program that wrote a HAR file
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a request and its response
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest is the request of an entry
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is the response of an entry
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of a request
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARContent is the body of a response
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings break down the time of an entry. fireup only knows the wait.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHAR exports exchanges as a HAR file. Headers stay redacted, and cut
+// off bodies are marked with a comment.
+func NewHAR(exchanges []Exchange, version string) HAR {
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "fireup", Version: version},
+		Entries: []HAREntry{},
+	}}
+	for _, e := range exchanges {
+		u := url.URL{Scheme: "http", Host: e.Host}
+		if parsed, err := url.ParseRequestURI(e.URL); err == nil {
+			u.Path, u.RawPath, u.RawQuery = parsed.Path, parsed.RawPath, parsed.RawQuery
+		}
+		entry := HAREntry{
+			StartedDateTime: e.Time.Format(time.RFC3339Nano),
+			Time:            e.Duration,
+			Request: HARRequest{
+				Method:      e.Method,
+				URL:         u.String(),
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []HARNameValue{},
+				Headers:     harHeaders(e.RequestHeaders),
+				QueryString: harQuery(u.Query()),
+				HeadersSize: -1,
+				BodySize:    e.RequestBody.Size,
+			},
+			Response: HARResponse{
+				Status:      e.Status,
+				StatusText:  http.StatusText(e.Status),
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []HARNameValue{},
+				Headers:     harHeaders(e.ResponseHeaders),
+				Content: HARContent{
+					Size:     e.ResponseBody.Size,
+					MimeType: e.ResponseHeaders.Get("Content-Type"),
+					Text:     e.ResponseBody.Text,
+					Comment:  bodyComment(e.ResponseBody),
+				},
+				RedirectURL: e.ResponseHeaders.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    e.ResponseBody.Size,
+			},
+			Timings: HARTimings{Send: 0, Wait: e.Duration, Receive: 0},
+			Comment: e.Error,
+		}
+		if e.RequestBody.Size > 0 {
+			entry.Request.PostData = &HARPostData{
+				MimeType: e.RequestHeaders.Get("Content-Type"),
+				Text:     e.RequestBody.Text,
+				Comment:  bodyComment(e.RequestBody),
+			}
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+	return har
+}
+
+// harHeaders lists headers sorted by name
+func harHeaders(h http.Header) []HARNameValue {
+	list := []HARNameValue{}
+	for name, values := range h {
+		for _, value := range values {
+			list = append(list, HARNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// harQuery lists query parameters sorted by name
+func harQuery(q url.Values) []HARNameValue {
+	list := []HARNameValue{}
+	for name, values := range q {
+		for _, value := range values {
+			list = append(list, HARNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// bodyComment explains a body that wasn't kept whole
+func bodyComment(b Body) string {
+	switch {
+	case b.Binary:
+		return fmt.Sprintf("binary body of %d bytes not captured", b.Size)
+	case b.Truncated:
+		return fmt.Sprintf("truncated to %d of %d bytes", len(b.Text), b.Size)
+	}
+	return ""
+}
+
+// NewRequest builds a request that sends the entry's request again, with
+// host as its Host, to be served by fireup
+func (entry HAREntry) NewRequest(host string) (*http.Request, error) {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %v", entry.Request.URL, err)
+	}
+	body := ""
+	if entry.Request.PostData != nil {
+		body = entry.Request.PostData.Text
+	}
+	r, err := http.NewRequest(entry.Request.Method, "http://"+host+u.RequestURI(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.RequestURI = u.RequestURI()
+	for _, h := range entry.Request.Headers {
+		// Skip HTTP/2 pseudo-headers and headers the body and host decide
+		switch {
+		case strings.HasPrefix(h.Name, ":"),
+			strings.EqualFold(h.Name, "Host"),
+			strings.EqualFold(h.Name, "Content-Length"),
+			h.Value == Redacted:
+			continue
+		}
+		r.Header.Add(h.Name, h.Value)
+	}
+	if entry.Request.PostData != nil && r.Header.Get("Content-Type") == "" && entry.Request.PostData.MimeType != "" {
+		r.Header.Set("Content-Type", entry.Request.PostData.MimeType)
+	}
+	return r, nil
+}
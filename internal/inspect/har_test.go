@@ -0,0 +1,52 @@
+package inspect
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHAR(t *testing.T) {
+	e := Exchange{
+		Time:            time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		Duration:        12.5,
+		Method:          "POST",
+		URL:             "/hook?source=stripe",
+		Host:            "app.test",
+		RequestHeaders:  http.Header{"Content-Type": {"application/json"}, "Authorization": {Redacted}},
+		RequestBody:     Body{Text: `{"id":1}`, Size: 8},
+		Status:          201,
+		ResponseHeaders: http.Header{"Content-Type": {"text/plain"}},
+		ResponseBody:    Body{Text: "ok", Size: 20, Truncated: true},
+	}
+	har := NewHAR([]Exchange{e}, "1.0.0")
+	if har.Log.Version != "1.2" || har.Log.Creator.Version != "1.0.0" || len(har.Log.Entries) != 1 {
+		t.Fatalf("unexpected HAR %+v", har.Log)
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.URL != "http://app.test/hook?source=stripe" || entry.StartedDateTime != "2026-01-02T15:04:05Z" {
+		t.Errorf("unexpected request %+v", entry.Request)
+	}
+	if len(entry.Request.QueryString) != 1 || entry.Request.QueryString[0] != (HARNameValue{"source", "stripe"}) {
+		t.Errorf("expected the query string, got %v", entry.Request.QueryString)
+	}
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != `{"id":1}` {
+		t.Errorf("expected the request body, got %+v", entry.Request.PostData)
+	}
+	if entry.Response.Status != 201 || entry.Response.StatusText != "Created" || entry.Response.Content.Comment != "truncated to 2 of 20 bytes" {
+		t.Errorf("unexpected response %+v", entry.Response)
+	}
+
+	r, err := entry.NewRequest("other.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r.Body)
+	if r.Method != "POST" || r.Host != "other.test" || r.RequestURI != "/hook?source=stripe" || string(data) != `{"id":1}` {
+		t.Errorf("unexpected request %s %s%s %q", r.Method, r.Host, r.RequestURI, data)
+	}
+	if _, found := r.Header["Authorization"]; found || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected redacted headers to be left out, got %v", r.Header)
+	}
+}
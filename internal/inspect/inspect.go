@@ -0,0 +1,278 @@
+// Package inspect keeps the recent requests to an app and their responses,
+// for debugging webhooks and API calls without adding logging to the app.
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for the Options an app doesn't set
+const (
+	DefaultMaxRequests = 50
+	DefaultMaxBody     = 8 << 10
+)
+
+// DefaultRedact are the headers whose values aren't kept unless the app
+// sets its own list
+var DefaultRedact = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Redacted replaces the values of redacted headers
+const Redacted = "[redacted]"
+
+// MaxReplayBody is how much of a request body is kept for replaying it.
+// Requests with larger bodies can't be replayed.
+const MaxReplayBody = 1 << 20
+
+// lastID numbers exchanges across all captures, so an ID alone finds one
+var lastID atomic.Int64
+
+// Options configure the capture of an app's requests
+type Options struct {
+	MaxRequests int      // Exchanges kept, oldest dropped first
+	MaxBody     int      // Bytes kept of each request and response body
+	Redact      []string // Headers whose values are hidden; nil for DefaultRedact
+}
+
+// withDefaults returns o with the settings it leaves out filled in
+func (o Options) withDefaults() Options {
+	if o.MaxRequests <= 0 {
+		o.MaxRequests = DefaultMaxRequests
+	}
+	if o.MaxBody <= 0 {
+		o.MaxBody = DefaultMaxBody
+	}
+	if o.Redact == nil {
+		o.Redact = DefaultRedact
+	}
+	return o
+}
+
+// Equal returns true if o and other capture the same way
+func (o Options) Equal(other Options) bool {
+	a, b := o.withDefaults(), other.withDefaults()
+	if a.MaxRequests != b.MaxRequests || a.MaxBody != b.MaxBody || len(a.Redact) != len(b.Redact) {
+		return false
+	}
+	for i := range a.Redact {
+		if http.CanonicalHeaderKey(a.Redact[i]) != http.CanonicalHeaderKey(b.Redact[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Exchange is a captured request and the response to it
+type Exchange struct {
+	ID       int64     `json:"id"`
+	Target   string    `json:"target"` // App or service process that handled it
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_ms"`
+
+	Method         string      `json:"method"`
+	URL            string      `json:"url"` // Path and query as the client sent them
+	Host           string      `json:"host"`
+	RequestHeaders http.Header `json:"request_headers"`
+	RequestBody    Body        `json:"request_body"`
+
+	Status          int         `json:"status"`
+	ResponseHeaders http.Header `json:"response_headers"`
+	ResponseBody    Body        `json:"response_body"`
+
+	Error    string `json:"error,omitempty"`     // Why the backend couldn't be reached
+	ReplayOf int64  `json:"replay_of,omitempty"` // ID of the exchange this replayed
+
+	// The request as received, unredacted, for replaying it. Never
+	// exported or shown.
+	original *originalRequest
+}
+
+// originalRequest is what's needed to send a request again
+type originalRequest struct {
+	header http.Header
+	body   []byte
+	whole  bool // body isn't cut off at MaxReplayBody
+}
+
+// KeepRequest keeps the unredacted request headers and the body written to
+// body, so the exchange can be replayed
+func (e *Exchange) KeepRequest(header http.Header, body *BodyWriter) {
+	e.original = &originalRequest{
+		header: header.Clone(),
+		body:   bytes.Clone(body.buf),
+		whole:  body.size == int64(len(body.buf)),
+	}
+}
+
+// NewRequest builds a request that sends the exchange again, to be served
+// by a proxy to its backend
+func (e Exchange) NewRequest() (*http.Request, error) {
+	if e.original == nil {
+		return nil, fmt.Errorf("request %d wasn't kept for replaying", e.ID)
+	}
+	if !e.original.whole {
+		return nil, fmt.Errorf("request %d has a body over %d bytes, too large to replay", e.ID, MaxReplayBody)
+	}
+	r, err := http.NewRequest(e.Method, "http://"+e.Host+e.URL, bytes.NewReader(e.original.body))
+	if err != nil {
+		return nil, err
+	}
+	r.Host = e.Host
+	r.Header = e.original.header.Clone()
+	r.RequestURI = e.URL
+	return r, nil
+}
+
+// Body is the start of a request or response body
+type Body struct {
+	Text      string `json:"text,omitempty"`
+	Size      int64  `json:"size"`                // Full size in bytes
+	Truncated bool   `json:"truncated,omitempty"` // Text is only the first MaxBody bytes
+	Binary    bool   `json:"binary,omitempty"`    // Not text, e.g. an image or gzip; Text is empty
+}
+
+// Capture is a ring buffer of an app's most recent exchanges
+type Capture struct {
+	opts   Options
+	redact map[string]bool
+
+	mu        sync.Mutex
+	exchanges []Exchange // Oldest first
+}
+
+// NewCapture creates an empty capture
+func NewCapture(opts Options) *Capture {
+	opts = opts.withDefaults()
+	redact := make(map[string]bool)
+	for _, name := range opts.Redact {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+	return &Capture{opts: opts, redact: redact}
+}
+
+// Options returns the options the capture was created with, defaults
+// filled in
+func (c *Capture) Options() Options {
+	return c.opts
+}
+
+// NewBody returns a writer keeping as much of a response body as the
+// capture shows
+func (c *Capture) NewBody() *BodyWriter {
+	return &BodyWriter{max: c.opts.MaxBody, show: c.opts.MaxBody}
+}
+
+// NewRequestBody returns a writer keeping enough of a request body to
+// replay it, of which it shows as much as NewBody
+func (c *Capture) NewRequestBody() *BodyWriter {
+	return &BodyWriter{max: max(MaxReplayBody, c.opts.MaxBody), show: c.opts.MaxBody}
+}
+
+// Add redacts the headers of e, assigns it the next ID and stores it,
+// dropping the oldest exchange if the capture is full. Returns the stored
+// exchange.
+func (c *Capture) Add(e Exchange) Exchange {
+	e.RequestHeaders = c.redactHeaders(e.RequestHeaders)
+	e.ResponseHeaders = c.redactHeaders(e.ResponseHeaders)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.ID = lastID.Add(1)
+	c.exchanges = append(c.exchanges, e)
+	if len(c.exchanges) > c.opts.MaxRequests {
+		c.exchanges = c.exchanges[len(c.exchanges)-c.opts.MaxRequests:]
+	}
+	return e
+}
+
+// Get returns the exchange with the given ID, if the capture still has it
+func (c *Capture) Get(id int64) (Exchange, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.exchanges {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Exchange{}, false
+}
+
+// Since returns the exchanges with an ID above id, oldest first. Since(0)
+// returns all of them.
+func (c *Capture) Since(id int64) []Exchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := []Exchange{}
+	for _, e := range c.exchanges {
+		if e.ID > id {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// redactHeaders returns a copy of h with the values of redacted headers
+// replaced
+func (c *Capture) redactHeaders(h http.Header) http.Header {
+	result := h.Clone()
+	for name, values := range result {
+		if c.redact[http.CanonicalHeaderKey(name)] {
+			redacted := make([]string, len(values))
+			for i := range redacted {
+				redacted[i] = Redacted
+			}
+			result[name] = redacted
+		}
+	}
+	return result
+}
+
+// BodyWriter keeps the first bytes written to it and counts the rest
+type BodyWriter struct {
+	max  int // Bytes kept
+	show int // Bytes of those shown by Body
+	buf  []byte
+	size int64
+}
+
+// Write implements io.Writer. It never fails.
+func (b *BodyWriter) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+	if room := b.max - len(b.buf); room > 0 {
+		if len(p) > room {
+			b.buf = append(b.buf, p[:room]...)
+		} else {
+			b.buf = append(b.buf, p...)
+		}
+	}
+	return len(p), nil
+}
+
+// Body returns what was written so far
+func (b *BodyWriter) Body() Body {
+	text := b.buf
+	if len(text) > b.show {
+		text = text[:b.show]
+	}
+	body := Body{Size: b.size, Truncated: b.size > int64(len(text))}
+	if body.Truncated {
+		// Drop a character that was cut in half
+		for i := 0; i < utf8.UTFMax-1 && len(text) > 0; i++ {
+			if r, _ := utf8.DecodeLastRune(text); r != utf8.RuneError {
+				break
+			}
+			text = text[:len(text)-1]
+		}
+	}
+	if utf8.Valid(text) {
+		body.Text = string(text)
+	} else {
+		body.Binary = true
+	}
+	return body
+}
@@ -0,0 +1,88 @@
+package inspect
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	t.Run("keeps the most recent exchanges", func(t *testing.T) {
+		c := NewCapture(Options{MaxRequests: 2})
+		for _, url := range []string{"/a", "/b", "/c"} {
+			c.Add(Exchange{URL: url})
+		}
+		all := c.Since(0)
+		if len(all) != 2 || all[0].URL != "/b" || all[1].URL != "/c" {
+			t.Fatalf("expected /b and /c, got %+v", all)
+		}
+		if all[1].ID != all[0].ID+1 {
+			t.Errorf("expected IDs to keep counting, got %d and %d", all[0].ID, all[1].ID)
+		}
+		if since := c.Since(all[0].ID); len(since) != 1 || since[0].URL != "/c" {
+			t.Errorf("expected only /c after ID 2, got %+v", since)
+		}
+	})
+
+	t.Run("redacts sensitive headers", func(t *testing.T) {
+		c := NewCapture(Options{})
+		req := http.Header{"Authorization": {"Bearer secret"}, "Accept": {"*/*"}}
+		c.Add(Exchange{RequestHeaders: req, ResponseHeaders: http.Header{"Set-Cookie": {"a=1", "b=2"}}})
+		e := c.Since(0)[0]
+		if got := e.RequestHeaders.Get("Authorization"); got != Redacted {
+			t.Errorf("expected Authorization to be redacted, got %q", got)
+		}
+		if got := e.RequestHeaders.Get("Accept"); got != "*/*" {
+			t.Errorf("expected Accept to be kept, got %q", got)
+		}
+		if got := e.ResponseHeaders["Set-Cookie"]; len(got) != 2 || got[1] != Redacted {
+			t.Errorf("expected both cookies to be redacted, got %v", got)
+		}
+		if req.Get("Authorization") != "Bearer secret" {
+			t.Error("expected the original headers to be left alone")
+		}
+	})
+
+	t.Run("redacts configured headers", func(t *testing.T) {
+		c := NewCapture(Options{Redact: []string{"x-api-key"}})
+		c.Add(Exchange{RequestHeaders: http.Header{"X-Api-Key": {"k"}, "Cookie": {"c"}}})
+		e := c.Since(0)[0]
+		if e.RequestHeaders.Get("X-Api-Key") != Redacted || e.RequestHeaders.Get("Cookie") != "c" {
+			t.Errorf("expected only X-Api-Key to be redacted, got %v", e.RequestHeaders)
+		}
+	})
+}
+
+func TestBodyWriter(t *testing.T) {
+	c := NewCapture(Options{MaxBody: 8})
+
+	b := c.NewBody()
+	b.Write([]byte(`{"a":1}`))
+	if body := b.Body(); body.Text != `{"a":1}` || body.Size != 7 || body.Truncated {
+		t.Errorf("expected the whole body, got %+v", body)
+	}
+
+	b = c.NewBody()
+	b.Write([]byte("hello "))
+	b.Write([]byte("wörld, and more"))
+	if body := b.Body(); body.Text != "hello w" || body.Size != 22 || !body.Truncated {
+		t.Errorf("expected the first 7 bytes without the split ö, got %+v", body)
+	}
+
+	b = c.NewBody()
+	b.Write([]byte{0x1f, 0x8b, 0x08, 0xff})
+	if body := b.Body(); !body.Binary || body.Text != "" {
+		t.Errorf("expected a binary body, got %+v", body)
+	}
+}
+
+func TestOptionsEqual(t *testing.T) {
+	if !(Options{}).Equal(Options{MaxRequests: DefaultMaxRequests, Redact: DefaultRedact}) {
+		t.Error("expected defaults to equal the options they fill in")
+	}
+	if (Options{}).Equal(Options{Redact: []string{}}) {
+		t.Error("expected an empty redact list to differ from the default")
+	}
+	if !(Options{Redact: []string{"cookie"}}).Equal(Options{Redact: []string{"Cookie"}}) {
+		t.Error("expected header names to compare case-insensitively")
+	}
+}
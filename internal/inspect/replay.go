@@ -0,0 +1,69 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Edit changes a request before it's replayed. Zero fields keep the
+// original.
+type Edit struct {
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url,omitempty"`     // Path and query
+	Headers map[string]string `json:"headers,omitempty"` // Headers to set; "" removes one
+	Body    *string           `json:"body,omitempty"`
+}
+
+// Apply makes the edit to r
+func (ed Edit) Apply(r *http.Request) error {
+	if ed.Method != "" {
+		r.Method = strings.ToUpper(ed.Method)
+	}
+	if ed.URL != "" {
+		u, err := url.ParseRequestURI(ed.URL)
+		if err != nil || u.Host != "" {
+			return fmt.Errorf("invalid url %q (a path such as /webhook?x=1)", ed.URL)
+		}
+		r.URL.Path, r.URL.RawPath, r.URL.RawQuery = u.Path, u.RawPath, u.RawQuery
+		r.RequestURI = u.RequestURI()
+	}
+	for name, value := range ed.Headers {
+		if value == "" {
+			r.Header.Del(name)
+		} else {
+			r.Header.Set(name, value)
+		}
+	}
+	if ed.Body != nil {
+		r.Body = io.NopCloser(strings.NewReader(*ed.Body))
+		r.ContentLength = int64(len(*ed.Body))
+		r.Header.Del("Content-Length")
+	}
+	return nil
+}
+
+// Replay follows a replayed request through the proxy, which records it as
+// a replay of the exchange Of and stores the result in Exchange
+type Replay struct {
+	Of       int64    // 0 for requests that weren't captured, e.g. from a HAR
+	Exchange Exchange // The replayed exchange, once Recorded
+	Recorded bool
+}
+
+// replayKey is the context key of a Replay
+type replayKey struct{}
+
+// WithReplay returns a context marking a request as a replay
+func WithReplay(ctx context.Context, rp *Replay) context.Context {
+	return context.WithValue(ctx, replayKey{}, rp)
+}
+
+// ReplayFrom returns the replay a request is part of, or nil
+func ReplayFrom(ctx context.Context) *Replay {
+	rp, _ := ctx.Value(replayKey{}).(*Replay)
+	return rp
+}
@@ -0,0 +1,62 @@
+package inspect
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestExchangeNewRequest(t *testing.T) {
+	c := NewCapture(Options{MaxBody: 4})
+	body := c.NewRequestBody()
+	body.Write([]byte(`{"id":1}`))
+	e := Exchange{Method: "POST", URL: "/hook?x=1", Host: "app.test", RequestHeaders: http.Header{"Authorization": {"Bearer secret"}}}
+	e.KeepRequest(e.RequestHeaders, body)
+	e = c.Add(e)
+
+	if got := e.RequestHeaders.Get("Authorization"); got != Redacted {
+		t.Errorf("expected the stored headers to be redacted, got %q", got)
+	}
+	r, err := e.NewRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r.Body)
+	if r.Method != "POST" || r.Host != "app.test" || r.RequestURI != "/hook?x=1" || string(data) != `{"id":1}` {
+		t.Errorf("unexpected request %s %s%s %q", r.Method, r.Host, r.RequestURI, data)
+	}
+	if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("expected the unredacted header, got %q", got)
+	}
+
+	big := c.NewRequestBody()
+	big.Write(make([]byte, MaxReplayBody+1))
+	e.KeepRequest(nil, big)
+	if _, err := e.NewRequest(); err == nil {
+		t.Error("expected an error for a body over MaxReplayBody")
+	}
+	if _, err := (Exchange{ID: 1}).NewRequest(); err == nil {
+		t.Error("expected an error for an exchange without its request")
+	}
+}
+
+func TestEditApply(t *testing.T) {
+	r, _ := http.NewRequest("POST", "http://app.test/hook", nil)
+	r.Header.Set("Cookie", "a=1")
+	body := "{}"
+	edit := Edit{Method: "put", URL: "/other?y=2", Headers: map[string]string{"X-Debug": "1", "Cookie": ""}, Body: &body}
+	if err := edit.Apply(r); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r.Body)
+	if r.Method != "PUT" || r.URL.Path != "/other" || r.URL.RawQuery != "y=2" || string(data) != "{}" || r.ContentLength != 2 {
+		t.Errorf("unexpected request %s %s %q", r.Method, r.URL, data)
+	}
+	if r.Header.Get("X-Debug") != "1" || r.Header.Get("Cookie") != "" {
+		t.Errorf("expected X-Debug set and Cookie removed, got %v", r.Header)
+	}
+
+	if err := (Edit{URL: "http://other.test/"}).Apply(r); err == nil {
+		t.Error("expected an error for a URL with a host")
+	}
+}
@@ -0,0 +1,227 @@
+//go:build linux
+
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// cpuPeriod is the cpu.max period in microseconds; the quota is a share of it
+const cpuPeriod = 100000
+
+// Cgroup is a cgroup v2 sub-tree enforcing the memory and CPU limits of one
+// process group
+type Cgroup struct {
+	path string
+	dir  *os.File // Handed to clone3 so the process starts inside the cgroup
+}
+
+var (
+	setupOnce sync.Once
+	parentDir string // Cgroup the process cgroups are created in
+	setupErr  error
+	created   atomic.Int64
+)
+
+// ownCgroup returns the directory of fireup's own cgroup in the cgroup v2
+// hierarchy, which is mounted on its own or next to v1 ("unified")
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	var path string
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "0::"); ok {
+			path, found = rest, true
+		}
+	}
+	if !found {
+		return "", errors.New("cgroup v2 is not in use")
+	}
+	for _, mount := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		if _, err := os.Stat(filepath.Join(mount, "cgroup.controllers")); err == nil {
+			return filepath.Join(mount, path), nil
+		}
+	}
+	return "", errors.New("cgroup v2 is not mounted")
+}
+
+// setup lets fireup's cgroup hand the memory and cpu controllers to child
+// cgroups. Cgroups that do so can't hold processes themselves (except the
+// root), so fireup and the processes it started move into a leaf first.
+// This only happens in a cgroup that holds nothing but fireup's processes,
+// i.e. one delegated to fireup, e.g. with systemd-run --user --scope -p
+// Delegate=yes. Both steps are logged.
+func setup() (string, error) {
+	own, err := ownCgroup()
+	if err != nil {
+		return "", err
+	}
+	controllers, _ := os.ReadFile(filepath.Join(own, "cgroup.controllers"))
+	if !hasFields(string(controllers), "memory", "cpu") {
+		return "", errors.New("the cgroup v2 memory and cpu controllers aren't available to fireup")
+	}
+	procs, err := os.ReadFile(filepath.Join(own, "cgroup.procs"))
+	if err != nil {
+		return "", err
+	}
+	pids := strings.Fields(string(procs))
+	for _, field := range pids {
+		if pid, _ := strconv.Atoi(field); !descendsFrom(pid, os.Getpid()) {
+			return "", fmt.Errorf("fireup's cgroup %s also holds processes fireup didn't start (PID %d); run fireup in a cgroup of its own to use cgroups", own, pid)
+		}
+	}
+	enabled, _ := os.ReadFile(filepath.Join(own, "cgroup.subtree_control"))
+	if hasFields(string(enabled), "memory", "cpu") {
+		return own, nil
+	}
+
+	// Only a cgroup with a parent has to give up its processes
+	if _, err := os.Stat(filepath.Join(own, "..", "cgroup.controllers")); err == nil {
+		leaf := filepath.Join(own, "fireup")
+		if err := os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+			return "", fmt.Errorf("creating cgroup: %w", err)
+		}
+		for _, pid := range pids {
+			os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(pid), 0644)
+		}
+		fmt.Printf("[fireup] Moved %d processes of cgroup %s into %s\n", len(pids), own, leaf)
+	}
+	if err := os.WriteFile(filepath.Join(own, "cgroup.subtree_control"), []byte("+memory +cpu"), 0644); err != nil {
+		return "", fmt.Errorf("enabling cgroup controllers: %w", err)
+	}
+	fmt.Printf("[fireup] Enabled the memory and cpu controllers for the cgroups under %s\n", own)
+	return own, nil
+}
+
+// descendsFrom returns true if pid is ancestor or one of its descendants
+func descendsFrom(pid, ancestor int) bool {
+	for pid > 0 {
+		if pid == ancestor {
+			return true
+		}
+		if pid == 1 {
+			return false
+		}
+		pid = parentPID(pid)
+	}
+	return false
+}
+
+// parentPID returns the parent of a process, or 0 if it's gone
+func parentPID(pid int) int {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0
+	}
+	// Format: pid (comm) state ppid ...; comm may contain spaces and ")"
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	return ppid
+}
+
+// hasFields returns true if the whitespace-separated list contains all names
+func hasFields(list string, names ...string) bool {
+	fields := strings.Fields(list)
+	for _, name := range names {
+		found := false
+		for _, field := range fields {
+			found = found || field == name
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// NewCgroup creates a cgroup with the memory and CPU limits of l for the
+// process called name. Fails where cgroup v2 or its controllers aren't
+// available to fireup.
+func NewCgroup(name string, l Limits) (*Cgroup, error) {
+	setupOnce.Do(func() {
+		parentDir, setupErr = setup()
+	})
+	if setupErr != nil {
+		return nil, setupErr
+	}
+
+	path := filepath.Join(parentDir, fmt.Sprintf("%s.%d", name, created.Add(1)))
+	if err := os.Mkdir(path, 0755); err != nil {
+		return nil, fmt.Errorf("creating cgroup: %w", err)
+	}
+	c := &Cgroup{path: path}
+	settings := map[string]string{}
+	if l.Memory != 0 {
+		settings["memory.max"] = strconv.FormatInt(l.Memory, 10)
+	}
+	if l.CPU != 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d %d", l.CPU*cpuPeriod/100, cpuPeriod)
+	}
+	for file, value := range settings {
+		if err := os.WriteFile(filepath.Join(path, file), []byte(value), 0644); err != nil {
+			c.Remove()
+			return nil, fmt.Errorf("setting %s: %w", file, err)
+		}
+	}
+	if l.Memory != 0 {
+		// Hit the limit instead of swapping, if swap accounting is on
+		os.WriteFile(filepath.Join(path, "memory.swap.max"), []byte("0"), 0644)
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		c.Remove()
+		return nil, err
+	}
+	c.dir = dir
+	return c, nil
+}
+
+// Attach makes a command start inside the cgroup, so that nothing it forks
+// escapes the limits
+func (c *Cgroup) Attach(attr *syscall.SysProcAttr) {
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(c.dir.Fd())
+}
+
+// OOMKilled returns true if the kernel killed a process in the cgroup for
+// going over its memory limit
+func (c *Cgroup) OOMKilled() bool {
+	data, err := os.ReadFile(filepath.Join(c.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if count, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			n, _ := strconv.Atoi(count)
+			return n > 0
+		}
+	}
+	return false
+}
+
+// Remove deletes the cgroup. Only works once its processes are gone; a
+// process that outlives the one fireup started keeps it, which is logged.
+func (c *Cgroup) Remove() {
+	if c.dir != nil {
+		c.dir.Close()
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("[fireup] Can't remove cgroup %s: %v\n", c.path, err)
+	}
+}
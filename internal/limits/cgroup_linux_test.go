@@ -0,0 +1,27 @@
+//go:build linux
+
+package limits
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestDescendsFrom(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	if !descendsFrom(cmd.Process.Pid, os.Getpid()) || !descendsFrom(os.Getpid(), os.Getpid()) {
+		t.Error("expected fireup and its child to descend from fireup")
+	}
+	if descendsFrom(os.Getppid(), os.Getpid()) {
+		t.Error("expected the parent not to descend from fireup")
+	}
+	if descendsFrom(0, os.Getpid()) {
+		t.Error("expected a missing process not to descend from fireup")
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package limits
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Cgroup is a cgroup v2 sub-tree; cgroups only exist on Linux
+type Cgroup struct{}
+
+// NewCgroup always fails outside Linux
+func NewCgroup(name string, l Limits) (*Cgroup, error) {
+	return nil, errors.New("cgroups require Linux")
+}
+
+// Attach does nothing outside Linux
+func (c *Cgroup) Attach(attr *syscall.SysProcAttr) {}
+
+// OOMKilled is always false outside Linux
+func (c *Cgroup) OOMKilled() bool { return false }
+
+// Remove does nothing outside Linux
+func (c *Cgroup) Remove() {}
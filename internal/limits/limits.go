@@ -0,0 +1,130 @@
+// Package limits caps the memory, CPU and open files of the processes
+// fireup starts.
+package limits
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits are the resource caps of a process. Zero means no limit.
+type Limits struct {
+	Memory  int64         // Bytes
+	CPU     int           // Percent of one core, e.g. 150 for one and a half cores
+	CPUTime time.Duration // Processor time of each process (ulimit -t)
+	NoFile  uint64        // Open files
+}
+
+// IsZero returns true if no limit is set
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// Or returns l with the limits it doesn't set taken from defaults
+func (l Limits) Or(defaults Limits) Limits {
+	if l.Memory == 0 {
+		l.Memory = defaults.Memory
+	}
+	if l.CPU == 0 {
+		l.CPU = defaults.CPU
+	}
+	if l.CPUTime == 0 {
+		l.CPUTime = defaults.CPUTime
+	}
+	if l.NoFile == 0 {
+		l.NoFile = defaults.NoFile
+	}
+	return l
+}
+
+// String describes the limits that are set, e.g. "memory 2G, cpu 150%"
+func (l Limits) String() string {
+	var parts []string
+	if l.Memory != 0 {
+		parts = append(parts, "memory "+FormatMemory(l.Memory))
+	}
+	if l.CPU != 0 {
+		parts = append(parts, fmt.Sprintf("cpu %d%%", l.CPU))
+	}
+	if l.CPUTime != 0 {
+		parts = append(parts, "cpu time "+FormatCPUTime(l.CPUTime))
+	}
+	if l.NoFile != 0 {
+		parts = append(parts, fmt.Sprintf("nofile %d", l.NoFile))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var memoryUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// ParseMemory parses a memory size such as "2G", "512M", "1.5GB" or a
+// number of bytes. Units are powers of 1024.
+func ParseMemory(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(value, "B")
+	unit := ""
+	if n := len(value); n > 0 && memoryUnits[value[n-1:]] > 1 {
+		unit = value[n-1:]
+		value = strings.TrimSpace(value[:n-1])
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	bytes := int64(n * float64(memoryUnits[unit]))
+	if err != nil || bytes <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q (e.g. 512M or 2G)", s)
+	}
+	return bytes, nil
+}
+
+// FormatMemory formats bytes the way ParseMemory reads them, e.g. "2G"
+func FormatMemory(bytes int64) string {
+	for _, unit := range []string{"T", "G", "M", "K"} {
+		size := memoryUnits[unit]
+		switch {
+		case bytes >= size && bytes%size == 0:
+			return fmt.Sprintf("%d%s", bytes/size, unit)
+		case bytes >= size:
+			return fmt.Sprintf("%.1f%s", float64(bytes)/float64(size), unit)
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// ParseCPU parses a CPU limit in percent of one core, e.g. "150%" or "150"
+func ParseCPU(s string) (int, error) {
+	percent, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")))
+	if err != nil || percent <= 0 {
+		return 0, fmt.Errorf("invalid cpu limit %q (percent of one core, e.g. 150%%)", s)
+	}
+	return percent, nil
+}
+
+// ParseCPUTime parses a processor time limit such as "10m" or "90s". The
+// limit is set in whole seconds.
+func ParseCPUTime(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid cpu time %q (e.g. 90s or 10m)", s)
+	}
+	return d.Truncate(time.Second), nil
+}
+
+// FormatCPUTime formats a processor time limit without trailing zero
+// units, e.g. "10m" rather than "10m0s"
+func FormatCPUTime(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
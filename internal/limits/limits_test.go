@@ -0,0 +1,94 @@
+package limits
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMemory(t *testing.T) {
+	valid := map[string]int64{
+		"2G":    2 << 30,
+		"512M":  512 << 20,
+		"512mb": 512 << 20,
+		"1.5G":  3 << 29,
+		"64K":   64 << 10,
+		"1024":  1024,
+	}
+	for s, want := range valid {
+		if got, err := ParseMemory(s); err != nil || got != want {
+			t.Errorf("%q: expected %d, got %d (err %v)", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"", "lots", "G", "-1G", "0", "2X"} {
+		if _, err := ParseMemory(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestFormatMemory(t *testing.T) {
+	cases := map[int64]string{
+		2 << 30:   "2G",
+		3 << 29:   "1.5G",
+		512 << 20: "512M",
+		64 << 10:  "64K",
+		100:       "100",
+	}
+	for bytes, want := range cases {
+		if got := FormatMemory(bytes); got != want {
+			t.Errorf("%d: expected %q, got %q", bytes, want, got)
+		}
+	}
+}
+
+func TestParseCPU(t *testing.T) {
+	for s, want := range map[string]int{"150%": 150, "50": 50, " 200 % ": 200} {
+		if got, err := ParseCPU(s); err != nil || got != want {
+			t.Errorf("%q: expected %d, got %d (err %v)", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"", "fast", "0%", "1.5"} {
+		if _, err := ParseCPU(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestParseCPUTime(t *testing.T) {
+	valid := map[string]time.Duration{
+		"90s":   90 * time.Second,
+		"10m":   10 * time.Minute,
+		"1.5s":  time.Second,
+		" 1h ":  time.Hour,
+		"1m30s": 90 * time.Second,
+	}
+	for s, want := range valid {
+		if got, err := ParseCPUTime(s); err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v (err %v)", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"", "10", "500ms", "-1m"} {
+		if _, err := ParseCPUTime(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+	for d, want := range map[time.Duration]string{10 * time.Minute: "10m", time.Hour: "1h", 90 * time.Second: "1m30s", 30 * time.Second: "30s"} {
+		if got := FormatCPUTime(d); got != want {
+			t.Errorf("%v: expected %q, got %q", d, want, got)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	root := Limits{Memory: 2 << 30, CPUTime: 10 * time.Minute, NoFile: 4096}
+	l := Limits{CPU: 150, NoFile: 1024}.Or(root)
+	if want := (Limits{Memory: 2 << 30, CPU: 150, CPUTime: 10 * time.Minute, NoFile: 1024}); l != want {
+		t.Errorf("expected %+v, got %+v", want, l)
+	}
+	if got := l.String(); got != "memory 2G, cpu 150%, cpu time 10m, nofile 1024" {
+		t.Errorf("unexpected description %q", got)
+	}
+	if !(Limits{}).IsZero() || l.IsZero() {
+		t.Error("expected only empty limits to be zero")
+	}
+}
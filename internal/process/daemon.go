@@ -0,0 +1,164 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// pidfileWait is how long a command that daemonizes may take to write its
+// pidfile after it exits
+const pidfileWait = 5 * time.Second
+
+// daemonStopWait is how long a daemon gets to shut down after SIGTERM before
+// it's killed. Longer than for process groups since databases flush on exit.
+const daemonStopWait = 2 * time.Second
+
+// logTailInterval is how often a logfile is checked for new output
+const logTailInterval = 250 * time.Millisecond
+
+// readPIDFile returns the PID on the first line of a pidfile. Some pidfiles
+// (e.g. Postgres's postmaster.pid) have more lines after it.
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pidfile %s", path)
+	}
+	return pid, nil
+}
+
+// pidAlive returns true if a process with the PID exists and isn't a zombie
+func pidAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return false
+	}
+	if runtime.GOOS == "linux" {
+		// Format: pid (comm) state ... where comm may contain spaces
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err == nil {
+			s := string(data)
+			fields := strings.Fields(s[strings.LastIndex(s, ")")+1:])
+			return len(fields) == 0 || fields[0] != "Z"
+		}
+	}
+	return true
+}
+
+// removeStalePIDFile removes a pidfile left behind by a daemon that is gone,
+// so that an old PID isn't mistaken for the new daemon's
+func removeStalePIDFile(path string) {
+	pid, err := readPIDFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil || !pidAlive(pid) {
+		os.Remove(path)
+		fmt.Printf("[fireup] Removed stale pidfile: %s\n", path)
+	}
+}
+
+// superviseDaemon waits for the pidfile of a command that daemonized and
+// then for the daemon to exit. Returns nil if the daemon was stopped by Kill.
+func (p *Process) superviseDaemon() error {
+	var pid int
+	var err error
+	deadline := time.Now().Add(pidfileWait)
+	for {
+		pid, err = readPIDFile(p.pidFile)
+		if err == nil && pidAlive(pid) {
+			break
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("command exited without writing pidfile %s", p.pidFile)
+			}
+			return fmt.Errorf("pid %d from %s is not running", pid, p.pidFile)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	p.mu.Lock()
+	p.daemonPID = pid
+	killed := p.killed
+	p.mu.Unlock()
+	if killed {
+		// Stopped while the pidfile was being written
+		killDaemon(pid, p.Name)
+		return nil
+	}
+	p.logs.Write([]byte(fmt.Sprintf("[fireup] Daemonized, supervising pid %d\n", pid)))
+
+	for pidAlive(pid) {
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.killed {
+		return nil
+	}
+	return fmt.Errorf("daemon (pid %d) exited", pid)
+}
+
+// killDaemon stops a daemon, and its process group if it leads one
+func killDaemon(pid int, name string) {
+	target := pid
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		target = -pid // e.g. unicorn's master and workers
+	}
+	fmt.Printf("[fireup] Kill %s: sending SIGTERM to daemon %d\n", name, target)
+	syscall.Kill(target, syscall.SIGTERM)
+
+	deadline := time.Now().Add(daemonStopWait)
+	for pidAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if pidAlive(pid) {
+		fmt.Printf("[fireup] Kill %s: sending SIGKILL to daemon %d\n", name, target)
+		syscall.Kill(target, syscall.SIGKILL)
+	}
+}
+
+// logFileSize returns the size of a logfile, or 0 if it doesn't exist yet
+func logFileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// tailLogFile copies what is written to path after offset into w until ctx
+// is done or done returns true. Starts over if the file is truncated.
+func tailLogFile(ctx context.Context, path string, offset int64, w io.WriteCloser, done func() bool) {
+	defer w.Close()
+	for {
+		// Decide before reading so output written just before exit is kept
+		stop := ctx.Err() != nil || done()
+		if f, err := os.Open(path); err == nil {
+			if info, err := f.Stat(); err == nil && info.Size() < offset {
+				offset = 0 // Truncated or rotated
+			}
+			if _, err := f.Seek(offset, io.SeekStart); err == nil {
+				n, _ := io.Copy(w, f)
+				offset += n
+			}
+			f.Close()
+		}
+		if stop {
+			return
+		}
+		time.Sleep(logTailInterval)
+	}
+}
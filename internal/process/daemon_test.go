@@ -0,0 +1,113 @@
+package process
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadPIDFile(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]int{
+		"4242\n":                        4242,
+		" 4242 ":                        4242,
+		"4242\n/var/lib/postgres\n5432": 4242, // postmaster.pid
+		"":                              0,
+		"not a pid\n":                   0,
+	}
+	for content, want := range cases {
+		path := filepath.Join(dir, "test.pid")
+		os.WriteFile(path, []byte(content), 0644)
+		pid, err := readPIDFile(path)
+		if pid != want || (want == 0) != (err != nil) {
+			t.Errorf("%q: expected %d, got %d (err %v)", content, want, pid, err)
+		}
+	}
+	if _, err := readPIDFile(filepath.Join(dir, "missing.pid")); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error for a missing pidfile, got %v", err)
+	}
+}
+
+func TestStartAsyncWithPIDFile(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "server.pid")
+	logFile := filepath.Join(dir, "server.log")
+	// Forks a server into the background and exits 0, like unicorn -D
+	command := "python3 -m http.server $PORT --bind 127.0.0.1 >> server.log 2>&1 & echo $! > server.pid"
+
+	m := NewManager()
+	defer m.StopAll()
+
+	start := func(t *testing.T) *Process {
+		t.Helper()
+		proc, err := m.StartAsyncWith("daemon", command, dir, nil, StartOptions{PIDFile: pidFile, LogFile: logFile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(readyTimeout)
+		for proc.IsStarting() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !proc.IsRunning() {
+			t.Fatalf("expected the daemon to be running: %s", proc.ExitError())
+		}
+		return proc
+	}
+	logsContain := func(proc *Process, text string) bool {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if strings.Contains(strings.Join(proc.Logs().Lines(), "\n"), text) {
+				return true
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		return false
+	}
+
+	t.Run("supervises the daemon and tails its logfile", func(t *testing.T) {
+		proc := start(t)
+		pid, err := readPIDFile(pidFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !logsContain(proc, fmt.Sprintf("supervising pid %d", pid)) {
+			t.Errorf("expected the logs to mention pid %d, got %v", pid, proc.Logs().Lines())
+		}
+
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/from-test", proc.Port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if !logsContain(proc, "GET /from-test") {
+			t.Errorf("expected the logfile's request line in the logs, got %v", proc.Logs().Lines())
+		}
+
+		m.Stop("daemon")
+		if pidAlive(pid) {
+			t.Errorf("expected the daemon (pid %d) to be stopped", pid)
+		}
+	})
+
+	t.Run("reports the daemon exiting", func(t *testing.T) {
+		proc := start(t)
+		pid, _ := readPIDFile(pidFile)
+		killDaemon(pid, "test")
+
+		deadline := time.Now().Add(3 * time.Second)
+		for !proc.HasFailed() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if proc.IsRunning() || !strings.Contains(proc.ExitError(), "daemon") {
+			t.Errorf("expected the daemon's exit to be reported, got running=%v error %q", proc.IsRunning(), proc.ExitError())
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/limits"
+)
+
+// memoryCheckInterval is how often the memory of a process is checked when
+// its memory limit can't be left to a cgroup
+const memoryCheckInterval = time.Second
+
+// ulimitPrefix returns shell commands that set the rlimits of l for the
+// command that follows them. A limit the shell can't set is reported in the
+// logs, and the command runs anyway. Memory isn't capped with ulimit -v:
+// address space is often far above the memory in use.
+func ulimitPrefix(l limits.Limits) string {
+	var prefix string
+	if l.CPUTime != 0 {
+		// The soft limit only: going over it sends SIGXCPU, which tells it
+		// apart from other kills, where the hard limit sends SIGKILL
+		prefix += fmt.Sprintf("ulimit -S -t %d; ", int64(l.CPUTime/time.Second))
+	}
+	if l.NoFile != 0 {
+		prefix += fmt.Sprintf("ulimit -n %d; ", l.NoFile)
+	}
+	return prefix
+}
+
+// watchMemory kills the process group once its resident memory goes over
+// limit. Stands in for cgroups where they aren't available.
+func (p *Process) watchMemory(ctx context.Context, limit int64) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(memoryCheckInterval):
+		}
+		if p.hasExited() {
+			return
+		}
+		pgid := p.groupID()
+		used := groupRSS(pgid)
+		if used <= limit {
+			continue
+		}
+
+		p.mu.Lock()
+		p.limitError = fmt.Sprintf("exceeded memory limit of %s", limits.FormatMemory(limit))
+		p.mu.Unlock()
+		p.logs.Write([]byte(fmt.Sprintf("[fireup] Using %s, over the memory limit of %s; killing it\n",
+			limits.FormatMemory(used), limits.FormatMemory(limit))))
+		syscall.Kill(-pgid, syscall.SIGCONT) // In case it's paused
+		syscall.Kill(-pgid, syscall.SIGKILL)
+		return
+	}
+}
+
+// groupRSS returns the resident memory of a process group in bytes. Uses
+// /proc on Linux and ps elsewhere.
+func groupRSS(pgid int) int64 {
+	if runtime.GOOS == "linux" {
+		var total int64
+		for _, pid := range groupPIDs(pgid) {
+			// Format: size resident shared ... in pages
+			data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "statm"))
+			if err != nil {
+				continue
+			}
+			if fields := strings.Fields(string(data)); len(fields) > 1 {
+				pages, _ := strconv.ParseInt(fields[1], 10, 64)
+				total += pages * int64(os.Getpagesize())
+			}
+		}
+		return total
+	}
+	output, err := exec.Command("ps", "-A", "-o", "pgid=,rss=").Output()
+	if err != nil {
+		return 0
+	}
+	return parsePsRSS(string(output), pgid)
+}
+
+// parsePsRSS sums the rss column (in KB) of "ps -o pgid=,rss=" output for
+// the processes in a process group
+func parsePsRSS(output string, pgid int) int64 {
+	var total int64
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != strconv.Itoa(pgid) {
+			continue
+		}
+		kb, _ := strconv.ParseInt(fields[1], 10, 64)
+		total += kb * 1024
+	}
+	return total
+}
+
+// limitExceededLocked returns the limit the process broke, or "" if it
+// didn't break one. err is what it exited with. Only limits that the memory
+// poller, the cgroup or a signal confirm are reported: going over nofile
+// just makes opening files fail, which the process handles itself. Called
+// with mu held once the process has exited.
+func (p *Process) limitExceededLocked(err error) string {
+	switch {
+	case p.limitError != "":
+		return p.limitError
+	case p.cgroup != nil && p.cgroup.OOMKilled():
+		return fmt.Sprintf("exceeded memory limit of %s", limits.FormatMemory(p.limits.Memory))
+	case p.limits.CPUTime != 0 && killedBy(err, syscall.SIGXCPU):
+		return fmt.Sprintf("exceeded cpu time limit of %s", limits.FormatCPUTime(p.limits.CPUTime))
+	}
+	return ""
+}
+
+// killedBy returns true if err says the process, or the command the shell
+// ran for it, was killed by sig
+func killedBy(err error, sig syscall.Signal) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return false
+	}
+	// Shells exit with 128 plus the signal that killed their command
+	return (status.Signaled() && status.Signal() == sig) || status.ExitStatus() == 128+int(sig)
+}
@@ -0,0 +1,100 @@
+package process
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/limits"
+)
+
+func TestParsePsRSS(t *testing.T) {
+	output := "  100   2048\n  200  51200\n  200  1024\n  300 8\n"
+	if got, want := parsePsRSS(output, 200), int64(52224*1024); got != want {
+		t.Errorf("expected %d bytes for the group, got %d", want, got)
+	}
+	if got := parsePsRSS(output, 400); got != 0 {
+		t.Errorf("expected 0 for an unknown group, got %d", got)
+	}
+}
+
+func TestUlimitPrefix(t *testing.T) {
+	l := limits.Limits{Memory: 2 << 30, CPU: 150, CPUTime: 90 * time.Second, NoFile: 4096}
+	if got, want := ulimitPrefix(l), "ulimit -S -t 90; ulimit -n 4096; "; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := ulimitPrefix(limits.Limits{Memory: 2 << 30, CPU: 150}); got != "" {
+		t.Errorf("expected no ulimit for memory and cpu limits, got %q", got)
+	}
+}
+
+func TestStartAsyncWithLimits(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+
+	m := NewManager()
+	defer m.StopAll()
+
+	waitFailed := func(t *testing.T, proc *Process) string {
+		t.Helper()
+		deadline := time.Now().Add(readyTimeout)
+		for !proc.HasFailed() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !proc.HasFailed() {
+			t.Fatal("expected the process to fail")
+		}
+		return proc.ExitError()
+	}
+
+	t.Run("reports going over the memory limit", func(t *testing.T) {
+		command := `python3 -c "import time; b = bytearray(300 * 1024 * 1024); time.sleep(60)"`
+		proc, err := m.StartAsyncWith("hungry", command, "/tmp", nil, StartOptions{Limits: limits.Limits{Memory: 100 << 20}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := waitFailed(t, proc); got != "exceeded memory limit of 100M" {
+			t.Errorf("expected the memory limit as the reason, got %q", got)
+		}
+	})
+
+	t.Run("reports going over the cpu time limit", func(t *testing.T) {
+		command := `python3 -c "while True: pass"`
+		proc, err := m.StartAsyncWith("busy", command, "/tmp", nil, StartOptions{Limits: limits.Limits{CPUTime: time.Second}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := waitFailed(t, proc); got != "exceeded cpu time limit of 1s" {
+			t.Errorf("expected the cpu time limit as the reason, got %q", got)
+		}
+	})
+
+	t.Run("applies the open files limit", func(t *testing.T) {
+		command := `python3 -c "files = [open('/dev/null') for _ in range(200)]"`
+		proc, err := m.StartAsyncWith("leaky", command, "/tmp", nil, StartOptions{Limits: limits.Limits{NoFile: 64}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Failing to open files is up to the process, so its exit code stays
+		if got := waitFailed(t, proc); got != "exit code 1" {
+			t.Errorf("expected the exit code as the reason, got %q", got)
+		}
+		logs := strings.Join(proc.Logs().Lines(), "\n")
+		if !strings.Contains(logs, "Limits: nofile 64") || !strings.Contains(logs, "Too many open files") {
+			t.Errorf("expected the limits and the error in the logs, got %v", proc.Logs().Lines())
+		}
+	})
+
+	t.Run("logs that look like a limit don't count", func(t *testing.T) {
+		command := `python3 -c "import sys; print('MemoryError: out of memory'); sys.exit(3)"`
+		proc, err := m.StartAsyncWith("chatty", command, "/tmp", nil, StartOptions{Limits: limits.Limits{Memory: 1 << 30}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := waitFailed(t, proc); got != "exit code 3" {
+			t.Errorf("expected the exit code as the reason, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package process
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// listeningPorts returns the TCP ports that processes in the process group
+// pgid listen on, sorted. Uses /proc on Linux and lsof elsewhere.
+func listeningPorts(pgid int) []int {
+	if runtime.GOOS == "linux" {
+		return procListeningPorts(pgid)
+	}
+	output, err := exec.Command("lsof", "-nP", "-a", "-g", strconv.Itoa(pgid), "-iTCP", "-sTCP:LISTEN", "-Fn").Output()
+	if err != nil {
+		return nil
+	}
+	return parseLsofPorts(string(output))
+}
+
+// procListeningPorts finds listening sockets of the process group in
+// /proc/net/tcp{,6} by matching socket inodes against the group's open files
+func procListeningPorts(pgid int) []int {
+	inodes := make(map[string]bool)
+	for _, pid := range groupPIDs(pgid) {
+		fds, _ := filepath.Glob(filepath.Join("/proc", strconv.Itoa(pid), "fd", "*"))
+		for _, fd := range fds {
+			link, err := os.Readlink(fd)
+			if err == nil && strings.HasPrefix(link, "socket:[") {
+				inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] = true
+			}
+		}
+	}
+	if len(inodes) == 0 {
+		return nil
+	}
+
+	var ports []int
+	for _, file := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		ports = append(ports, parseProcNetTCP(string(data), inodes)...)
+	}
+	return uniquePorts(ports)
+}
+
+// groupPIDs returns the PIDs of the processes in a process group
+func groupPIDs(pgid int) []int {
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	var pids []int
+	for _, stat := range stats {
+		data, err := os.ReadFile(stat)
+		if err != nil {
+			continue
+		}
+		// Format: pid (comm) state ppid pgrp ... where comm may contain spaces
+		s := string(data)
+		fields := strings.Fields(s[strings.LastIndex(s, ")")+1:])
+		if len(fields) < 3 || fields[2] != strconv.Itoa(pgid) {
+			continue
+		}
+		if pid, err := strconv.Atoi(filepath.Base(filepath.Dir(stat))); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// parseProcNetTCP returns the ports of listening sockets in /proc/net/tcp
+// format whose inode is in inodes
+func parseProcNetTCP(data string, inodes map[string]bool) []int {
+	const stateListen = "0A"
+	var ports []int
+	for _, line := range strings.Split(data, "\n")[1:] { // Skip header
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[3] != stateListen || !inodes[fields[9]] {
+			continue
+		}
+		local := fields[1]
+		port, err := strconv.ParseInt(local[strings.LastIndex(local, ":")+1:], 16, 32)
+		if err == nil {
+			ports = append(ports, int(port))
+		}
+	}
+	return ports
+}
+
+// parseLsofPorts returns the ports in lsof -Fn output, e.g. "n*:5173" or
+// "n[::1]:5173"
+func parseLsofPorts(output string) []int {
+	var ports []int
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "n") {
+			continue
+		}
+		port, err := strconv.Atoi(line[strings.LastIndex(line, ":")+1:])
+		if err == nil {
+			ports = append(ports, port)
+		}
+	}
+	return uniquePorts(ports)
+}
+
+// uniquePorts sorts ports and removes duplicates (IPv4 and IPv6 sockets)
+func uniquePorts(ports []int) []int {
+	sort.Ints(ports)
+	var result []int
+	for i, port := range ports {
+		if i == 0 || port != ports[i-1] {
+			result = append(result, port)
+		}
+	}
+	return result
+}
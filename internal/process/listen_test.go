@@ -0,0 +1,100 @@
+package process
+
+import (
+	"os/exec"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParseProcNetTCP(t *testing.T) {
+	data := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1435 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 51234 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 51235 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 51236 1 0000000000000000 20 4 30 10 -1
+   3: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 999 1 0000000000000000 100 0 0 10 0
+`
+	inodes := map[string]bool{"51234": true, "51235": true, "51236": true}
+	got := parseProcNetTCP(data, inodes)
+	if want := []int{5173, 3000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v (listening sockets of the group only), got %v", want, got)
+	}
+}
+
+func TestParseLsofPorts(t *testing.T) {
+	output := "p1234\nf20\nn*:5173\nf21\nn[::1]:5173\np1240\nf8\nn127.0.0.1:24678\n"
+	got := parseLsofPorts(output)
+	if want := []int{5173, 24678}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestStartAutoPort(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+
+	waitReady := func(t *testing.T, proc *Process) {
+		t.Helper()
+		deadline := time.Now().Add(readyTimeout)
+		for proc.IsStarting() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the process to listen")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !proc.IsRunning() {
+			t.Fatalf("expected process to be running: %s", proc.ExitError())
+		}
+	}
+
+	// Port 0 makes the server pick its own port, ignoring $PORT
+	command := "python3 -u -m http.server 0 --bind 127.0.0.1"
+
+	t.Run("detects the listening port", func(t *testing.T) {
+		m := NewManager()
+		defer m.StopAll()
+		proc, err := m.StartAsyncWith("picky", command, "/tmp", nil, StartOptions{PortAuto: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitReady(t, proc)
+		if !proc.PortDetected() || proc.ListenPort() == proc.Port {
+			t.Errorf("expected a detected port other than %d, got %d", proc.Port, proc.ListenPort())
+		}
+		if !portInUse(proc.ListenPort()) {
+			t.Errorf("expected port %d to accept connections", proc.ListenPort())
+		}
+	})
+
+	t.Run("uses the port from the logs", func(t *testing.T) {
+		m := NewManager()
+		defer m.StopAll()
+		pattern := regexp.MustCompile(`port (\d+)`)
+		proc, err := m.StartAsyncWith("picky", command, "/tmp", nil, StartOptions{PortAuto: true, PortPattern: pattern})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitReady(t, proc)
+		proc.mu.Lock()
+		logged := proc.loggedPort
+		proc.mu.Unlock()
+		if logged == 0 || proc.ListenPort() != logged {
+			t.Errorf("expected the logged port %d, got %d", logged, proc.ListenPort())
+		}
+	})
+
+	t.Run("keeps the assigned port when it's used", func(t *testing.T) {
+		m := NewManager()
+		defer m.StopAll()
+		proc, err := m.StartAsyncWith("polite", "python3 -m http.server $PORT --bind 127.0.0.1", "/tmp", nil, StartOptions{PortAuto: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitReady(t, proc)
+		if proc.PortDetected() || proc.ListenPort() != proc.Port {
+			t.Errorf("expected assigned port %d, got %d", proc.Port, proc.ListenPort())
+		}
+	})
+}
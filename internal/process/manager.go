@@ -10,12 +10,16 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/panozzaj/fireup/internal/backend"
+	"github.com/panozzaj/fireup/internal/limits"
 )
 
 // getUserShell returns the current user's default shell.
@@ -57,9 +61,37 @@ type Process struct {
 	logs      *LogBuffer
 	started   time.Time
 	starting  bool // true while waiting for port to be ready
+	exited    bool // true once the process (or the daemon it started) is gone
 	failed    bool
+	killed    bool // true once Kill was called
 	exitError string
 	mu        sync.Mutex
+
+	host string // backend host setting the process was started with
+
+	// port: auto - the process may listen somewhere other than Port
+	autoPort     bool
+	portPattern  *regexp.Regexp // Log line announcing the port, with the port as first group
+	loggedPort   int            // Port announced in the logs
+	detectedPort int            // Port the process turned out to listen on
+
+	// Commands that daemonize: the PID in pidFile is supervised once the
+	// command exits, and logFile is tailed into the logs
+	pidFile   string
+	logFile   string
+	daemonPID int
+
+	// Pausing: the process group is stopped with SIGSTOP while idle
+	paused     bool
+	pauseAfter time.Duration // Pause after this long without requests (0 to never)
+	idleTimer  *time.Timer
+	inflight   int // Requests being proxied to the process
+
+	limits     limits.Limits
+	cgroup     *limits.Cgroup // Enforces the memory and CPU limits, if available
+	limitError string         // Limit the process was killed for breaking
+
+	restartError string // Why the last Overlap couldn't replace the process
 }
 
 // LogBuffer stores recent log output
@@ -119,10 +151,15 @@ func (b *LogBuffer) Clear() {
 type Manager struct {
 	mu            sync.RWMutex
 	processes     map[string]*Process
-	reservedPorts map[int]bool // ports allocated but not yet bound
+	pending       map[string]*Process // New instances started by Overlap, until they take over
+	reservedPorts map[int]bool        // ports allocated but not yet bound
 	portStart     int
 	portEnd       int
 	nextPort      int
+
+	// Reference counts of shared processes (see Acquire)
+	refMu sync.Mutex
+	refs  map[string]*sharedRef
 }
 
 // NewManager creates a new process manager
@@ -137,6 +174,7 @@ func NewManager() *Manager {
 		portStart:     portStart,
 		portEnd:       portEnd,
 		nextPort:      nextPort,
+		refs:          make(map[string]*sharedRef),
 	}
 }
 
@@ -156,9 +194,9 @@ func (m *Manager) findFreePort() (int, error) {
 			continue
 		}
 
-		// First check if anything is already LISTENING on this port
-		// This catches processes bound to 0.0.0.0 that wouldn't block our 127.0.0.1 bind
-		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 50*time.Millisecond)
+		// First check if anything is already LISTENING on this port, on IPv4 or IPv6
+		// This catches processes bound to 0.0.0.0 or ::1 that wouldn't block our 127.0.0.1 bind
+		conn, err := backend.DialTimeout(backend.Addr(backend.Auto, port), 50*time.Millisecond)
 		if err == nil {
 			conn.Close()
 			fmt.Printf("[fireup] Port %d has something listening, skipping\n", port)
@@ -193,12 +231,49 @@ func (m *Manager) releasePort(port int) {
 	delete(m.reservedPorts, port)
 }
 
-// Start starts a process
-func (m *Manager) Start(name, command, dir string, env map[string]string) (*Process, error) {
+// StartAsync starts a process without waiting for the port to be ready.
+// Returns immediately after the process is spawned.
+func (m *Manager) StartAsync(name, command, dir string, env map[string]string) (*Process, error) {
+	return m.StartAsyncWith(name, command, dir, env, StartOptions{})
+}
+
+// StartOptions say how a process gets its port and where fireup reaches it
+type StartOptions struct {
+	Port        int            // Fixed port the command always listens on (0 to assign one)
+	PortAuto    bool           // Detect the port the process actually listens on
+	PortPattern *regexp.Regexp // With PortAuto: log line announcing the port
+	Host        string         // Loopback address setting, see package backend (empty for auto)
+	PIDFile     string         // For commands that daemonize: supervise the PID written here
+	LogFile     string         // Tail this file into the logs
+	PauseAfter  time.Duration  // Pause the process after this long without requests
+	Limits      limits.Limits  // Memory, CPU and open files limits
+}
+
+// StartAsyncWith is StartAsync with options for the port and host. With a
+// fixed port, it fails if something else already listens there. With
+// PortAuto, for commands that may ignore $PORT, the process is ready once
+// it listens on the assigned port, on the port a log line matching
+// PortPattern announces, or on any port of its process group.
+func (m *Manager) StartAsyncWith(name, command, dir string, env map[string]string, opts StartOptions) (*Process, error) {
+	fixedPort := opts.Port
+	// Checked before locking since waiting for the port can take a moment
+	if p, exists := m.Get(name); fixedPort != 0 && (!exists || (!p.IsRunning() && !p.IsStarting())) {
+		if err := m.checkFixedPort(name, fixedPort); err != nil {
+			return nil, err
+		}
+	}
+	return m.start(name, command, dir, env, opts, nil)
+}
+
+// start starts a process without waiting for it. With replace set, the
+// process is a new instance of replace that Overlap swaps in once it's
+// ready; until then it's kept in pending and shares replace's logs.
+func (m *Manager) start(name, command, dir string, env map[string]string, opts StartOptions, replace *Process) (*Process, error) {
+	fixedPort := opts.Port
 	m.mu.Lock()
 
-	// Check if already running
-	if p, exists := m.processes[name]; exists && p.IsRunning() {
+	// Check if already running or starting
+	if p, exists := m.processes[name]; replace == nil && exists && (p.IsRunning() || p.IsStarting()) {
 		m.mu.Unlock()
 		return p, nil
 	}
@@ -216,14 +291,25 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 		}
 	}
 
-	// Find a free port
-	port, err := m.findFreePort()
-	if err != nil {
-		m.mu.Unlock()
-		return nil, err
+	// Find a free port, or make sure the fixed one is free
+	port := fixedPort
+	if port == 0 {
+		var err error
+		if port, err = m.findFreePort(); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+	} else {
+		m.reservedPorts[port] = true
 	}
 	fmt.Printf("[fireup] Starting %s on port %d\n", name, port)
 
+	// Don't mistake a PID left over from an earlier run for the new daemon
+	if opts.PIDFile != "" {
+		removeStalePIDFile(opts.PIDFile)
+	}
+	logOffset := logFileSize(opts.LogFile)
+
 	// Create process
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -231,6 +317,7 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 	procEnv := os.Environ()
 	procEnv = append(procEnv, fmt.Sprintf("PORT=%d", port))
 	procEnv = append(procEnv, "FORCE_COLOR=1")
+	procEnv = append(procEnv, "HOST="+backend.EnvHost(opts.Host))
 	portStr := fmt.Sprintf("%d", port)
 	for k, v := range env {
 		// Expand $PORT in env values
@@ -242,14 +329,16 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 	// Use interactive login shell to ensure user's environment (rvm, rbenv, nvm, etc.) is loaded
 	// -l (login) sources .zprofile; -i (interactive) sources .zshrc/.bashrc
 	shell := getUserShell()
-	cmd := exec.CommandContext(ctx, shell, "-i", "-l", "-c", command)
+	cmd := exec.CommandContext(ctx, shell, "-i", "-l", "-c", ulimitPrefix(opts.Limits)+command)
 	cmd.Dir = dir
 	cmd.Env = procEnv
-	// Run in own process group so we can kill the entire tree
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	// Set up logging
 	logs := NewLogBuffer(1000)
+	if replace != nil {
+		logs = replace.logs
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -267,6 +356,25 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 		return nil, fmt.Errorf("stderr pipe: %w", err)
 	}
 
+	// Memory and CPU limits go to a cgroup where possible; otherwise memory
+	// is polled and CPU isn't limited
+	var cgroup *limits.Cgroup
+	var cgroupErr error
+	if opts.Limits.Memory != 0 || opts.Limits.CPU != 0 {
+		if cgroup, cgroupErr = limits.NewCgroup(name, opts.Limits); cgroupErr == nil {
+			cgroup.Attach(cmd.SysProcAttr)
+		}
+	}
+	if !opts.Limits.IsZero() {
+		logs.Write([]byte(fmt.Sprintf("[fireup] Limits: %s\n", opts.Limits)))
+		if cgroupErr != nil {
+			logs.Write([]byte(fmt.Sprintf("[fireup] No cgroup for the limits: %v\n", cgroupErr)))
+			if opts.Limits.CPU != 0 {
+				logs.Write([]byte("[fireup] The cpu limit needs a cgroup and is not enforced\n"))
+			}
+		}
+	}
+
 	proc := &Process{
 		Name:    name,
 		Command: command,
@@ -277,10 +385,22 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 		cancel:  cancel,
 		logs:    logs,
 		started: time.Now(),
+
+		host:        opts.Host,
+		autoPort:    opts.PortAuto,
+		portPattern: opts.PortPattern,
+		pidFile:     opts.PIDFile,
+		logFile:     opts.LogFile,
+		pauseAfter:  opts.PauseAfter,
+		limits:      opts.Limits,
+		cgroup:      cgroup,
 	}
 
 	// Start process
 	if err := cmd.Start(); err != nil {
+		if cgroup != nil {
+			cgroup.Remove()
+		}
 		cancel()
 		m.releasePort(port)
 		m.mu.Unlock()
@@ -288,49 +408,71 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 	}
 
 	// Stream logs
-	go streamLogs(stdout, logs, name)
-	go streamLogs(stderr, logs, name)
+	go streamLogs(stdout, logs, name, proc.scanPort)
+	go streamLogs(stderr, logs, name, proc.scanPort)
+	if opts.LogFile != "" {
+		r, w := io.Pipe()
+		go streamLogs(r, logs, name, proc.scanPort)
+		go tailLogFile(ctx, opts.LogFile, logOffset, w, proc.hasExited)
+	}
+	if opts.Limits.Memory != 0 && cgroup == nil {
+		go proc.watchMemory(ctx, opts.Limits.Memory)
+	}
 
 	// Monitor for exit
 	go func() {
 		err := cmd.Wait()
+		if err == nil && proc.pidFile != "" {
+			// The command daemonized; the process to watch is in the pidfile
+			err = proc.superviseDaemon()
+		}
 		// Write log BEFORE setting failed flag to avoid race condition
 		// where status shows "failed" but logs are empty
 		if err != nil {
 			proc.logs.Write([]byte("[fireup] Process exited\n"))
 		}
 		proc.mu.Lock()
+		proc.exited = true
 		if err != nil {
 			proc.failed = true
-			if exitErr, ok := err.(*exec.ExitError); ok {
+			if reason := proc.limitExceededLocked(err); reason != "" {
+				proc.exitError = reason
+			} else if exitErr, ok := err.(*exec.ExitError); ok {
 				proc.exitError = fmt.Sprintf("exit code %d", exitErr.ExitCode())
 			} else {
 				proc.exitError = err.Error()
 			}
 		}
 		proc.mu.Unlock()
-		// Don't delete failed processes so we can show their status
-		// They'll be replaced if started again
+		if cgroup != nil {
+			cgroup.Remove()
+		}
+
+		// Let go of shared processes, unless this process was already replaced
+		m.mu.RLock()
+		current := m.processes[name] == proc
+		m.mu.RUnlock()
+		if current {
+			m.Release(name)
+		}
 	}()
 
 	proc.starting = true
-	m.processes[name] = proc
-
-	// Release lock BEFORE waiting for port - this can take a while and would block all requests
+	if replace != nil {
+		if m.pending == nil {
+			m.pending = make(map[string]*Process)
+		}
+		m.pending[name] = proc
+	} else {
+		m.processes[name] = proc
+	}
 	m.mu.Unlock()
 
-	// Wait for port to be ready (keep checking until port ready or process exits)
+	// Wait for port in background (keep checking until port ready or process exits)
 	go func() {
-		// Release port reservation when done (process bound or exited)
-		defer func() {
-			m.mu.Lock()
-			m.releasePort(port)
-			m.mu.Unlock()
-		}()
-
 		for {
 			// Check if process has exited
-			if proc.cmd.ProcessState != nil {
+			if proc.hasExited() {
 				proc.mu.Lock()
 				proc.starting = false
 				proc.mu.Unlock()
@@ -338,11 +480,14 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 			}
 
 			// Check if port is ready
-			conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 100*time.Millisecond)
-			if err == nil {
-				conn.Close()
+			if ready := proc.readyPort(); ready != 0 {
 				proc.mu.Lock()
+				if ready != proc.Port {
+					fmt.Printf("[fireup] %s listens on port %d instead of %d\n", name, ready, proc.Port)
+					proc.detectedPort = ready
+				}
 				proc.starting = false
+				proc.scheduleIdlePauseLocked()
 				proc.mu.Unlock()
 				return
 			}
@@ -351,169 +496,110 @@ func (m *Manager) Start(name, command, dir string, env map[string]string) (*Proc
 		}
 	}()
 
-	// Wait up to 30s for initial startup, then return
-	// Process stays in "starting" state until port is actually ready
-	waitForPort(port, 30*time.Second)
-
 	return proc, nil
 }
 
-// StartAsync starts a process without waiting for the port to be ready.
-// Returns immediately after the process is spawned.
-func (m *Manager) StartAsync(name, command, dir string, env map[string]string) (*Process, error) {
-	m.mu.Lock()
-
-	// Check if already running or starting
-	if p, exists := m.processes[name]; exists && (p.IsRunning() || p.IsStarting()) {
-		m.mu.Unlock()
-		return p, nil
+// readyPort returns the port the process accepts connections on, or 0 if
+// it isn't listening yet. With port: auto, the port announced in the logs
+// and then the lowest port its process group listens on are tried too.
+func (p *Process) readyPort() int {
+	if p.listening(p.Port) {
+		return p.Port
 	}
-
-	// Clean up stale Rails PID file if this looks like a Rails server
-	if strings.Contains(command, "rails server") || strings.Contains(command, "rails s") {
-		cleanupRailsPID(dir)
+	if !p.autoPort {
+		return 0
 	}
-
-	// Check if working directory exists
-	if dir != "" {
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			m.mu.Unlock()
-			return nil, fmt.Errorf("working directory does not exist: %s", dir)
-		}
+	p.mu.Lock()
+	logged := p.loggedPort
+	p.mu.Unlock()
+	if logged != 0 && p.listening(logged) {
+		return logged
 	}
-
-	// Find a free port
-	port, err := m.findFreePort()
-	if err != nil {
-		m.mu.Unlock()
-		return nil, err
+	if ports := listeningPorts(p.groupID()); len(ports) > 0 {
+		return ports[0]
 	}
-	fmt.Printf("[fireup] Starting %s on port %d\n", name, port)
-
-	// Create process
-	ctx, cancel := context.WithCancel(context.Background())
+	return 0
+}
 
-	// Build environment
-	procEnv := os.Environ()
-	procEnv = append(procEnv, fmt.Sprintf("PORT=%d", port))
-	procEnv = append(procEnv, "FORCE_COLOR=1")
-	portStr := fmt.Sprintf("%d", port)
-	for k, v := range env {
-		// Expand $PORT in env values
-		v = strings.ReplaceAll(v, "$PORT", portStr)
-		procEnv = append(procEnv, fmt.Sprintf("%s=%s", k, v))
+// listening returns true if the process accepts connections on port at
+// its host address
+func (p *Process) listening(port int) bool {
+	conn, err := backend.DialTimeout(backend.Addr(p.host, port), 100*time.Millisecond)
+	if err != nil {
+		return false
 	}
+	conn.Close()
+	return true
+}
 
-	// Parse command (handle shell execution)
-	// Use interactive login shell to ensure user's environment (rvm, rbenv, nvm, etc.) is loaded
-	// -l (login) sources .zprofile; -i (interactive) sources .zshrc/.bashrc
-	shell := getUserShell()
-	cmd := exec.CommandContext(ctx, shell, "-i", "-l", "-c", command)
-	cmd.Dir = dir
-	cmd.Env = procEnv
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+// Addr returns the address to proxy to, e.g. "127.0.0.1:4001"
+func (p *Process) Addr() string {
+	return backend.Addr(p.host, p.ListenPort())
+}
 
-	// Set up logging
-	logs := NewLogBuffer(1000)
+// groupID returns the process group to look for listening sockets in: the
+// daemon's once the command daemonized, otherwise the command's own
+func (p *Process) groupID() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.groupIDLocked()
+}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		cancel()
-		m.releasePort(port)
-		m.mu.Unlock()
-		return nil, fmt.Errorf("stdout pipe: %w", err)
+// groupIDLocked is groupID with mu held
+func (p *Process) groupIDLocked() int {
+	if p.daemonPID != 0 {
+		if pgid, err := syscall.Getpgid(p.daemonPID); err == nil {
+			return pgid
+		}
 	}
+	return p.cmd.Process.Pid
+}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		cancel()
-		m.releasePort(port)
-		m.mu.Unlock()
-		return nil, fmt.Errorf("stderr pipe: %w", err)
+// scanPort remembers the port announced by a log line matching portPattern
+func (p *Process) scanPort(line string) {
+	if p.portPattern == nil {
+		return
 	}
-
-	proc := &Process{
-		Name:    name,
-		Command: command,
-		Dir:     dir,
-		Port:    port,
-		Env:     env,
-		cmd:     cmd,
-		cancel:  cancel,
-		logs:    logs,
-		started: time.Now(),
+	m := p.portPattern.FindStringSubmatch(line)
+	if len(m) < 2 {
+		return
 	}
-
-	// Start process
-	if err := cmd.Start(); err != nil {
-		cancel()
-		m.releasePort(port)
-		m.mu.Unlock()
-		return nil, fmt.Errorf("start process: %w", err)
+	if port, err := strconv.Atoi(m[1]); err == nil {
+		p.mu.Lock()
+		p.loggedPort = port
+		p.mu.Unlock()
 	}
+}
 
-	// Stream logs
-	go streamLogs(stdout, logs, name)
-	go streamLogs(stderr, logs, name)
-
-	// Monitor for exit
-	go func() {
-		err := cmd.Wait()
-		// Write log BEFORE setting failed flag to avoid race condition
-		// where status shows "failed" but logs are empty
-		if err != nil {
-			proc.logs.Write([]byte("[fireup] Process exited\n"))
-		}
-		proc.mu.Lock()
-		if err != nil {
-			proc.failed = true
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				proc.exitError = fmt.Sprintf("exit code %d", exitErr.ExitCode())
-			} else {
-				proc.exitError = err.Error()
-			}
-		}
-		proc.mu.Unlock()
-	}()
-
-	proc.starting = true
-	m.processes[name] = proc
-	m.mu.Unlock()
-
-	// Wait for port in background (keep checking until port ready or process exits)
-	go func() {
-		for {
-			// Check if process has exited
-			if proc.cmd.ProcessState != nil {
-				proc.mu.Lock()
-				proc.starting = false
-				proc.mu.Unlock()
-				return
-			}
-
-			// Check if port is ready
-			conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 100*time.Millisecond)
-			if err == nil {
-				conn.Close()
-				proc.mu.Lock()
-				proc.starting = false
-				proc.mu.Unlock()
-				return
-			}
-
-			time.Sleep(500 * time.Millisecond)
-		}
-	}()
+// ListenPort returns the port to proxy to: the detected port for port: auto
+// processes that ignored $PORT, otherwise Port
+func (p *Process) ListenPort() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.detectedPort != 0 {
+		return p.detectedPort
+	}
+	return p.Port
+}
 
-	return proc, nil
+// PortDetected returns true if the process listens on a port other than
+// the one it was assigned
+func (p *Process) PortDetected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.detectedPort != 0
 }
 
-// streamLogs reads from a reader and writes to the log buffer
-func streamLogs(r io.Reader, logs *LogBuffer, name string) {
+// streamLogs reads from a reader and writes to the log buffer. onLine, if
+// not nil, sees every line.
+func streamLogs(r io.Reader, logs *LogBuffer, name string, onLine func(string)) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 		logs.Write([]byte(line + "\n"))
+		if onLine != nil {
+			onLine(line)
+		}
 		// Also print to stdout for debugging
 		fmt.Printf("[%s] %s\n", name, line)
 	}
@@ -561,37 +647,49 @@ func cleanupRailsPID(dir string) {
 	os.Remove(pidFile)
 }
 
-// waitForPort waits for a port to become available
-func waitForPort(port int, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
-		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 100*time.Millisecond)
-		if err == nil {
-			conn.Close()
-			return nil
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	return fmt.Errorf("timeout waiting for port %d", port)
-}
-
-// Stop stops a process
+// Stop stops a process, or every instance of a scaled one
 func (m *Manager) Stop(name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.stopPendingLocked(name)
 	proc, exists := m.processes[name]
 	if !exists {
-		return fmt.Errorf("process not found: %s", name)
+		// The instances of a scaled process stop together
+		instances := m.instancesLocked(name)
+		if len(instances) == 0 {
+			return fmt.Errorf("process not found: %s", name)
+		}
+		for _, proc := range instances {
+			proc.Kill()
+			delete(m.processes, proc.Name)
+		}
+		m.Release(name)
+		return nil
 	}
 
 	proc.Kill()
 	delete(m.processes, name)
+	m.Release(name)
 	return nil
 }
 
-// Kill terminates the process and all its children
+// Kill terminates the process and all its children, and the daemon it
+// started if it has a pidfile
 func (p *Process) Kill() {
+	p.mu.Lock()
+	// A stopped process would only act on SIGTERM once continued
+	if err := p.resumeLocked(); err != nil {
+		fmt.Printf("[fireup] Kill %s: %v\n", p.Name, err)
+	}
+	p.stopIdleTimerLocked()
+	p.killed = true
+	daemon := p.daemonPID
+	p.mu.Unlock()
+	if daemon != 0 {
+		killDaemon(daemon, p.Name)
+	}
+
 	p.mu.Lock()
 	var pid int
 	var pgid int
@@ -664,56 +762,6 @@ func killChildProcesses(parentPid int, name string) {
 	}
 }
 
-// Restart restarts a process
-func (m *Manager) Restart(name string) (*Process, error) {
-	m.mu.RLock()
-	proc, exists := m.processes[name]
-	m.mu.RUnlock()
-
-	if !exists {
-		return nil, fmt.Errorf("process not found: %s", name)
-	}
-
-	// Capture config before stopping
-	command := proc.Command
-	dir := proc.Dir
-	env := proc.Env
-
-	// Stop
-	m.Stop(name)
-
-	// Brief wait for port release
-	time.Sleep(100 * time.Millisecond)
-
-	// Start again
-	return m.Start(name, command, dir, env)
-}
-
-// RestartAsync restarts a process without blocking
-func (m *Manager) RestartAsync(name string) {
-	m.mu.RLock()
-	proc, exists := m.processes[name]
-	m.mu.RUnlock()
-
-	if !exists {
-		return
-	}
-
-	// Capture config before stopping
-	command := proc.Command
-	dir := proc.Dir
-	env := proc.Env
-
-	// Stop
-	m.Stop(name)
-
-	// Start again asynchronously after brief delay
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-		m.Start(name, command, dir, env)
-	}()
-}
-
 // Get returns a process by name
 func (m *Manager) Get(name string) (*Process, bool) {
 	m.mu.RLock()
@@ -746,6 +794,10 @@ func (m *Manager) All() []*Process {
 func (m *Manager) StopAll() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.releaseAll()
+	for name := range m.pending {
+		m.stopPendingLocked(name)
+	}
 
 	if len(m.processes) == 0 {
 		fmt.Println("[fireup] StopAll: no processes to stop")
@@ -776,11 +828,15 @@ func (p *Process) IsRunning() bool {
 	}
 
 	// Check if process has exited
-	if p.cmd.ProcessState != nil {
+	if p.exited {
 		return false
 	}
 
-	fmt.Printf("[fireup] IsRunning: %s = true (port %d)\n", p.Name, p.Port)
+	port := p.Port
+	if p.detectedPort != 0 {
+		port = p.detectedPort
+	}
+	fmt.Printf("[fireup] IsRunning: %s = true (port %d)\n", p.Name, port)
 	return true
 }
 
@@ -794,6 +850,13 @@ func (p *Process) Uptime() time.Duration {
 	return time.Since(p.started)
 }
 
+// hasExited returns true once the process (or the daemon it started) is gone
+func (p *Process) hasExited() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exited
+}
+
 // HasFailed returns true if the process exited with an error
 func (p *Process) HasFailed() bool {
 	p.mu.Lock()
@@ -3,6 +3,8 @@ package process
 import (
 	"fmt"
 	"net"
+	"os/exec"
+	"strings"
 	"testing"
 	"time"
 )
@@ -433,3 +435,81 @@ func TestPortReservation(t *testing.T) {
 		}
 	})
 }
+
+func TestStartFixedPort(t *testing.T) {
+	m := NewManager()
+	defer m.StopAll()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	t.Run("reports a conflict", func(t *testing.T) {
+		_, err := m.StartAsyncWith("legacy", "sleep 999", "/tmp", nil, StartOptions{Port: port})
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("port %d is already in use", port)) {
+			t.Errorf("expected port conflict, got %v", err)
+		}
+		if _, found := m.Get("legacy"); found {
+			t.Error("expected no process after a conflict")
+		}
+	})
+
+	ln.Close()
+
+	t.Run("starts on the fixed port", func(t *testing.T) {
+		proc, err := m.StartAsyncWith("legacy", "sleep 999", "/tmp", nil, StartOptions{Port: port})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proc.Port != port {
+			t.Errorf("expected port %d, got %d", port, proc.Port)
+		}
+	})
+
+	t.Run("reports a conflict with another app", func(t *testing.T) {
+		_, err := m.StartAsyncWith("other", "sleep 999", "/tmp", nil, StartOptions{Port: port})
+		if err == nil || !strings.Contains(err.Error(), "already used by legacy") {
+			t.Errorf("expected conflict with legacy, got %v", err)
+		}
+	})
+}
+
+// readyTimeout is how long tests wait for a real server to listen. The login
+// shell commands run in can be slow to initialize, e.g. when a version
+// manager waits for a lock left by a shell that an earlier test killed.
+const readyTimeout = 90 * time.Second
+
+func TestStartAsyncWithHost(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	if ln, err := net.Listen("tcp", "[::1]:0"); err != nil {
+		t.Skip("IPv6 loopback not available")
+	} else {
+		ln.Close()
+	}
+
+	m := NewManager()
+	defer m.StopAll()
+
+	// Binds ::1 only, like Node 17+ resolving localhost
+	proc, err := m.StartAsyncWith("ipv6", "echo HOST=$HOST; python3 -m http.server $PORT --bind ::1", "/tmp", nil, StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(readyTimeout)
+	for proc.IsStarting() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !proc.IsRunning() {
+		t.Fatalf("expected the IPv6-only process to become ready: %s", proc.ExitError())
+	}
+	if !strings.Contains(strings.Join(proc.Logs().Lines(), "\n"), "HOST=localhost") {
+		t.Errorf("expected HOST=localhost in the environment, got logs %v", proc.Logs().Lines())
+	}
+	if want := fmt.Sprintf("localhost:%d", proc.Port); proc.Addr() != want {
+		t.Errorf("expected address %s, got %s", want, proc.Addr())
+	}
+}
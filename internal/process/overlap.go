@@ -0,0 +1,136 @@
+package process
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// OverlapGrace is how long an instance replaced by Overlap gets to finish
+// its requests and exit after SIGTERM before it's killed
+var OverlapGrace = 10 * time.Second
+
+// Overlap restarts the named process without downtime. The new instance
+// starts next to the running one on a fresh port and takes over the name
+// once it's ready; the old one then finishes its requests and is stopped.
+// If the new instance fails first, the old one keeps running and the
+// error is returned, and kept for RestartError. Blocks until either
+// happens. Processes that aren't running are started the usual way.
+func (m *Manager) Overlap(name, command, dir string, env map[string]string, opts StartOptions) error {
+	old, found := m.Get(name)
+	if !found || !old.IsRunning() {
+		_, err := m.StartAsyncWith(name, command, dir, env, opts)
+		return err
+	}
+	if opts.Port != 0 {
+		return fmt.Errorf("%s: can't overlap instances on fixed port %d", name, opts.Port)
+	}
+
+	// A restart still waiting for its new instance is superseded
+	m.mu.Lock()
+	m.stopPendingLocked(name)
+	m.mu.Unlock()
+
+	old.mu.Lock()
+	old.restartError = ""
+	old.mu.Unlock()
+	old.logs.Write([]byte("[fireup] Starting a new instance; this one serves until it's ready\n"))
+	proc, err := m.start(name, command, dir, env, opts, old)
+	if err != nil {
+		old.setRestartError(err)
+		return err
+	}
+
+	for proc.IsStarting() {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	m.mu.Lock()
+	if m.pending[name] != proc {
+		// Stopped in the meantime
+		m.mu.Unlock()
+		return nil
+	}
+	delete(m.pending, name)
+	if !proc.IsRunning() {
+		m.mu.Unlock()
+		proc.Kill()
+		err := fmt.Errorf("new instance of %s failed: %s", name, proc.ExitError())
+		if proc.ExitError() == "" {
+			err = fmt.Errorf("new instance of %s exited before it was ready", name)
+		}
+		old.setRestartError(err)
+		return err
+	}
+	if m.processes[name] != old {
+		// Replaced some other way in the meantime
+		m.mu.Unlock()
+		proc.Kill()
+		return nil
+	}
+	m.processes[name] = proc
+	m.mu.Unlock()
+
+	proc.logs.Write([]byte(fmt.Sprintf("[fireup] New instance ready on port %d, stopping the old one\n", proc.ListenPort())))
+	go old.stopGracefully(OverlapGrace)
+	return nil
+}
+
+// stopPendingLocked kills the new instance Overlap is starting for name,
+// if any. Called with m.mu held.
+func (m *Manager) stopPendingLocked(name string) {
+	if proc, ok := m.pending[name]; ok {
+		delete(m.pending, name)
+		proc.Kill()
+	}
+}
+
+// RestartError returns why the last Overlap couldn't replace the process,
+// or "" if it could
+func (p *Process) RestartError() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restartError
+}
+
+// setRestartError records a failed Overlap of the process
+func (p *Process) setRestartError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.restartError = err.Error()
+	p.logs.Write([]byte(fmt.Sprintf("[fireup] %v; this instance keeps running\n", err)))
+}
+
+// stopGracefully waits for the requests in flight to the process to
+// finish, then sends its process group SIGTERM. It's killed if it's still
+// there once grace has passed.
+func (p *Process) stopGracefully(grace time.Duration) {
+	deadline := time.Now().Add(grace)
+	for p.inFlight() > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	p.mu.Lock()
+	if err := p.resumeLocked(); err != nil {
+		fmt.Printf("[fireup] Stop %s: %v\n", p.Name, err)
+	}
+	p.stopIdleTimerLocked()
+	p.killed = true
+	pgid := p.groupIDLocked()
+	p.mu.Unlock()
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		fmt.Printf("[fireup] Stop %s: SIGTERM to group -%d failed: %v\n", p.Name, pgid, err)
+	}
+
+	for !p.hasExited() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	p.Kill()
+}
+
+// inFlight returns the number of requests being proxied to the process
+func (p *Process) inFlight() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inflight
+}
@@ -0,0 +1,102 @@
+package process
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOverlap(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	defer func(d time.Duration) { OverlapGrace = d }(OverlapGrace)
+	OverlapGrace = 2 * time.Second
+
+	command := "python3 -m http.server $PORT --bind 127.0.0.1"
+	m := NewManager()
+	defer m.StopAll()
+
+	start := func(t *testing.T, name string) *Process {
+		t.Helper()
+		proc, err := m.StartAsyncWith(name, command, "/tmp", nil, StartOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(readyTimeout)
+		for proc.IsStarting() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !proc.IsRunning() {
+			t.Fatalf("expected %s to be running: %s", name, proc.ExitError())
+		}
+		return proc
+	}
+
+	t.Run("switches to the new instance once it's ready", func(t *testing.T) {
+		old := start(t, "web")
+		if err := m.Overlap("web", command, "/tmp", nil, StartOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		proc, _ := m.Get("web")
+		if proc == old || proc.Port == old.Port {
+			t.Fatalf("expected a new instance on a new port, still on %d", old.Port)
+		}
+		resp, err := http.Get(fmt.Sprintf("http://%s/", proc.Addr()))
+		if err != nil {
+			t.Fatalf("expected the new instance to serve: %v", err)
+		}
+		resp.Body.Close()
+
+		deadline := time.Now().Add(OverlapGrace + time.Second)
+		for !old.hasExited() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !old.hasExited() {
+			t.Error("expected the old instance to be stopped")
+		}
+	})
+
+	t.Run("keeps the old instance when the new one fails", func(t *testing.T) {
+		old := start(t, "api")
+		err := m.Overlap("api", "exit 3", "/tmp", nil, StartOptions{})
+		if err == nil || !strings.Contains(err.Error(), "exit code 3") {
+			t.Fatalf("expected the new instance's exit code, got %v", err)
+		}
+
+		if proc, _ := m.Get("api"); proc != old || !old.IsRunning() {
+			t.Error("expected the old instance to keep running")
+		}
+		if old.RestartError() != err.Error() {
+			t.Errorf("expected RestartError %q, got %q", err, old.RestartError())
+		}
+
+		// The next successful restart clears it
+		if err := m.Overlap("api", command, "/tmp", nil, StartOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if proc, _ := m.Get("api"); proc.RestartError() != "" {
+			t.Errorf("expected no RestartError, got %q", proc.RestartError())
+		}
+	})
+
+	t.Run("starts a process that isn't running", func(t *testing.T) {
+		if err := m.Overlap("idle", command, "/tmp", nil, StartOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, found := m.Get("idle"); !found {
+			t.Error("expected idle to be started")
+		}
+	})
+
+	t.Run("rejects a fixed port", func(t *testing.T) {
+		start(t, "fixed")
+		if err := m.Overlap("fixed", command, "/tmp", nil, StartOptions{Port: 45678}); err == nil {
+			t.Error("expected an error for a fixed port")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package process
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// Pause stops the process group with SIGSTOP. The process keeps its memory
+// and port but gets no CPU until Resume, which is much faster than a cold
+// start.
+func (p *Process) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pauseLocked()
+}
+
+// pauseLocked is Pause with mu held
+func (p *Process) pauseLocked() error {
+	switch {
+	case p.paused:
+		return nil
+	case p.exited || p.killed:
+		return fmt.Errorf("%s is not running", p.Name)
+	case p.starting:
+		return fmt.Errorf("%s is still starting", p.Name)
+	}
+	if err := syscall.Kill(-p.groupIDLocked(), syscall.SIGSTOP); err != nil {
+		return fmt.Errorf("pause %s: %w", p.Name, err)
+	}
+	p.paused = true
+	p.stopIdleTimerLocked()
+	p.logs.Write([]byte("[fireup] Paused\n"))
+	return nil
+}
+
+// Resume continues a paused process group with SIGCONT
+func (p *Process) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.resumeLocked(); err != nil {
+		return err
+	}
+	p.scheduleIdlePauseLocked()
+	return nil
+}
+
+// resumeLocked is Resume with mu held, without restarting the idle timer
+func (p *Process) resumeLocked() error {
+	if !p.paused {
+		return nil
+	}
+	if err := syscall.Kill(-p.groupIDLocked(), syscall.SIGCONT); err != nil {
+		return fmt.Errorf("resume %s: %w", p.Name, err)
+	}
+	p.paused = false
+	p.logs.Write([]byte("[fireup] Resumed\n"))
+	return nil
+}
+
+// IsPaused returns true while the process is stopped by Pause
+func (p *Process) IsPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// BeginRequest marks a request to the process as in flight, resuming the
+// process first if it's paused. Call EndRequest once the request is done.
+func (p *Process) BeginRequest() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight++
+	p.stopIdleTimerLocked()
+	if p.paused {
+		fmt.Printf("[fireup] Resuming %s for a request\n", p.Name)
+		if err := p.resumeLocked(); err != nil {
+			fmt.Printf("[fireup] %v\n", err)
+		}
+	}
+}
+
+// EndRequest marks a request started with BeginRequest as done. The
+// pause_after timer starts once no requests are left.
+func (p *Process) EndRequest() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inflight > 0 {
+		p.inflight--
+	}
+	p.scheduleIdlePauseLocked()
+}
+
+// scheduleIdlePauseLocked (re)starts the timer that pauses the process
+// after pauseAfter without requests. Called with mu held.
+func (p *Process) scheduleIdlePauseLocked() {
+	p.stopIdleTimerLocked()
+	if p.pauseAfter == 0 || p.inflight > 0 || p.paused || p.starting || p.exited || p.killed {
+		return
+	}
+	// Read by the callback only after it takes mu, once timer is assigned
+	var timer *time.Timer
+	timer = time.AfterFunc(p.pauseAfter, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.idleTimer != timer || p.inflight > 0 {
+			return // Rescheduled or busy since
+		}
+		p.idleTimer = nil
+		if err := p.pauseLocked(); err == nil {
+			fmt.Printf("[fireup] Paused %s after %s without requests\n", p.Name, p.pauseAfter)
+		}
+	})
+	p.idleTimer = timer
+}
+
+// stopIdleTimerLocked cancels the pause_after timer. Called with mu held.
+func (p *Process) stopIdleTimerLocked() {
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
+}
+
+// Pause pauses a process by name
+func (m *Manager) Pause(name string) error {
+	proc, exists := m.Get(name)
+	if !exists {
+		return fmt.Errorf("process not found: %s", name)
+	}
+	return proc.Pause()
+}
+
+// Resume resumes a paused process by name
+func (m *Manager) Resume(name string) error {
+	proc, exists := m.Get(name)
+	if !exists {
+		return fmt.Errorf("process not found: %s", name)
+	}
+	return proc.Resume()
+}
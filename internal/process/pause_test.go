@@ -0,0 +1,113 @@
+package process
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestPauseResume(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+
+	m := NewManager()
+	defer m.StopAll()
+
+	start := func(t *testing.T, name string, opts StartOptions) *Process {
+		t.Helper()
+		proc, err := m.StartAsyncWith(name, "python3 -m http.server $PORT --bind 127.0.0.1", "/tmp", nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := proc.Pause(); err == nil {
+			t.Error("expected pausing a starting process to fail")
+		}
+		deadline := time.Now().Add(readyTimeout)
+		for proc.IsStarting() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !proc.IsRunning() {
+			t.Fatalf("expected process to be running: %s", proc.ExitError())
+		}
+		return proc
+	}
+	// A stopped server still accepts connections but never answers
+	responds := func(proc *Process) bool {
+		client := http.Client{Timeout: 500 * time.Millisecond}
+		resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/", proc.Port))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}
+
+	t.Run("pause stops the process and resume continues it", func(t *testing.T) {
+		proc := start(t, "manual", StartOptions{})
+		if err := m.Pause("manual"); err != nil {
+			t.Fatal(err)
+		}
+		if !proc.IsPaused() || !proc.IsRunning() {
+			t.Errorf("expected a paused, running process, got paused=%v running=%v", proc.IsPaused(), proc.IsRunning())
+		}
+		if responds(proc) {
+			t.Error("expected a paused process not to respond")
+		}
+		if err := m.Resume("manual"); err != nil {
+			t.Fatal(err)
+		}
+		if proc.IsPaused() || !responds(proc) {
+			t.Error("expected a resumed process to respond")
+		}
+	})
+
+	t.Run("requests resume the process and pause_after pauses it", func(t *testing.T) {
+		proc := start(t, "idle", StartOptions{PauseAfter: 300 * time.Millisecond})
+		waitPaused := func() bool {
+			deadline := time.Now().Add(3 * time.Second)
+			for !proc.IsPaused() && time.Now().Before(deadline) {
+				time.Sleep(50 * time.Millisecond)
+			}
+			return proc.IsPaused()
+		}
+		if !waitPaused() {
+			t.Fatal("expected the process to pause once idle")
+		}
+
+		proc.BeginRequest()
+		if proc.IsPaused() || !responds(proc) {
+			t.Error("expected a request to resume the process")
+		}
+		time.Sleep(600 * time.Millisecond)
+		if proc.IsPaused() {
+			t.Error("expected the process not to pause during a request")
+		}
+		proc.EndRequest()
+		if !waitPaused() {
+			t.Error("expected the process to pause again after the request")
+		}
+	})
+
+	t.Run("stopping a paused process", func(t *testing.T) {
+		start(t, "stopped", StartOptions{})
+		if err := m.Pause("stopped"); err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan struct{})
+		go func() {
+			m.Stop("stopped")
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out stopping a paused process")
+		}
+		if err := m.Pause("stopped"); err == nil {
+			t.Error("expected pausing a stopped process to fail")
+		}
+	})
+}
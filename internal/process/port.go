@@ -0,0 +1,84 @@
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/backend"
+)
+
+// Listener is a process listening on a TCP port
+type Listener struct {
+	Command string
+	PID     int
+}
+
+// String returns e.g. "node (pid 1234)"
+func (l Listener) String() string {
+	return fmt.Sprintf("%s (pid %d)", l.Command, l.PID)
+}
+
+// ListenerOnPort returns the process listening on a TCP port. Returns false
+// if nothing is listening or lsof can't tell (e.g. another user's process).
+func ListenerOnPort(port int) (Listener, bool) {
+	// lsof works without sudo for processes we own
+	cmd := exec.Command("lsof", "-i", fmt.Sprintf(":%d", port), "-sTCP:LISTEN", "-n", "-P")
+	output, err := cmd.Output()
+	if err != nil {
+		return Listener{}, false
+	}
+
+	// Parse lsof output - format: COMMAND PID USER ...
+	lines := strings.Split(string(output), "\n")
+	for _, line := range lines[1:] { // Skip header
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			pid, _ := strconv.Atoi(fields[1])
+			return Listener{Command: fields[0], PID: pid}, true
+		}
+	}
+	return Listener{}, false
+}
+
+// portInUse returns true if something accepts connections on the port, on
+// IPv4 or IPv6
+func portInUse(port int) bool {
+	conn, err := backend.DialTimeout(backend.Addr(backend.Auto, port), 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// portReleaseWait is how long a fixed port may take to free up, e.g. after
+// the previous process was stopped for a restart
+const portReleaseWait = time.Second
+
+// checkFixedPort returns an error describing who holds port, if anyone
+func (m *Manager) checkFixedPort(name string, port int) error {
+	for _, p := range m.All() {
+		if p.Name != name && p.ListenPort() == port && (p.IsRunning() || p.IsStarting()) {
+			return fmt.Errorf("port %d is already used by %s", port, p.Name)
+		}
+	}
+	deadline := time.Now().Add(portReleaseWait)
+	for portInUse(port) {
+		if time.Now().After(deadline) {
+			return portConflict(port)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+// portConflict describes the process that holds port
+func portConflict(port int) error {
+	if l, ok := ListenerOnPort(port); ok {
+		return fmt.Errorf("port %d is already in use by %s", port, l)
+	}
+	return fmt.Errorf("port %d is already in use by another process", port)
+}
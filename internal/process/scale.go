@@ -0,0 +1,89 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InstanceName returns the process name of instance i (counting from 1) of
+// a process started with StartScaled, e.g. web-myapp#2
+func InstanceName(name string, i int) string {
+	return fmt.Sprintf("%s#%d", name, i)
+}
+
+// StartScaled starts n instances of a process without waiting for them,
+// each on its own port with its own logs, named with InstanceName.
+// $FIREUP_INSTANCE tells each instance its number. Instances already
+// running or starting are kept, and instances left from a larger n are
+// stopped.
+func (m *Manager) StartScaled(name, command, dir string, env map[string]string, opts StartOptions, n int) ([]*Process, error) {
+	if opts.Port != 0 || opts.PIDFile != "" {
+		return nil, fmt.Errorf("%s: can't scale a process with a fixed port or pidfile", name)
+	}
+
+	m.mu.Lock()
+	for _, proc := range m.instancesLocked(name) {
+		if instanceNumber(name, proc.Name) > n {
+			proc.Kill()
+			delete(m.processes, proc.Name)
+		}
+	}
+	m.mu.Unlock()
+
+	var procs []*Process
+	for i := 1; i <= n; i++ {
+		instanceEnv := make(map[string]string, len(env)+1)
+		for k, v := range env {
+			instanceEnv[k] = v
+		}
+		instanceEnv["FIREUP_INSTANCE"] = strconv.Itoa(i)
+		proc, err := m.StartAsyncWith(InstanceName(name, i), command, dir, instanceEnv, opts)
+		if err != nil {
+			return procs, err
+		}
+		procs = append(procs, proc)
+	}
+	return procs, nil
+}
+
+// Instances returns the named process, or the instances of a process
+// started with StartScaled by number. It's empty if there are neither.
+func (m *Manager) Instances(name string) []*Process {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if proc, ok := m.processes[name]; ok {
+		return []*Process{proc}
+	}
+	return m.instancesLocked(name)
+}
+
+// instancesLocked returns the instances of a scaled process by number.
+// Called with m.mu held.
+func (m *Manager) instancesLocked(name string) []*Process {
+	var procs []*Process
+	for key, proc := range m.processes {
+		if instanceNumber(name, key) > 0 {
+			procs = append(procs, proc)
+		}
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return instanceNumber(name, procs[i].Name) < instanceNumber(name, procs[j].Name)
+	})
+	return procs
+}
+
+// instanceNumber returns the number of the instance of name that process
+// is, or 0 if it's not one
+func instanceNumber(name, process string) int {
+	suffix, ok := strings.CutPrefix(process, name+"#")
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(suffix)
+	if err != nil || i < 1 {
+		return 0
+	}
+	return i
+}
@@ -0,0 +1,73 @@
+package process
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestStartScaled(t *testing.T) {
+	m := NewManager()
+	defer m.StopAll()
+
+	names := func() []string {
+		var names []string
+		for _, proc := range m.Instances("web-shop") {
+			names = append(names, proc.Name)
+		}
+		return names
+	}
+
+	t.Run("starts instances on their own ports", func(t *testing.T) {
+		procs, err := m.StartScaled("web-shop", "sleep 999", "/tmp", map[string]string{"MODE": "a"}, StartOptions{}, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ports := make(map[int]bool)
+		for i, proc := range procs {
+			if want := InstanceName("web-shop", i+1); proc.Name != want {
+				t.Errorf("expected %s, got %s", want, proc.Name)
+			}
+			if proc.Env["MODE"] != "a" || proc.Env["FIREUP_INSTANCE"] != strconv.Itoa(i+1) {
+				t.Errorf("%s: unexpected env %v", proc.Name, proc.Env)
+			}
+			ports[proc.Port] = true
+		}
+		if len(ports) != 3 {
+			t.Errorf("expected 3 ports, got %v", ports)
+		}
+		if got := names(); len(got) != 3 || got[0] != "web-shop#1" || got[2] != "web-shop#3" {
+			t.Errorf("expected the instances by number, got %v", got)
+		}
+		if _, found := m.Get("web-shop"); found {
+			t.Error("expected no process named after the service itself")
+		}
+	})
+
+	t.Run("keeps running instances and stops extra ones", func(t *testing.T) {
+		first := m.Instances("web-shop")[0]
+		if _, err := m.StartScaled("web-shop", "sleep 999", "/tmp", nil, StartOptions{}, 2); err != nil {
+			t.Fatal(err)
+		}
+		if got := names(); len(got) != 2 {
+			t.Errorf("expected 2 instances, got %v", got)
+		}
+		if m.Instances("web-shop")[0] != first {
+			t.Error("expected the running instance to be kept")
+		}
+	})
+
+	t.Run("stops every instance", func(t *testing.T) {
+		if err := m.Stop("web-shop"); err != nil {
+			t.Fatal(err)
+		}
+		if got := names(); len(got) != 0 {
+			t.Errorf("expected no instances, got %v", got)
+		}
+	})
+
+	t.Run("rejects a fixed port", func(t *testing.T) {
+		if _, err := m.StartScaled("web-shop", "sleep 999", "/tmp", nil, StartOptions{Port: 45678}, 2); err == nil {
+			t.Error("expected an error for a fixed port")
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultSharedGrace is how long a shared process keeps running after its
+// last holder lets go, so that restarting a dependent doesn't bounce it
+const DefaultSharedGrace = 30 * time.Second
+
+// sharedRef tracks which processes hold a reference to a shared process
+type sharedRef struct {
+	holders map[string]bool
+	grace   time.Duration
+	timer   *time.Timer // Pending stop once there are no holders
+}
+
+// Acquire records that holder (a dependent process) uses the shared process
+// name. A pending stop of name is cancelled. grace is how long name keeps
+// running once the last holder is gone.
+func (m *Manager) Acquire(name, holder string, grace time.Duration) {
+	m.refMu.Lock()
+	defer m.refMu.Unlock()
+
+	ref, ok := m.refs[name]
+	if !ok {
+		ref = &sharedRef{holders: make(map[string]bool)}
+		m.refs[name] = ref
+	}
+	ref.holders[holder] = true
+	ref.grace = grace
+	if ref.timer != nil {
+		ref.timer.Stop()
+		ref.timer = nil
+	}
+}
+
+// Release drops every reference holder has. Shared processes left without
+// holders are stopped after their grace period unless acquired again.
+// Stop and process exit release automatically.
+func (m *Manager) Release(holder string) {
+	m.refMu.Lock()
+	defer m.refMu.Unlock()
+
+	for name, ref := range m.refs {
+		if !ref.holders[holder] {
+			continue
+		}
+		delete(ref.holders, holder)
+		if len(ref.holders) > 0 || ref.timer != nil {
+			continue
+		}
+		name, ref := name, ref
+		fmt.Printf("[fireup] %s has no more dependents, stopping in %s\n", name, ref.grace)
+		ref.timer = time.AfterFunc(ref.grace, func() {
+			m.refMu.Lock()
+			if m.refs[name] != ref || len(ref.holders) > 0 {
+				m.refMu.Unlock()
+				return // Acquired again in the meantime
+			}
+			delete(m.refs, name)
+			m.refMu.Unlock()
+			m.Stop(name)
+		})
+	}
+}
+
+// Holders returns the processes that hold a reference to name, sorted
+func (m *Manager) Holders(name string) []string {
+	m.refMu.Lock()
+	defer m.refMu.Unlock()
+
+	ref, ok := m.refs[name]
+	if !ok {
+		return nil
+	}
+	holders := make([]string, 0, len(ref.holders))
+	for holder := range ref.holders {
+		holders = append(holders, holder)
+	}
+	sort.Strings(holders)
+	return holders
+}
+
+// releaseAll drops all references and pending stops
+func (m *Manager) releaseAll() {
+	m.refMu.Lock()
+	defer m.refMu.Unlock()
+
+	for _, ref := range m.refs {
+		if ref.timer != nil {
+			ref.timer.Stop()
+		}
+	}
+	m.refs = make(map[string]*sharedRef)
+}
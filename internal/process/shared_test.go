@@ -0,0 +1,65 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSharedProcess(t *testing.T) {
+	m := NewManager()
+	defer m.StopAll()
+
+	grace := 200 * time.Millisecond
+	running := func(name string) bool {
+		proc, found := m.Get(name)
+		return found && (proc.IsRunning() || proc.IsStarting())
+	}
+
+	if _, err := m.StartAsync("redis", "sleep 999", "/tmp", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"shop", "blog"} {
+		if _, err := m.StartAsync(name, "sleep 999", "/tmp", nil); err != nil {
+			t.Fatal(err)
+		}
+		m.Acquire("redis", name, grace)
+	}
+	if got := m.Holders("redis"); !reflect.DeepEqual(got, []string{"blog", "shop"}) {
+		t.Errorf("expected blog and shop to hold redis, got %v", got)
+	}
+
+	t.Run("keeps running while held", func(t *testing.T) {
+		m.Stop("shop")
+		time.Sleep(2 * grace)
+		if !running("redis") {
+			t.Error("expected redis to keep running for blog")
+		}
+		if got := m.Holders("redis"); !reflect.DeepEqual(got, []string{"blog"}) {
+			t.Errorf("expected only blog to hold redis, got %v", got)
+		}
+	})
+
+	t.Run("acquiring again cancels the stop", func(t *testing.T) {
+		m.Stop("blog")
+		m.Acquire("redis", "blog", grace)
+		time.Sleep(2 * grace)
+		if !running("redis") {
+			t.Error("expected redis to keep running after being acquired again")
+		}
+	})
+
+	t.Run("stops after the grace period", func(t *testing.T) {
+		m.Release("blog")
+		if !running("redis") {
+			t.Error("expected redis to run during the grace period")
+		}
+		time.Sleep(2 * grace)
+		if running("redis") {
+			t.Error("expected redis to be stopped after the grace period")
+		}
+		if got := m.Holders("redis"); got != nil {
+			t.Errorf("expected no holders, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Policy is how a Balancer picks instances. The values are those of the
+// balance: setting.
+type Policy string
+
+const (
+	RoundRobin       Policy = "round-robin"       // Each instance in turn (also the default)
+	LeastConnections Policy = "least-connections" // The instance with the fewest requests in flight
+	Sticky           Policy = "sticky"            // The instance the client got first, pinned with a cookie
+)
+
+// Balancer spreads the requests to the instances of a scaled process
+type Balancer struct {
+	name   string // Process name, which names the sticky cookie
+	policy Policy
+
+	mu     sync.Mutex
+	next   int            // Round-robin position
+	active map[string]int // Requests in flight by instance
+}
+
+// Pick returns the instance among instances (process names, in a stable
+// order) that serves r, and a func to call once it has. instances are the
+// healthy ones and can't be empty; instances that crashed or are still
+// starting are taken out of rotation by leaving them out. With Sticky, the
+// client is pinned to the instance it got with a cookie set on w, until
+// that instance leaves rotation.
+func (b *Balancer) Pick(w http.ResponseWriter, r *http.Request, instances []string) (string, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var picked string
+	switch b.policy {
+	case Sticky:
+		if c, err := r.Cookie(b.cookieName()); err == nil && contains(instances, c.Value) {
+			picked = c.Value
+		} else {
+			picked = b.roundRobinLocked(instances)
+			http.SetCookie(w, &http.Cookie{Name: b.cookieName(), Value: picked, Path: "/", HttpOnly: true})
+		}
+	case LeastConnections:
+		// Ties go round-robin, so idle instances share the load too
+		start := b.next
+		b.next++
+		for i := range instances {
+			instance := instances[(start+i)%len(instances)]
+			if picked == "" || b.active[instance] < b.active[picked] {
+				picked = instance
+			}
+		}
+	default:
+		picked = b.roundRobinLocked(instances)
+	}
+
+	if b.active == nil {
+		b.active = make(map[string]int)
+	}
+	b.active[picked]++
+	return picked, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.active[picked]--; b.active[picked] <= 0 {
+			delete(b.active, picked)
+		}
+	}
+}
+
+// roundRobinLocked returns the next instance in turn. Called with b.mu
+// held.
+func (b *Balancer) roundRobinLocked(instances []string) string {
+	instance := instances[b.next%len(instances)]
+	b.next++
+	return instance
+}
+
+// cookieName returns the name of the cookie pinning clients with Sticky
+func (b *Balancer) cookieName() string {
+	return "fireup-instance-" + b.name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Balancers keeps one Balancer per scaled process, so the rotation and the
+// requests in flight carry over between requests. The zero value is ready
+// to use.
+type Balancers struct {
+	mu        sync.Mutex
+	balancers map[string]*Balancer
+}
+
+// Get returns the balancer of the named process, building a new one if
+// there is none yet or its policy changed
+func (bs *Balancers) Get(name string, policy Policy) *Balancer {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if b, ok := bs.balancers[name]; ok && b.policy == policy {
+		return b
+	}
+	if bs.balancers == nil {
+		bs.balancers = make(map[string]*Balancer)
+	}
+	b := &Balancer{name: name, policy: policy}
+	bs.balancers[name] = b
+	return b
+}
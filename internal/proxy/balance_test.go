@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBalancer(t *testing.T) {
+	instances := []string{"web-shop#1", "web-shop#2", "web-shop#3"}
+	pick := func(b *Balancer, instances []string) string {
+		name, done := b.Pick(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), instances)
+		done()
+		return name
+	}
+
+	t.Run("round-robin takes turns", func(t *testing.T) {
+		var bs Balancers
+		b := bs.Get("web-shop", "")
+		var got []string
+		for i := 0; i < 4; i++ {
+			got = append(got, pick(b, instances))
+		}
+		if want := "web-shop#1 web-shop#2 web-shop#3 web-shop#1"; strings.Join(got, " ") != want {
+			t.Errorf("expected %s, got %v", want, got)
+		}
+	})
+
+	t.Run("skips instances out of rotation", func(t *testing.T) {
+		var bs Balancers
+		b := bs.Get("web-shop", RoundRobin)
+		for i := 0; i < 4; i++ {
+			if got := pick(b, []string{"web-shop#1", "web-shop#3"}); got == "web-shop#2" {
+				t.Fatalf("expected web-shop#2 to be skipped")
+			}
+		}
+	})
+
+	t.Run("least-connections avoids busy instances", func(t *testing.T) {
+		var bs Balancers
+		b := bs.Get("web-shop", LeastConnections)
+		req := httptest.NewRequest("GET", "/", nil)
+		first, done1 := b.Pick(httptest.NewRecorder(), req, instances)
+		second, done2 := b.Pick(httptest.NewRecorder(), req, instances)
+		if first == second {
+			t.Errorf("expected a second instance while %s is busy", first)
+		}
+		done1()
+		done2()
+
+		// A slow request keeps its instance busy
+		busy, done := b.Pick(httptest.NewRecorder(), req, instances)
+		defer done()
+		for i := 0; i < 4; i++ {
+			if got := pick(b, instances); got == busy {
+				t.Errorf("expected the busy %s to be avoided", busy)
+			}
+		}
+	})
+
+	t.Run("sticky pins clients with a cookie", func(t *testing.T) {
+		var bs Balancers
+		b := bs.Get("web-shop", Sticky)
+		w := httptest.NewRecorder()
+		first, done := b.Pick(w, httptest.NewRequest("GET", "/", nil), instances)
+		done()
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Value != first {
+			t.Fatalf("expected a cookie naming %s, got %v", first, cookies)
+		}
+
+		for i := 0; i < 3; i++ {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.AddCookie(cookies[0])
+			w := httptest.NewRecorder()
+			name, done := b.Pick(w, r, instances)
+			done()
+			if name != first {
+				t.Errorf("expected the pinned %s, got %s", first, name)
+			}
+			if len(w.Result().Cookies()) != 0 {
+				t.Error("expected no new cookie for a pinned client")
+			}
+		}
+
+		// The client moves on once its instance leaves rotation
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(cookies[0])
+		w = httptest.NewRecorder()
+		name, done := b.Pick(w, r, []string{"web-shop#9"})
+		done()
+		if name != "web-shop#9" || len(w.Result().Cookies()) != 1 {
+			t.Errorf("expected to be pinned to web-shop#9 instead, got %s", name)
+		}
+	})
+
+	t.Run("a new policy replaces the balancer", func(t *testing.T) {
+		var bs Balancers
+		if bs.Get("web-shop", RoundRobin) == bs.Get("web-shop", Sticky) {
+			t.Error("expected a new balancer for a new policy")
+		}
+		if b := bs.Get("web-shop", Sticky); b != bs.Get("web-shop", Sticky) {
+			t.Error("expected the balancer to be reused")
+		}
+	})
+}
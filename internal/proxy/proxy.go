@@ -1,29 +1,93 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/panozzaj/fireup/internal/backend"
+	"github.com/panozzaj/fireup/internal/inspect"
 )
 
 // ReverseProxy handles proxying requests to backend services
 type ReverseProxy struct {
-	target *url.URL
-	proxy  *httputil.ReverseProxy
+	target  *url.URL
+	theme   string
+	proxy   *httputil.ReverseProxy
+	name    string           // App or process name, recorded with captured requests
+	capture *inspect.Capture // Records requests and responses if set
+}
+
+// transport is shared by all proxies. It dials backends through
+// backend.Dial, so that apps on either loopback family are reachable with
+// host: auto, and keeps enough idle connections per backend that bursts of
+// asset requests reuse them instead of opening new ones.
+var transport = newTransport()
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = backend.Dial
+	t.MaxIdleConns = 256
+	t.MaxIdleConnsPerHost = 64
+	t.IdleConnTimeout = 90 * time.Second
+	return t
 }
 
-// NewReverseProxy creates a new reverse proxy to the given port
-func NewReverseProxy(port int, theme string) *ReverseProxy {
-	target, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", port))
+// Cache keeps one ReverseProxy per backend so requests reuse it. The zero
+// value is ready to use.
+type Cache struct {
+	mu      sync.Mutex
+	proxies map[string]*ReverseProxy
+}
+
+// Get returns the proxy for key (an app or process name), building a new
+// one if there is none yet or the backend's address, the theme or the
+// capture changed, e.g. because the process restarted on another port.
+// capture may be nil.
+func (c *Cache) Get(key, addr, theme string, capture *inspect.Capture) *ReverseProxy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rp, ok := c.proxies[key]; ok && rp.target.Host == addr && rp.theme == theme && rp.capture == capture {
+		return rp
+	}
+	if c.proxies == nil {
+		c.proxies = make(map[string]*ReverseProxy)
+	}
+	rp := NewReverseProxy(addr, theme)
+	rp.name = key
+	rp.capture = capture
+	c.proxies[key] = rp
+	return rp
+}
+
+// Remove drops the proxy for key, and those of its instances if it's a
+// scaled process (key#1, key#2, ...), once the app is removed or the
+// process stopped
+func (c *Cache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.proxies {
+		if k == key || strings.HasPrefix(k, key+"#") {
+			delete(c.proxies, k)
+		}
+	}
+}
+
+// NewReverseProxy creates a new reverse proxy to the given backend address
+// (see backend.Addr)
+func NewReverseProxy(addr string, theme string) *ReverseProxy {
+	target, _ := url.Parse("http://" + addr)
 
 	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
 
 	// Preserve the original Host header
 	originalDirector := proxy.Director
@@ -38,6 +102,9 @@ func NewReverseProxy(port int, theme string) *ReverseProxy {
 
 	// Handle errors gracefully with a styled page that auto-retries
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if e, ok := r.Context().Value(exchangeKey{}).(*inspect.Exchange); ok {
+			e.Error = err.Error()
+		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusBadGateway)
 		fmt.Fprintf(w, `<!DOCTYPE html>
@@ -94,6 +161,7 @@ func NewReverseProxy(port int, theme string) *ReverseProxy {
 
 	return &ReverseProxy{
 		target: target,
+		theme:  theme,
 		proxy:  proxy,
 	}
 }
@@ -120,15 +188,114 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if p.capture != nil {
+		p.serveCaptured(w, r)
+		return
+	}
+
 	p.proxy.ServeHTTP(w, r)
 }
 
+// exchangeKey is the context key of the exchange being captured, so the
+// error handler can record why the backend couldn't be reached
+type exchangeKey struct{}
+
+// serveCaptured proxies a request and records it and the response
+func (p *ReverseProxy) serveCaptured(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	e := &inspect.Exchange{
+		Target:         p.name,
+		Time:           start,
+		Method:         r.Method,
+		URL:            requestURI(r),
+		Host:           r.Host,
+		RequestHeaders: r.Header.Clone(),
+	}
+	replay := inspect.ReplayFrom(r.Context())
+	if replay != nil {
+		e.ReplayOf = replay.Of
+	}
+	reqBody := p.capture.NewRequestBody()
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = teeReadCloser{io.TeeReader(r.Body, reqBody), r.Body}
+	}
+	cw := &captureWriter{ResponseWriter: w, body: p.capture.NewBody()}
+
+	p.proxy.ServeHTTP(cw, r.WithContext(context.WithValue(r.Context(), exchangeKey{}, e)))
+
+	e.Duration = float64(time.Since(start).Microseconds()) / 1000
+	e.RequestBody = reqBody.Body()
+	e.Status = cw.status
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	e.ResponseHeaders = w.Header().Clone()
+	e.ResponseBody = cw.body.Body()
+	e.KeepRequest(e.RequestHeaders, reqBody)
+	stored := p.capture.Add(*e)
+	if replay != nil {
+		replay.Exchange, replay.Recorded = stored, true
+	}
+}
+
+// requestURI returns the path and query the client asked for, before
+// routes or Tailscale Serve rewrote r.URL
+func requestURI(r *http.Request) string {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		return u.RequestURI()
+	}
+	return r.URL.RequestURI()
+}
+
+// teeReadCloser reads through a TeeReader and closes the original body
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter records the status and body of a response as it's written
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   *inspect.BodyWriter
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.body.Write(p)
+	return cw.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Flush passes flushes of streamed responses on
+func (cw *captureWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // serveWebSocket handles WebSocket upgrade requests by hijacking the client
 // connection and relaying bytes bidirectionally to the backend
 func (p *ReverseProxy) serveWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Dial the backend
 	backendAddr := p.target.Host
-	backendConn, err := net.DialTimeout("tcp", backendAddr, 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	backendConn, err := backend.Dial(ctx, "tcp", backendAddr)
+	cancel()
 	if err != nil {
 		http.Error(w, "Backend unavailable", http.StatusBadGateway)
 		return
@@ -164,18 +331,22 @@ func (p *ReverseProxy) serveWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Bidirectional relay
+	// Bidirectional relay, then close both
+	Relay(clientConn, backendConn)
+}
+
+// Relay copies bytes between two connections in both directions until
+// either direction is done. The caller closes both.
+func Relay(a, b io.ReadWriter) {
 	done := make(chan struct{}, 2)
 	go func() {
-		io.Copy(clientConn, backendConn)
+		io.Copy(a, b)
 		done <- struct{}{}
 	}()
 	go func() {
-		io.Copy(backendConn, clientConn)
+		io.Copy(b, a)
 		done <- struct{}{}
 	}()
-
-	// Wait for either direction to finish, then close both
 	<-done
 }
 
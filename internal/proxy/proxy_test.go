@@ -9,8 +9,11 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/panozzaj/fireup/internal/inspect"
 )
 
 func TestReverseProxy_HTTP(t *testing.T) {
@@ -22,7 +25,7 @@ func TestReverseProxy_HTTP(t *testing.T) {
 	defer backend.Close()
 
 	port := portFromURL(t, backend.URL)
-	rp := NewReverseProxy(port, "dark")
+	rp := NewReverseProxy(fmt.Sprintf("127.0.0.1:%d", port), "dark")
 
 	// Proxy server
 	proxy := httptest.NewServer(rp)
@@ -53,7 +56,7 @@ func TestReverseProxy_ForwardsHeaders(t *testing.T) {
 	defer backend.Close()
 
 	port := portFromURL(t, backend.URL)
-	rp := NewReverseProxy(port, "dark")
+	rp := NewReverseProxy(fmt.Sprintf("127.0.0.1:%d", port), "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -70,9 +73,38 @@ func TestReverseProxy_ForwardsHeaders(t *testing.T) {
 	}
 }
 
+func TestReverseProxy_AutoHostIPv6(t *testing.T) {
+	// Like Node 17+ binding localhost to ::1 only
+	ln, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback not available")
+	}
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello over ipv6")
+	}))
+	backend.Listener = ln
+	backend.Start()
+	defer backend.Close()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	proxy := httptest.NewServer(NewReverseProxy(fmt.Sprintf("localhost:%d", port), "dark"))
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL + "/")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || string(body) != "hello over ipv6" {
+		t.Errorf("expected the IPv6 backend's response, got %d %q", resp.StatusCode, string(body))
+	}
+}
+
 func TestReverseProxy_ErrorHandler(t *testing.T) {
 	// Proxy to a port with nothing listening
-	rp := NewReverseProxy(19999, "dark")
+	rp := NewReverseProxy("127.0.0.1:19999", "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -101,7 +133,7 @@ func TestReverseProxy_CacheBusting(t *testing.T) {
 	defer backend.Close()
 
 	port := portFromURL(t, backend.URL)
-	rp := NewReverseProxy(port, "dark")
+	rp := NewReverseProxy(fmt.Sprintf("127.0.0.1:%d", port), "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -118,6 +150,22 @@ func TestReverseProxy_CacheBusting(t *testing.T) {
 	}
 }
 
+func TestCacheRemove(t *testing.T) {
+	var c Cache
+	web := c.Get("web-shop", "127.0.0.1:3000", "dark", nil)
+	c.Get("web-shop#1", "127.0.0.1:3001", "dark", nil)
+	c.Get("web-shop#2", "127.0.0.1:3002", "dark", nil)
+	worker := c.Get("worker-shop", "127.0.0.1:3003", "dark", nil)
+
+	c.Remove("web-shop")
+	if len(c.proxies) != 1 || c.proxies["worker-shop"] != worker {
+		t.Errorf("expected only worker-shop to be left, got %v", c.proxies)
+	}
+	if c.Get("web-shop", "127.0.0.1:3000", "dark", nil) == web {
+		t.Error("expected a new proxy after Remove")
+	}
+}
+
 func TestReverseProxy_NoCacheBustingForNonHTML(t *testing.T) {
 	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -126,7 +174,7 @@ func TestReverseProxy_NoCacheBustingForNonHTML(t *testing.T) {
 	defer backend.Close()
 
 	port := portFromURL(t, backend.URL)
-	rp := NewReverseProxy(port, "dark")
+	rp := NewReverseProxy(fmt.Sprintf("127.0.0.1:%d", port), "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -214,7 +262,7 @@ func TestReverseProxy_WebSocketRelay(t *testing.T) {
 	defer backend.Close()
 
 	port := portFromURL(t, backend.URL)
-	rp := NewReverseProxy(port, "dark")
+	rp := NewReverseProxy(fmt.Sprintf("127.0.0.1:%d", port), "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -288,7 +336,7 @@ func TestReverseProxy_WebSocketRelay(t *testing.T) {
 
 func TestReverseProxy_WebSocketBackendDown(t *testing.T) {
 	// Proxy to a port with nothing listening — WebSocket upgrade should fail gracefully
-	rp := NewReverseProxy(19999, "dark")
+	rp := NewReverseProxy("127.0.0.1:19999", "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -330,7 +378,7 @@ func TestReverseProxy_RegularUpgradeNotIntercepted(t *testing.T) {
 	defer backend.Close()
 
 	port := portFromURL(t, backend.URL)
-	rp := NewReverseProxy(port, "dark")
+	rp := NewReverseProxy(fmt.Sprintf("127.0.0.1:%d", port), "dark")
 
 	proxy := httptest.NewServer(rp)
 	defer proxy.Close()
@@ -345,8 +393,98 @@ func TestReverseProxy_RegularUpgradeNotIntercepted(t *testing.T) {
 	}
 }
 
+func TestCache(t *testing.T) {
+	var cache Cache
+	rp := cache.Get("web-shop", "127.0.0.1:3000", "dark", nil)
+	if cache.Get("web-shop", "127.0.0.1:3000", "dark", nil) != rp {
+		t.Error("expected the same backend to reuse its proxy")
+	}
+	if cache.Get("web-shop", "127.0.0.1:3001", "dark", nil) == rp {
+		t.Error("expected a new proxy once the port changes")
+	}
+	if cache.Get("web-shop", "127.0.0.1:3001", "light", nil).theme != "light" {
+		t.Error("expected a new proxy once the theme changes")
+	}
+	if cache.Get("api-shop", "127.0.0.1:3001", "light", nil) == cache.Get("web-shop", "127.0.0.1:3001", "light", nil) {
+		t.Error("expected a proxy per key")
+	}
+}
+
+func TestReverseProxy_CaptureBackendDown(t *testing.T) {
+	var cache Cache
+	capture := inspect.NewCapture(inspect.Options{})
+	rp := cache.Get("hooks", "127.0.0.1:19999", "dark", capture)
+
+	w := httptest.NewRecorder()
+	rp.ServeHTTP(w, httptest.NewRequest("GET", "http://hooks.test/webhook", nil))
+
+	exchanges := capture.Since(0)
+	if len(exchanges) != 1 {
+		t.Fatalf("expected one exchange, got %d", len(exchanges))
+	}
+	e := exchanges[0]
+	if e.Target != "hooks" || e.Status != http.StatusBadGateway || !strings.Contains(e.Error, "connection refused") {
+		t.Errorf("expected a 502 with the dial error, got %+v", e)
+	}
+}
+
+// BenchmarkReverseProxy compares building a proxy and its transport for
+// every request, which dials the backend each time, with a cached proxy
+// over the shared transport, which reuses its connections. conns/op is the
+// number of new backend connections per request. Run with
+// go test -bench ReverseProxy -cpu 1,4 ./internal/proxy
+func BenchmarkReverseProxy(b *testing.B) {
+	var conns atomic.Int64
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "body { color: red }")
+	}))
+	backend.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	backend.Start()
+	defer backend.Close()
+	addr := fmt.Sprintf("127.0.0.1:%d", portFromURL(b, backend.URL))
+
+	var cache Cache
+	cases := []struct {
+		name  string
+		proxy func() (*ReverseProxy, func())
+	}{
+		{"new proxy per request", func() (*ReverseProxy, func()) {
+			rp := NewReverseProxy(addr, "dark")
+			t := newTransport()
+			rp.proxy.Transport = t
+			return rp, t.CloseIdleConnections
+		}},
+		{"cached proxy", func() (*ReverseProxy, func()) {
+			return cache.Get("web-shop", addr, "dark", nil), func() {}
+		}},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			conns.Store(0)
+			// Parallel requests, like a browser loading a page's assets
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					w := httptest.NewRecorder()
+					rp, done := tc.proxy()
+					rp.ServeHTTP(w, httptest.NewRequest("GET", "http://shop.test/app.css", nil))
+					done()
+					if w.Code != 200 {
+						b.Errorf("expected 200, got %d", w.Code)
+					}
+				}
+			})
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
+
 // portFromURL extracts the port number from an httptest server URL
-func portFromURL(t *testing.T, rawURL string) int {
+func portFromURL(t testing.TB, rawURL string) int {
 	t.Helper()
 	u, err := url.Parse(rawURL)
 	if err != nil {
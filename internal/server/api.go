@@ -26,24 +26,12 @@ func parseServiceName(name string) (serviceName, appName string, ok bool) {
 	return "", name, false
 }
 
-// getClaudeCommand reads the claude_command from config.json fresh each time,
-// allowing changes without restarting the server.
+// getClaudeCommand returns the configured Claude command. It follows
+// config.json edits via reloadGlobalConfig.
 func (s *Server) getClaudeCommand() string {
-	configPath := filepath.Join(s.cfg.Dir, "config.json")
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return s.cfg.ClaudeCommand // fallback to cached value
-	}
-	var cfg struct {
-		ClaudeCommand string `json:"claude_command"`
-	}
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return s.cfg.ClaudeCommand
-	}
-	if cfg.ClaudeCommand == "" {
-		return s.cfg.ClaudeCommand
-	}
-	return cfg.ClaudeCommand
+	s.globalMu.RLock()
+	defer s.globalMu.RUnlock()
+	return s.cfg.ClaudeCommand
 }
 
 // handleDashboard serves the web UI and API endpoints
@@ -61,7 +49,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 
 	switch r.URL.Path {
 	case "/":
-		ui.ServeIndex(w, r, s.cfg.TLD, s.cfg.URLPort, s.getStatus(), s.getTheme())
+		ui.ServeIndex(w, r, s.tld(), s.cfg.URLPort, s.getStatus(), s.getTheme())
 
 	case "/icons":
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -74,7 +62,13 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		s.handleSSE(w, r)
 
 	case "/api/reload":
-		s.apps.Reload()
+		diff, err := s.apps.Reload()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.logRequest("Config reloaded (v%d): %s", s.apps.Snapshot().Version, diff)
+		s.broadcastStatus()
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 
@@ -87,9 +81,24 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	case "/api/start":
 		s.handleStart(w, r)
 
+	case "/api/pause":
+		s.handlePause(w, r, true)
+
+	case "/api/resume":
+		s.handlePause(w, r, false)
+
+	case "/api/profile":
+		s.handleProfile(w, r)
+
 	case "/api/logs":
 		s.handleLogs(w, r)
 
+	case "/api/requests":
+		s.handleRequests(w, r)
+
+	case "/api/requests/har":
+		s.handleHAR(w, r)
+
 	case "/api/server-logs":
 		// Return fireup's request handling logs
 		w.Header().Set("Content-Type", "application/json")
@@ -149,6 +158,10 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		s.handleConfigPath(w, r)
 
 	default:
+		if id, ok := replayID(r.URL.Path); ok {
+			s.handleReplay(w, r, id)
+			return
+		}
 		http.NotFound(w, r)
 	}
 }
@@ -159,7 +172,8 @@ func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
 	if name != "" {
 		// First try to resolve as a service name (supports app:svc, svc.app, svc, svc-app)
 		if match := s.resolveServiceName(name); match != nil {
-			s.procs.Stop(match.ProcName)
+			s.stopProc(match.ProcName)
+			s.stopAppDependencies([]procRef{{app: match.App, svc: match.Service}})
 			s.broadcastStatus()
 			w.WriteHeader(http.StatusOK)
 			return
@@ -170,19 +184,74 @@ func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
 		}
 		// Try direct process name first
 		if _, found := s.procs.Get(name); found {
-			s.procs.Stop(name)
+			s.stopProc(name)
+			if app, found := s.apps.Get(name); found && app.Type == config.AppTypeCommand {
+				s.stopAppDependencies([]procRef{{app: app}})
+			}
 		} else if app, found := s.apps.Get(name); found && app.Type == config.AppTypeYAML {
 			// Stop all services for multi-service app
 			for _, svc := range app.Services {
 				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
-				s.procs.Stop(procName)
+				s.stopProc(procName)
 			}
+			s.stopAppDependencies(appProcs(app))
 		}
 		s.broadcastStatus()
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// handlePause pauses the running processes of an app or service, or resumes
+// them if pause is false. Requests to a paused app resume it too.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request, pause bool) {
+	name := r.URL.Query().Get("name")
+	var procs []procRef
+	if match := s.resolveServiceName(name); match != nil {
+		procs = []procRef{{app: match.App, svc: match.Service}}
+	} else if app, found := s.apps.GetByNameOrAlias(name); found {
+		procs = appProcs(app)
+	}
+	if len(procs) == 0 {
+		http.Error(w, fmt.Sprintf("no app or service with processes: %s", name), http.StatusNotFound)
+		return
+	}
+
+	var errs []string
+	active := 0
+	for _, p := range procs {
+		for _, proc := range s.procs.Instances(p.name()) {
+			if !proc.IsRunning() && !proc.IsStarting() {
+				continue // Nothing to pause in idle services
+			}
+			active++
+			var err error
+			if pause {
+				err = proc.Pause()
+			} else {
+				err = proc.Resume()
+			}
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if pause {
+				s.logRequest("Paused %s", proc.Name)
+			} else {
+				s.logRequest("Resumed %s", proc.Name)
+			}
+		}
+	}
+	if active == 0 {
+		errs = append(errs, fmt.Sprintf("%s is not running", name))
+	}
+	s.broadcastStatus()
+	if len(errs) > 0 {
+		http.Error(w, strings.Join(errs, "; "), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // handleRestart restarts an app or service
 func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
@@ -191,9 +260,7 @@ func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 		// First try to resolve as a service name (supports app:svc, svc.app, svc, svc-app)
 		if match := s.resolveServiceName(name); match != nil {
 			s.logRequest("  Restarting service: %s", match.ProcName)
-			s.procs.Stop(match.ProcName)
-			s.ensureDependencies(match.App, match.Service)
-			s.procs.StartAsync(match.ProcName, match.Service.Command, match.Service.Dir, match.Service.Env)
+			s.restartProc(procRef{app: match.App, svc: match.Service})
 			s.broadcastStatus()
 			w.WriteHeader(http.StatusOK)
 			return
@@ -205,16 +272,24 @@ func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 		// Try direct process name first
 		if proc, found := s.procs.Get(name); found {
 			s.logRequest("  Restarting process: %s", proc.Name)
-			// Stop then start fresh to pick up any config changes
-			s.procs.Stop(proc.Name)
-			s.startByName(name)
+			// Start fresh to pick up any config changes
+			if app, found := s.apps.Get(name); found && app.Type == config.AppTypeCommand {
+				s.restartProc(procRef{app: app})
+			} else {
+				s.stopProc(proc.Name)
+				s.startByName(name)
+			}
 		} else if app, found := s.apps.Get(name); found && app.Type == config.AppTypeYAML {
 			// Restart all services for multi-service app
-			// Stop ALL existing processes first (including those still starting/hung)
+			// Stop ALL existing processes first (including those still
+			// starting/hung), except running ones that overlap restarts
 			for i := range app.Services {
 				svc := &app.Services[i]
 				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
 				if proc, found := s.procs.Get(procName); found {
+					if svc.RestartStrategy == config.RestartOverlap && proc.IsRunning() {
+						continue
+					}
 					status := "idle"
 					if proc.IsRunning() {
 						status = "running"
@@ -224,15 +299,12 @@ func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 						status = "failed"
 					}
 					s.logRequest("  Stopping %s (was %s)", procName, status)
-					s.procs.Stop(procName)
+					s.stopProc(procName)
 				}
 			}
 			// Now start all services fresh with current config
 			for i := range app.Services {
-				svc := &app.Services[i]
-				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
-				s.ensureDependencies(app, svc)
-				s.procs.StartAsync(procName, svc.Command, svc.Dir, svc.Env)
+				s.restartProc(procRef{app: app, svc: &app.Services[i]})
 			}
 		} else {
 			// Try to start it fresh
@@ -246,11 +318,28 @@ func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 // handleStart starts an app or service
 func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
+	if profile := r.URL.Query().Get("profile"); name != "" && profile != "" {
+		// Switch profile before starting (profiles are per app, so resolve the owning app)
+		var app *config.App
+		if match := s.resolveServiceName(name); match != nil {
+			app = match.App
+		} else if a, found := s.apps.GetByNameOrAlias(name); found {
+			app = a
+		}
+		if app == nil {
+			http.Error(w, fmt.Sprintf("app not found: %s", name), http.StatusNotFound)
+			return
+		}
+		if _, _, err := s.switchProfile(app, profile); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 	if name != "" {
 		// First try to resolve as a service name (supports app:svc, svc.app, svc, svc-app)
 		if match := s.resolveServiceName(name); match != nil {
 			s.ensureDependencies(match.App, match.Service)
-			s.procs.StartAsync(match.ProcName, match.Service.Command, match.Service.Dir, match.Service.Env)
+			s.startService(match.App, match.Service)
 			s.broadcastStatus()
 			w.WriteHeader(http.StatusOK)
 			return
@@ -277,14 +366,25 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 	// Try direct process name first
 	if proc, found := s.procs.Get(name); found {
 		allLogs = proc.Logs().Lines()
+	} else if instances := s.procs.Instances(name); len(instances) > 0 {
+		// For scaled services, aggregate logs from all instances
+		for _, proc := range instances {
+			for _, line := range proc.Logs().Lines() {
+				allLogs = append(allLogs, fmt.Sprintf("[#%s] %s", instanceSuffix(proc.Name), line))
+			}
+		}
 	} else {
 		// For multi-service apps, aggregate logs from all services
 		if app, found := s.apps.Get(name); found && app.Type == config.AppTypeYAML {
 			for _, svc := range app.Services {
 				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
-				if proc, found := s.procs.Get(procName); found {
+				for _, proc := range s.procs.Instances(procName) {
+					label := svc.Name
+					if proc.Name != procName {
+						label += "#" + instanceSuffix(proc.Name)
+					}
 					for _, line := range proc.Logs().Lines() {
-						allLogs = append(allLogs, fmt.Sprintf("[%s] %s", svc.Name, line))
+						allLogs = append(allLogs, fmt.Sprintf("[%s] %s", label, line))
 					}
 				}
 			}
@@ -308,7 +408,7 @@ func (s *Server) handleAppStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status := singleAppStatus{Status: "idle"}
-	if proc, found := s.procs.Get(name); found {
+	if proc, found := s.getProc(name); found {
 		if proc.IsStarting() {
 			status.Status = "starting"
 		} else if proc.IsRunning() {
@@ -330,7 +430,7 @@ func (s *Server) handleAppStatus(w http.ResponseWriter, r *http.Request) {
 						continue
 					}
 					depProcName := fmt.Sprintf("%s-%s", depName, app.Name)
-					depProc, found := s.procs.Get(depProcName)
+					depProc, found := s.getProc(depProcName)
 					if !found {
 						// Dependency not started yet - report starting
 						status.Status = "starting"
@@ -358,7 +458,8 @@ func (s *Server) handleAppStatus(w http.ResponseWriter, r *http.Request) {
 
 // handleAnalyzeLogs uses Ollama to identify error lines in logs
 func (s *Server) handleAnalyzeLogs(w http.ResponseWriter, r *http.Request) {
-	if s.ollamaClient == nil {
+	ollamaClient := s.getOllamaClient()
+	if ollamaClient == nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{"enabled": false})
 		return
@@ -370,7 +471,7 @@ func (s *Server) handleAnalyzeLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var logs []string
-	if proc, found := s.procs.Get(name); found {
+	if proc, found := s.getProc(name); found {
 		logs = proc.Logs().Lines()
 	}
 
@@ -381,7 +482,7 @@ func (s *Server) handleAnalyzeLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Run analysis async-ish but return result
-	errorLines, err := s.ollamaClient.AnalyzeLogs(context.Background(), logs)
+	errorLines, err := ollamaClient.AnalyzeLogs(context.Background(), logs)
 	if err != nil {
 		s.logRequest("Ollama analysis error: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -411,7 +512,7 @@ func (s *Server) handleOpenTerminal(w http.ResponseWriter, r *http.Request) {
 	var logs []string
 
 	// Try direct process name first (for services like "web-myapp")
-	if proc, found := s.procs.Get(name); found {
+	if proc, found := s.getProc(name); found {
 		dir = proc.Dir
 		logs = proc.Logs().Lines()
 	}
@@ -531,22 +632,26 @@ func (s *Server) getConfigPath(name string) string {
 		}
 	}
 
-	// Resolve alias to app name
+	// Resolve alias to app name, and find its group directory
+	dir := s.cfg.Dir
 	if app, found := s.apps.GetByNameOrAlias(appName); found {
 		appName = app.Name
+		if app.Group != "" {
+			dir = filepath.Join(dir, app.Group)
+		}
 	}
 
 	// Check for .yml first, then .yaml
-	ymlPath := fmt.Sprintf("%s/%s.yml", s.cfg.Dir, appName)
+	ymlPath := fmt.Sprintf("%s/%s.yml", dir, appName)
 	if _, err := os.Stat(ymlPath); err == nil {
 		return ymlPath
 	}
-	yamlPath := fmt.Sprintf("%s/%s.yaml", s.cfg.Dir, appName)
+	yamlPath := fmt.Sprintf("%s/%s.yaml", dir, appName)
 	if _, err := os.Stat(yamlPath); err == nil {
 		return yamlPath
 	}
 	// Check for plain file (no extension)
-	plainPath := fmt.Sprintf("%s/%s", s.cfg.Dir, appName)
+	plainPath := fmt.Sprintf("%s/%s", dir, appName)
 	if _, err := os.Stat(plainPath); err == nil {
 		return plainPath
 	}
@@ -590,5 +695,5 @@ func (s *Server) handleOpenConfig(w http.ResponseWriter, r *http.Request) {
 // handleWelcome serves the built-in welcome/test page at fireup-test.<tld>
 func (s *Server) handleWelcome(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(pages.Welcome(s.cfg.TLD, s.cfg.Dir, s.getTheme())))
+	w.Write([]byte(pages.Welcome(s.tld(), s.cfg.Dir, s.getTheme())))
 }
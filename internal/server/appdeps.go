@@ -0,0 +1,242 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+// procRef is a process fireup can start: a command app, or a service of a
+// multi-service app
+type procRef struct {
+	app *config.App
+	svc *config.Service // nil for command apps
+}
+
+// name returns the process name
+func (p procRef) name() string {
+	if p.svc == nil {
+		return p.app.Name
+	}
+	return fmt.Sprintf("%s-%s", slugify(p.svc.Name), p.app.Name)
+}
+
+// appDeps returns the app: references of the process and its app
+func (p procRef) appDeps() []config.AppDependency {
+	deps := append([]config.AppDependency(nil), p.app.AppDeps...)
+	if p.svc != nil {
+		deps = append(deps, p.svc.AppDeps...)
+	}
+	return deps
+}
+
+// startProc starts the process without waiting for it
+func (s *Server) startProc(p procRef) error {
+	if p.svc == nil {
+		_, err := s.startCommand(p.app)
+		return err
+	}
+	_, err := s.startService(p.app, p.svc)
+	return err
+}
+
+// stopProc stops a process, or every instance of a scaled one, and drops
+// their cached reverse proxies
+func (s *Server) stopProc(name string) {
+	s.procs.Stop(name)
+	s.proxies.Remove(name)
+}
+
+// isActive returns true if the process is running or starting
+func (s *Server) isActive(p procRef) bool {
+	proc, found := s.getProc(p.name())
+	return found && (proc.IsRunning() || proc.IsStarting())
+}
+
+// appProcs returns the processes of an app. Port and static apps have none.
+func appProcs(app *config.App) []procRef {
+	switch app.Type {
+	case config.AppTypeCommand:
+		return []procRef{{app: app}}
+	case config.AppTypeYAML:
+		procs := make([]procRef, len(app.Services))
+		for i := range app.Services {
+			procs[i] = procRef{app: app, svc: &app.Services[i]}
+		}
+		return procs
+	}
+	return nil
+}
+
+// resolveDependency returns the processes a dependency points at
+func (s *Server) resolveDependency(dep config.AppDependency) ([]procRef, error) {
+	app, found := s.apps.GetByNameOrAlias(dep.App)
+	if !found {
+		return nil, fmt.Errorf("unknown app %q", dep.App)
+	}
+	if dep.Service == "" {
+		return appProcs(app), nil
+	}
+	if app.Type == config.AppTypeYAML {
+		for i := range app.Services {
+			if app.Services[i].Name == dep.Service {
+				return []procRef{{app: app, svc: &app.Services[i]}}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("app %q has no service %q", app.Name, dep.Service)
+}
+
+// dependencyProcs returns the processes that must start before p: services
+// it depends on within its app, then the apps and services it references
+// with app:. References that don't resolve are returned as errors.
+func (s *Server) dependencyProcs(p procRef) ([]procRef, []error) {
+	var procs []procRef
+	var errs []error
+	if p.svc != nil {
+		for _, name := range p.svc.DependsOn {
+			if dep := s.findService(p.app, name); dep != nil {
+				procs = append(procs, procRef{app: p.app, svc: dep})
+			}
+		}
+	}
+	for _, dep := range p.appDeps() {
+		targets, err := s.resolveDependency(dep)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dependency %s: %w", dep, err))
+			continue
+		}
+		procs = append(procs, targets...)
+	}
+	return procs, errs
+}
+
+// ensureAppDependencies starts the dependencies of p that aren't already
+// running, dependencies first. Nothing is waited for, like services within
+// an app.
+func (s *Server) ensureAppDependencies(p procRef) {
+	s.startDependencies(p, map[string]bool{p.name(): true})
+}
+
+// startDependencies starts the dependencies of p. seen holds the processes
+// already handled, which also stops dependency cycles.
+func (s *Server) startDependencies(p procRef, seen map[string]bool) {
+	targets, errs := s.dependencyProcs(p)
+	for _, err := range errs {
+		s.logRequest("%s: skipping %v", p.name(), err)
+	}
+	for _, target := range targets {
+		if seen[target.name()] {
+			continue
+		}
+		seen[target.name()] = true
+		s.startDependencies(target, seen)
+		if s.isActive(target) {
+			continue
+		}
+		if target.app.Name != p.app.Name {
+			s.logRequest("Starting %s (needed by %s)", target.name(), p.name())
+		}
+		if err := s.startProc(target); err != nil {
+			s.logRequest("Failed to start %s (needed by %s): %v", target.name(), p.name(), err)
+		}
+	}
+}
+
+// beginRequest marks a request to p as in flight on its process and the
+// processes it depends on, resuming any that are paused. The returned func
+// ends the request.
+func (s *Server) beginRequest(p procRef) func() {
+	var procs []*process.Process
+	seen := map[string]bool{}
+	var visit func(p procRef)
+	visit = func(p procRef) {
+		if seen[p.name()] {
+			return
+		}
+		seen[p.name()] = true
+		// Every instance of a scaled service, which is in use as a whole
+		procs = append(procs, s.procs.Instances(p.name())...)
+		targets, _ := s.dependencyProcs(p)
+		for _, target := range targets {
+			visit(target)
+		}
+	}
+	visit(p)
+
+	for _, proc := range procs {
+		proc.BeginRequest()
+	}
+	return func() {
+		for _, proc := range procs {
+			proc.EndRequest()
+		}
+	}
+}
+
+// holdSharedDependencies records p as a holder of the shared apps it depends
+// on, so they keep running while p does
+func (s *Server) holdSharedDependencies(p procRef) {
+	targets, _ := s.dependencyProcs(p)
+	for _, target := range targets {
+		if !target.app.Shared || target.app.Name == p.app.Name {
+			continue
+		}
+		grace := target.app.SharedGrace
+		if grace == 0 {
+			grace = process.DefaultSharedGrace
+		}
+		s.procs.Acquire(target.name(), p.name(), grace)
+	}
+}
+
+// stopAppDependencies stops the app: dependencies of stopped processes of
+// an app with stop_dependencies set, unless another running process still
+// needs them. Dependencies of the stopped dependencies follow if their app
+// has stop_dependencies set too.
+func (s *Server) stopAppDependencies(stopped []procRef) {
+	for _, p := range stopped {
+		if !p.app.StopDependencies {
+			continue
+		}
+		for _, dep := range p.appDeps() {
+			targets, err := s.resolveDependency(dep)
+			if err != nil {
+				continue
+			}
+			var unused []procRef
+			for _, target := range targets {
+				if !s.isActive(target) || target.app.Shared {
+					continue // Shared apps stop on their own once unused
+				}
+				if dependent, needed := s.neededBy(target); needed {
+					s.logRequest("Keeping %s running (needed by %s)", target.name(), dependent)
+					continue
+				}
+				s.logRequest("Stopping %s (no longer needed by %s)", target.name(), p.name())
+				s.stopProc(target.name())
+				unused = append(unused, target)
+			}
+			s.stopAppDependencies(unused)
+		}
+	}
+}
+
+// neededBy returns the name of a running process that depends on target
+func (s *Server) neededBy(target procRef) (string, bool) {
+	for _, app := range s.apps.All() {
+		for _, p := range appProcs(app) {
+			if p.name() == target.name() || !s.isActive(p) {
+				continue
+			}
+			deps, _ := s.dependencyProcs(p)
+			for _, dep := range deps {
+				if dep.name() == target.name() {
+					return p.name(), true
+				}
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestAppDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+	write("storefront.yml", `
+root: /tmp
+cmd: sleep 999
+depends_on: [app:auth, app:payments:api]
+stop_dependencies: true
+`)
+	write("admin.yml", `
+root: /tmp
+cmd: sleep 999
+depends_on: [app:auth]
+`)
+	// auth depends back on storefront to check that cycles are broken
+	write("auth.yml", `
+root: /tmp
+cmd: sleep 999
+depends_on: [app:storefront]
+`)
+	write("payments.yml", `
+root: /tmp
+services:
+  db:
+    cmd: sleep 999
+  api:
+    cmd: sleep 999
+    depends_on: [db]
+  web:
+    cmd: sleep 999
+`)
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(20)
+
+	active := func(name string) bool {
+		proc, found := procs.Get(name)
+		return found && (proc.IsRunning() || proc.IsStarting())
+	}
+
+	t.Run("starts dependencies first", func(t *testing.T) {
+		s.startByName("storefront")
+		for _, name := range []string{"storefront", "auth", "api-payments", "db-payments"} {
+			if !active(name) {
+				t.Errorf("expected %s to be started", name)
+			}
+		}
+		if active("web-payments") {
+			t.Error("expected web-payments not to be started")
+		}
+	})
+
+	t.Run("keeps dependencies other apps need", func(t *testing.T) {
+		s.startByName("admin")
+		s.handleStop(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/stop?name=storefront", nil))
+
+		if active("storefront") || active("api-payments") {
+			t.Error("expected storefront and api-payments to be stopped")
+		}
+		if !active("auth") {
+			t.Error("expected auth to keep running for admin")
+		}
+		if !active("db-payments") {
+			t.Error("expected db-payments to keep running (not an app dependency)")
+		}
+	})
+
+	t.Run("leaves dependencies running without stop_dependencies", func(t *testing.T) {
+		s.handleStop(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/stop?name=admin", nil))
+		if !active("auth") {
+			t.Error("expected auth to keep running")
+		}
+	})
+
+	t.Run("stops the last dependency", func(t *testing.T) {
+		s.startByName("storefront")
+		s.handleStop(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/stop?name=storefront", nil))
+		if active("auth") {
+			t.Error("expected auth to be stopped with its last dependent")
+		}
+	})
+
+	t.Run("starts new dependencies on reload without restarting", func(t *testing.T) {
+		s.startByName("admin")
+		before, _ := procs.Get("admin")
+		write("admin.yml", `
+root: /tmp
+cmd: sleep 999
+depends_on: [app:auth, app:payments:api]
+`)
+		diff, err := apps.Reload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.applyAppChange(diff.Changed[0])
+
+		if !active("api-payments") {
+			t.Error("expected the new dependency to be started")
+		}
+		if after, _ := procs.Get("admin"); after != before {
+			t.Error("expected admin to keep running")
+		}
+	})
+}
+
+func TestSharedApps(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+	write("redis.yml", `
+root: /tmp
+cmd: sleep 999
+shared: true
+shared_grace: 500ms
+`)
+	write("shop.yml", `
+root: /tmp
+cmd: sleep 999
+depends_on: [app:redis]
+`)
+	write("blog.yml", `
+root: /tmp
+services:
+  web:
+    cmd: sleep 999
+    depends_on: [app:redis]
+  worker:
+    cmd: sleep 999
+`)
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(20)
+
+	heldBy := func() []string {
+		var status []appStatus
+		json.Unmarshal(s.getStatus(), &status)
+		for _, app := range status {
+			if app.Name == "redis" {
+				if !app.Shared {
+					t.Error("expected redis to be reported as shared")
+				}
+				return app.HeldBy
+			}
+		}
+		t.Fatal("redis missing from status")
+		return nil
+	}
+	active := func(name string) bool {
+		proc, found := procs.Get(name)
+		return found && (proc.IsRunning() || proc.IsStarting())
+	}
+
+	s.startByName("shop")
+	s.startByName("blog")
+	if !active("redis") {
+		t.Fatal("expected redis to be started by its dependents")
+	}
+	if got := heldBy(); !reflect.DeepEqual(got, []string{"shop", "web-blog"}) {
+		t.Errorf("expected shop and web-blog to hold redis, got %v", got)
+	}
+
+	s.handleStop(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/stop?name=shop", nil))
+	time.Sleep(800 * time.Millisecond)
+	if !active("redis") {
+		t.Error("expected redis to keep running for blog")
+	}
+
+	s.handleStop(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/stop?name=blog", nil))
+	if !active("redis") {
+		t.Error("expected redis to keep running during the grace period")
+	}
+	time.Sleep(800 * time.Millisecond)
+	if active("redis") {
+		t.Error("expected redis to stop after its last dependent")
+	}
+	if got := heldBy(); got != nil {
+		t.Errorf("expected no holders, got %v", got)
+	}
+}
+
+func TestPauseApps(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	tmpDir := t.TempDir()
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+	write("shop.yml", `
+root: /tmp
+cmd: python3 -m http.server $PORT --bind 127.0.0.1
+depends_on: [app:search]
+`)
+	write("search.yml", `
+root: /tmp
+cmd: python3 -m http.server $PORT --bind 127.0.0.1
+`)
+	write("mail.yml", "root: /tmp\ncmd: sleep 999\n")
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(20)
+
+	s.startByName("shop")
+	for _, name := range []string{"shop", "search"} {
+		proc, _ := procs.Get(name)
+		deadline := time.Now().Add(90 * time.Second)
+		for proc.IsStarting() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !proc.IsRunning() {
+			t.Fatalf("expected %s to be running: %s", name, proc.ExitError())
+		}
+	}
+	shop, _ := procs.Get("shop")
+	search, _ := procs.Get("search")
+	call := func(action, name string) int {
+		w := httptest.NewRecorder()
+		s.handleDashboard(w, httptest.NewRequest("GET", "/api/"+action+"?name="+name, nil))
+		return w.Code
+	}
+
+	t.Run("pauses and reports paused apps", func(t *testing.T) {
+		if code := call("pause", "shop"); code != 200 {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		if code := call("pause", "search"); code != 200 {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		var status []appStatus
+		json.Unmarshal(s.getStatus(), &status)
+		for _, as := range status {
+			if (as.Name == "shop" || as.Name == "search") && (!as.Paused || !as.Running) {
+				t.Errorf("expected %s to be running and paused, got %+v", as.Name, as)
+			}
+		}
+	})
+
+	t.Run("requests resume the app and its dependencies", func(t *testing.T) {
+		app, _ := apps.Get("shop")
+		end := s.beginRequest(procRef{app: app})
+		if shop.IsPaused() || search.IsPaused() {
+			t.Errorf("expected shop and search to be resumed, got paused %v and %v", shop.IsPaused(), search.IsPaused())
+		}
+		end()
+	})
+
+	t.Run("rejects apps that aren't running", func(t *testing.T) {
+		if code := call("pause", "mail"); code != 409 {
+			t.Errorf("expected 409 for an idle app, got %d", code)
+		}
+		if code := call("resume", "nope"); code != 404 {
+			t.Errorf("expected 404 for an unknown app, got %d", code)
+		}
+	})
+}
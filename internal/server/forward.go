@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/backend"
+	"github.com/panozzaj/fireup/internal/process"
+	"github.com/panozzaj/fireup/internal/proxy"
+)
+
+// forward is a stable local port relayed to a service (tcp: listen)
+type forward struct {
+	port     int
+	listener net.Listener
+
+	mu       sync.Mutex
+	app, svc string // Names of the service relayed to, updated on reloads
+}
+
+// String names the port in the logs, e.g. "tcp:5433"
+func (f *forward) String() string {
+	return fmt.Sprintf("tcp:%d", f.port)
+}
+
+// target returns the app and service names the port is relayed to
+func (f *forward) target() (string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.app, f.svc
+}
+
+// setTarget points the port at another app and service
+func (f *forward) setTarget(app, svc string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.app, f.svc = app, svc
+}
+
+// syncForwards opens the tcp: ports of the loaded apps and closes those no
+// longer configured. Ports that stay open follow their service to its new
+// config.
+func (s *Server) syncForwards() {
+	type target struct{ app, svc string }
+	want := make(map[int]target)
+	for _, app := range s.apps.All() {
+		for _, svc := range app.Services {
+			if svc.TCPListen == 0 {
+				continue
+			}
+			if other, taken := want[svc.TCPListen]; taken {
+				msg := fmt.Sprintf("tcp port %d of %s is already forwarded to %s:%s", svc.TCPListen, app.Name, other.app, other.svc)
+				s.logRequest("%s", msg)
+				s.broadcastNotice("error", msg, "")
+				continue
+			}
+			want[svc.TCPListen] = target{app.Name, svc.Name}
+		}
+	}
+
+	s.forwardsMu.Lock()
+	defer s.forwardsMu.Unlock()
+	for port, f := range s.forwards {
+		if _, ok := want[port]; !ok {
+			f.listener.Close()
+			delete(s.forwards, port)
+			s.logRequest("Stopped forwarding %s", f)
+		}
+	}
+	ports := make([]int, 0, len(want))
+	for port := range want {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	for _, port := range ports {
+		t := want[port]
+		if f, ok := s.forwards[port]; ok {
+			f.setTarget(t.app, t.svc)
+			continue
+		}
+		f := &forward{port: port, app: t.app, svc: t.svc}
+		if err := s.openForward(f); err != nil {
+			msg := fmt.Sprintf("Can't forward %s to %s:%s: %v", f, t.app, t.svc, err)
+			s.logRequest("%s", msg)
+			s.broadcastNotice("error", msg, "")
+			continue
+		}
+		if s.forwards == nil {
+			s.forwards = make(map[int]*forward)
+		}
+		s.forwards[port] = f
+		s.logRequest("Forwarding %s to %s:%s", f, t.app, t.svc)
+	}
+}
+
+// openForward listens on the local port of f and relays its connections
+func (s *Server) openForward(f *forward) error {
+	ln, err := net.Listen("tcp", net.JoinHostPort(backend.IPv4, strconv.Itoa(f.port)))
+	if err != nil {
+		return err
+	}
+	f.listener = ln
+	go s.serveTCP(f, ln)
+	return nil
+}
+
+// closeForwards closes every tcp: port
+func (s *Server) closeForwards() {
+	s.forwardsMu.Lock()
+	defer s.forwardsMu.Unlock()
+	for port, f := range s.forwards {
+		f.listener.Close()
+		delete(s.forwards, port)
+	}
+}
+
+// forwardTarget returns the service a port is relayed to
+func (s *Server) forwardTarget(f *forward) (procRef, error) {
+	appName, svcName := f.target()
+	app, found := s.apps.Get(appName)
+	if !found {
+		return procRef{}, fmt.Errorf("unknown app %q", appName)
+	}
+	svc := s.findService(app, svcName)
+	if svc == nil {
+		return procRef{}, fmt.Errorf("app %q has no service %q", appName, svcName)
+	}
+	return procRef{app: app, svc: svc}, nil
+}
+
+// serveTCP relays each connection to a tcp: port until the port is closed
+func (s *Server) serveTCP(f *forward, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			s.logRequest("%s: %v", f, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go s.relayTCP(f, conn)
+	}
+}
+
+// relayTCP relays a connection to its service, starting the service first
+// if it's idle. The connection is closed if the service can't start.
+func (s *Server) relayTCP(f *forward, conn net.Conn) {
+	defer conn.Close()
+	p, proc, ok := s.forwardProc(f)
+	if !ok {
+		return
+	}
+	defer s.beginRequest(p)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	backendConn, err := backend.Dial(ctx, "tcp", proc.Addr())
+	cancel()
+	if err != nil {
+		s.logRequest("%s -> %s: %v", f, p.name(), err)
+		return
+	}
+	defer backendConn.Close()
+	s.logRequest("%s -> RELAY to %s (%s)", f, p.name(), proc.Addr())
+	proxy.Relay(conn, backendConn)
+}
+
+// forwardProc returns the service a port is relayed to once it's running,
+// starting it if it's idle. Errors are logged.
+func (s *Server) forwardProc(f *forward) (procRef, *process.Process, bool) {
+	p, err := s.forwardTarget(f)
+	if err != nil {
+		s.logRequest("%s: %v", f, err)
+		return procRef{}, nil, false
+	}
+	proc, err := s.startAndWait(context.Background(), p)
+	if err != nil {
+		s.logRequest("%s -> %s: %v", f, p.name(), err)
+		return procRef{}, nil, false
+	}
+	return p, proc, true
+}
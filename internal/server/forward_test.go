@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+// freePort returns a TCP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestForward(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	tmpDir := t.TempDir()
+	tcpPort := freePort(t)
+	writeConfig := func(tcpPort int) {
+		os.WriteFile(filepath.Join(tmpDir, "db.yml"), []byte(fmt.Sprintf(`
+root: %s
+services:
+  web:
+    cmd: python3 -m http.server $PORT --bind 127.0.0.1
+    tcp:
+      listen: %d
+`, tmpDir, tcpPort)), 0644)
+	}
+	writeConfig(tcpPort)
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(100)
+	s.syncForwards()
+	defer s.closeForwards()
+
+	// get sends an HTTP request over a raw TCP connection to port
+	get := func(port int) (string, error) {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(40 * time.Second))
+		fmt.Fprint(conn, "GET / HTTP/1.0\r\n\r\n")
+		return bufio.NewReader(conn).ReadString('\n')
+	}
+
+	t.Run("tcp starts the service on the first connection", func(t *testing.T) {
+		if proc, found := procs.Get("web-db"); found && proc.IsRunning() {
+			t.Fatal("expected web to be idle")
+		}
+		status, err := get(tcpPort)
+		if err != nil || !strings.Contains(status, "200") {
+			t.Fatalf("expected a 200 relayed from web, got %q, %v", status, err)
+		}
+		proc, _ := procs.Get("web-db")
+		if proc.ListenPort() == tcpPort {
+			t.Error("expected web to run on its own port")
+		}
+	})
+
+	t.Run("a new listen port replaces the old one on reload", func(t *testing.T) {
+		newPort := freePort(t)
+		writeConfig(newPort)
+		if _, err := apps.Reload(); err != nil {
+			t.Fatal(err)
+		}
+		s.syncForwards()
+
+		if _, err := get(tcpPort); err == nil {
+			t.Errorf("expected port %d to be closed", tcpPort)
+		}
+		if status, err := get(newPort); err != nil || !strings.Contains(status, "200") {
+			t.Errorf("expected a 200 on port %d, got %q, %v", newPort, status, err)
+		}
+	})
+
+	t.Run("a port that's taken is reported", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		writeConfig(ln.Addr().(*net.TCPAddr).Port)
+		apps.Reload()
+		s.syncForwards()
+
+		if logs := strings.Join(s.requestLog.Lines(), "\n"); !strings.Contains(logs, "Can't forward tcp:") {
+			t.Errorf("expected an error in the logs, got %s", logs)
+		}
+	})
+}
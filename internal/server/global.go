@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/dns"
+	"github.com/panozzaj/fireup/internal/ollama"
+)
+
+// tld returns the current top-level domain
+func (s *Server) tld() string {
+	s.globalMu.RLock()
+	defer s.globalMu.RUnlock()
+	return s.cfg.TLD
+}
+
+// getOllamaClient returns the LLM client, or nil if Ollama is disabled
+func (s *Server) getOllamaClient() *ollama.Client {
+	s.globalMu.RLock()
+	defer s.globalMu.RUnlock()
+	return s.ollamaClient
+}
+
+// startDNS starts the built-in DNS server for tld, stopping any previous one.
+// Nothing is started for "localhost", which resolves without help.
+func (s *Server) startDNS(tld string) {
+	s.globalMu.Lock()
+	old := s.dnsServer
+	s.dnsServer = nil
+	if s.cfg.DNSPort > 0 && tld != "localhost" {
+		s.dnsServer = dns.New(s.cfg.DNSPort, tld)
+	}
+	srv := s.dnsServer
+	s.globalMu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+	if srv == nil {
+		return
+	}
+	go func() {
+		if err := srv.Start(); err != nil {
+			s.logRequest("DNS server error: %v", err)
+		}
+	}()
+	fmt.Printf("DNS server on 127.0.0.1:%d for *.%s\n", s.cfg.DNSPort, tld)
+}
+
+// reloadGlobalConfig rereads config.json and applies it. Invalid edits are
+// rejected and the last good settings are kept.
+func (s *Server) reloadGlobalConfig() {
+	settings, err := config.ReadGlobalSettings(s.cfg.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("%s was removed", config.GlobalFile)
+		}
+		s.logRequest("Rejected %s change: %v (keeping current settings)", config.GlobalFile, err)
+		s.broadcastNotice("error", fmt.Sprintf("%s not applied: %v. Keeping the previous settings.", config.GlobalFile, err), "")
+		return
+	}
+	s.applyGlobalSettings(settings)
+}
+
+// applyGlobalSettings switches the server to new global settings, updating
+// routing, DNS, certificates and the Ollama client as needed
+func (s *Server) applyGlobalSettings(settings *config.GlobalSettings) {
+	var changes []string
+	dashboardURL := ""
+
+	s.globalMu.Lock()
+	oldTLD := s.cfg.TLD
+	newTLD := settings.TLD
+	if s.cfg.FixedTLD && newTLD != oldTLD {
+		s.logRequest("Ignoring tld %q from %s (set by --tld)", newTLD, config.GlobalFile)
+		newTLD = oldTLD
+	}
+	tldChanged := newTLD != oldTLD
+	if tldChanged {
+		s.cfg.TLD = newTLD
+		changes = append(changes, fmt.Sprintf("TLD is now .%s", newTLD))
+	}
+
+	if !sameOllamaConfig(s.cfg.Ollama, settings.Ollama) {
+		s.cfg.Ollama = settings.Ollama
+		if settings.Ollama != nil {
+			s.ollamaClient = ollama.New(settings.Ollama.URL, settings.Ollama.Model)
+			changes = append(changes, fmt.Sprintf("Ollama enabled (model: %s)", settings.Ollama.Model))
+		} else {
+			s.ollamaClient = nil
+			changes = append(changes, "Ollama disabled")
+		}
+	}
+
+	if s.cfg.ClaudeCommand != settings.ClaudeCommand {
+		s.cfg.ClaudeCommand = settings.ClaudeCommand
+		changes = append(changes, fmt.Sprintf("Claude command is now %q", settings.ClaudeCommand))
+	}
+	certManager := s.certManager
+	s.globalMu.Unlock()
+
+	if len(changes) == 0 {
+		return
+	}
+
+	if tldChanged {
+		s.apps.SetTLD(newTLD)
+		if _, err := s.apps.Reload(); err != nil {
+			s.logRequest("Config reload error: %v", err)
+		}
+		s.startDNS(newTLD)
+		if certManager != nil {
+			certManager.SetTLD(newTLD)
+		}
+		dashboardURL = s.dashboardURL()
+	}
+
+	message := strings.Join(changes, "; ")
+	s.logRequest("Applied %s: %s", config.GlobalFile, message)
+	s.broadcastNotice("info", "Settings updated: "+message, dashboardURL)
+	s.broadcastStatus()
+}
+
+// sameOllamaConfig returns true if a and b describe the same Ollama setup.
+// A disabled config is the same as none: the startup config may have one
+// while reloaded settings have nil.
+func sameOllamaConfig(a, b *config.OllamaConfig) bool {
+	if a != nil && !a.Enabled {
+		a = nil
+	}
+	if b != nil && !b.Enabled {
+		b = nil
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// dashboardURL returns the dashboard URL for the current TLD
+func (s *Server) dashboardURL() string {
+	if s.cfg.URLPort == 0 || s.cfg.URLPort == 80 {
+		return fmt.Sprintf("http://fireup.%s", s.tld())
+	}
+	return fmt.Sprintf("http://fireup.%s:%d", s.tld(), s.cfg.URLPort)
+}
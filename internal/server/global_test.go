@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestGlobalConfigReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "myapp"), []byte("3000"), 0644)
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir, URLPort: 80, ClaudeCommand: "claude"}
+	storeCfg := *cfg
+	apps := config.NewAppStore(&storeCfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(cfg, apps, process.NewManager())
+	s.requestLog = process.NewLogBuffer(10)
+
+	writeGlobal := func(content string) {
+		os.WriteFile(filepath.Join(tmpDir, config.GlobalFile), []byte(content), 0644)
+		s.reloadGlobalConfig()
+	}
+	statusURL := func() string {
+		var status []appStatus
+		if err := json.Unmarshal(s.getStatus(), &status); err != nil {
+			t.Fatal(err)
+		}
+		return status[0].URL
+	}
+
+	ch := s.broadcaster.Subscribe()
+	defer s.broadcaster.Unsubscribe(ch)
+
+	t.Run("applies TLD, Ollama and Claude command", func(t *testing.T) {
+		writeGlobal(`{"tld": "dev", "claude_command": "claude --continue", "ollama": {"enabled": true, "url": "http://localhost:11434", "model": "llama3"}}`)
+
+		if s.tld() != "dev" {
+			t.Errorf("expected TLD dev, got %q", s.tld())
+		}
+		if url := statusURL(); url != "http://myapp.dev" {
+			t.Errorf("expected app URL to follow the TLD, got %q", url)
+		}
+		if s.getOllamaClient() == nil {
+			t.Error("expected Ollama client to be created")
+		}
+		if s.getClaudeCommand() != "claude --continue" {
+			t.Errorf("unexpected Claude command: %q", s.getClaudeCommand())
+		}
+
+		notice := <-ch
+		if !strings.Contains(string(notice), `"type":"notice"`) || !strings.Contains(string(notice), "http://fireup.dev") {
+			t.Errorf("expected a notice with the new dashboard URL, got %s", notice)
+		}
+	})
+
+	t.Run("invalid edit keeps previous settings", func(t *testing.T) {
+		for len(ch) > 0 {
+			<-ch
+		}
+		writeGlobal(`{"tld": "Bad TLD"}`)
+
+		if s.tld() != "dev" || s.getOllamaClient() == nil {
+			t.Errorf("expected previous settings to be kept, got TLD %q", s.tld())
+		}
+		notice := <-ch
+		if !strings.Contains(string(notice), `"level":"error"`) {
+			t.Errorf("expected an error notice, got %s", notice)
+		}
+	})
+
+	t.Run("disabling Ollama clears the client", func(t *testing.T) {
+		writeGlobal(`{"tld": "dev"}`)
+		if s.getOllamaClient() != nil {
+			t.Error("expected Ollama client to be cleared")
+		}
+		if s.getClaudeCommand() != "claude" {
+			t.Errorf("expected default Claude command, got %q", s.getClaudeCommand())
+		}
+	})
+
+	t.Run("a disabled Ollama config isn't reported as a change", func(t *testing.T) {
+		s.cfg.Ollama = &config.OllamaConfig{Enabled: false}
+		for len(ch) > 0 {
+			<-ch
+		}
+		writeGlobal(`{"tld": "dev", "claude_command": "claude --resume"}`)
+		notice := <-ch
+		if strings.Contains(string(notice), "Ollama") {
+			t.Errorf("expected no Ollama change, got %s", notice)
+		}
+	})
+
+	t.Run("--tld takes precedence", func(t *testing.T) {
+		s.cfg.FixedTLD = true
+		writeGlobal(`{"tld": "local"}`)
+		if s.tld() != "dev" {
+			t.Errorf("expected fixed TLD to be kept, got %q", s.tld())
+		}
+	})
+}
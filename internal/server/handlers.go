@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"html"
 	"net/http"
+	"regexp"
 	"strings"
 
+	"github.com/panozzaj/fireup/internal/backend"
 	"github.com/panozzaj/fireup/internal/config"
 	"github.com/panozzaj/fireup/internal/process"
 	"github.com/panozzaj/fireup/internal/proxy"
@@ -22,19 +24,19 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check for dashboard or fireup subdomains (for test services)
-	if host == "fireup."+s.cfg.TLD || host == "fireup" {
+	if host == "fireup."+s.tld() || host == "fireup" {
 		s.handleDashboard(w, r)
 		return
 	}
 
 	// Built-in welcome page at fireup-test.<tld>
-	if host == "fireup-test."+s.cfg.TLD || host == "fireup-test" {
+	if host == "fireup-test."+s.tld() || host == "fireup-test" {
 		s.handleWelcome(w, r)
 		return
 	}
-	if strings.HasSuffix(host, ".fireup."+s.cfg.TLD) {
+	if strings.HasSuffix(host, ".fireup."+s.tld()) {
 		// Subdomain of fireup.test → route to fireup-tests services
-		subdomain := strings.TrimSuffix(host, ".fireup."+s.cfg.TLD)
+		subdomain := strings.TrimSuffix(host, ".fireup."+s.tld())
 		if app, svc, found := s.apps.GetService("fireup-tests", subdomain); found {
 			s.handleService(w, r, app, svc)
 			return
@@ -44,8 +46,8 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, pages.Error(
 			"Service not found",
 			fmt.Sprintf("No service named '%s' in fireup-tests", subdomain),
-			fmt.Sprintf(`<p class="hint">Check available services at <a href="//fireup.%s">fireup.%s</a></p>`, s.cfg.TLD, s.cfg.TLD),
-			s.cfg.TLD, s.getTheme()))
+			fmt.Sprintf(`<p class="hint">Check available services at <a href="//fireup.%s">fireup.%s</a></p>`, s.tld(), s.tld()),
+			s.tld(), s.getTheme()))
 		return
 	}
 
@@ -56,19 +58,19 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !strings.HasSuffix(host, "."+s.cfg.TLD) {
+	if !strings.HasSuffix(host, "."+s.tld()) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusBadRequest)
 		fmt.Fprint(w, pages.Error(
 			"Invalid host",
-			fmt.Sprintf("Expected *.%s, got %s", s.cfg.TLD, host),
+			fmt.Sprintf("Expected *.%s, got %s", s.tld(), host),
 			"",
-			s.cfg.TLD, s.getTheme()))
+			s.tld(), s.getTheme()))
 		return
 	}
 
 	// Remove TLD
-	name := strings.TrimSuffix(host, "."+s.cfg.TLD)
+	name := strings.TrimSuffix(host, "."+s.tld())
 
 	// Check for service-app pattern (service-appname)
 	if idx := strings.Index(name, "-"); idx != -1 {
@@ -87,9 +89,8 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	// Try progressively shorter names to support subdomains
 	// e.g., admin.myapp → try "admin.myapp", then "myapp"
 	app, found := s.findApp(name)
-	if !found {
-		// Reload config and try again
-		s.apps.Reload()
+	if !found && s.apps.ReloadOnMiss() {
+		// A config appeared that the watcher hasn't picked up yet
 		app, found = s.findApp(name)
 	}
 
@@ -100,7 +101,7 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 			"App not found",
 			fmt.Sprintf("No app configured for '%s'", name),
 			fmt.Sprintf(`<p class="hint">Create config at: %s/%s.yml</p>`, html.EscapeString(s.cfg.Dir), html.EscapeString(name)),
-			s.cfg.TLD, s.getTheme()))
+			s.tld(), s.getTheme()))
 		return
 	}
 
@@ -135,49 +136,67 @@ func (s *Server) handleApp(w http.ResponseWriter, r *http.Request, app *config.A
 	switch app.Type {
 	case config.AppTypePort:
 		// Simple proxy to fixed port
-		proxy.NewReverseProxy(app.Port, s.getTheme()).ServeHTTP(w, r)
+		s.proxies.Get(app.Name, backend.Addr(app.Host, app.Port), s.getTheme(), s.captureFor(app)).ServeHTTP(w, r)
 
 	case config.AppTypeCommand:
 		// Check process status and serve appropriately
 		proc, found := s.procs.Get(app.Name)
 		if found && proc.IsRunning() {
-			// Already running - proxy directly
-			proxy.NewReverseProxy(proc.Port, s.getTheme()).ServeHTTP(w, r)
+			// Already running - proxy directly, resuming it if paused
+			s.proxyTo(w, r, procRef{app: app}, proc)
+			return
+		}
+		if !isNavigation(r) {
+			// API clients can't use the interstitial - hold until ready
+			s.holdRequest(w, r, procRef{app: app})
 			return
 		}
 		if found && proc.HasFailed() {
 			// Failed - show interstitial with error
 			w.Header().Set("Content-Type", "text/html")
 			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-			w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.cfg.TLD, s.getTheme(), true, proc.ExitError())))
+			w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.tld(), s.getTheme(), true, proc.ExitError())))
 			return
 		}
 		if found && proc.IsStarting() {
 			// Starting - show interstitial
 			w.Header().Set("Content-Type", "text/html")
 			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-			w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.cfg.TLD, s.getTheme(), false, "")))
+			w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.tld(), s.getTheme(), false, "")))
 			return
 		}
-		// Idle - start async and show interstitial
-		_, err := s.procs.StartAsync(app.Name, app.Command, app.Dir, app.Env)
+		// Idle - start dependencies, then start async and show interstitial
+		s.ensureAppDependencies(procRef{app: app})
+		_, err := s.startCommand(app)
 		if err != nil {
 			// Immediate failure (e.g., directory doesn't exist)
 			w.Header().Set("Content-Type", "text/html")
 			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-			w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.cfg.TLD, s.getTheme(), true, err.Error())))
+			w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.tld(), s.getTheme(), true, err.Error())))
 			return
 		}
 		w.Header().Set("Content-Type", "text/html")
 		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-		w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.cfg.TLD, s.getTheme(), false, "")))
+		w.Write([]byte(pages.Interstitial(app.Name, app.Name, app.Name, s.tld(), s.getTheme(), false, "")))
 
 	case config.AppTypeStatic:
 		// Serve static files
 		proxy.NewStaticHandler(app.FilePath).ServeHTTP(w, r)
 
 	case config.AppTypeYAML:
-		// Multi-service app - use default service, first service, or show list
+		// Multi-service app - use a route, the default service, the only
+		// service, or show list
+		if route := app.RouteFor(r.URL.Path); route != nil {
+			if svc := s.findService(app, route.Service); svc != nil {
+				if route.StripPrefix {
+					r.URL.Path = route.Strip(r.URL.Path)
+					r.URL.RawPath = ""
+				}
+				s.handleService(w, r, app, svc)
+				return
+			}
+		}
+
 		if len(app.Services) == 1 {
 			s.handleService(w, r, app, &app.Services[0])
 			return
@@ -195,7 +214,7 @@ func (s *Server) handleApp(w http.ResponseWriter, r *http.Request, app *config.A
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, "<h1>%s</h1>\n<p>Available services:</p>\n<ul>\n", app.Name)
 		for _, svc := range app.Services {
-			url := fmt.Sprintf("http://%s-%s.%s", slugify(svc.Name), app.Name, s.cfg.TLD)
+			url := fmt.Sprintf("http://%s-%s.%s", slugify(svc.Name), app.Name, s.tld())
 			fmt.Fprintf(w, "<li><a href=\"%s\">%s</a></li>\n", url, svc.Name)
 		}
 		fmt.Fprintf(w, "</ul>\n")
@@ -212,20 +231,125 @@ func (s *Server) findService(app *config.App, name string) *config.Service {
 	return nil
 }
 
-// ensureDependencies starts any dependencies that aren't already running
+// ensureDependencies starts any dependencies that aren't already running,
+// including other apps referenced with app:
 func (s *Server) ensureDependencies(app *config.App, svc *config.Service) {
-	for _, depName := range svc.DependsOn {
-		dep := s.findService(app, depName)
-		if dep == nil {
-			continue // Skip unknown dependencies
+	s.ensureAppDependencies(procRef{app: app, svc: svc})
+}
+
+// startService starts a service of a multi-service app without waiting for it
+func (s *Server) startService(app *config.App, svc *config.Service) (*process.Process, error) {
+	return s.startProcWith(procRef{app: app, svc: svc})
+}
+
+// startCommand starts a command app without waiting for it. Apps with a
+// fixed port fail to start if something else already listens on it.
+func (s *Server) startCommand(app *config.App) (*process.Process, error) {
+	return s.startProcWith(procRef{app: app})
+}
+
+// startProcWith starts a process as launchFor says, holding the shared
+// apps it depends on
+func (s *Server) startProcWith(p procRef) (*process.Process, error) {
+	l, err := s.launchFor(p)
+	if err != nil {
+		return nil, err
+	}
+	var proc *process.Process
+	if p.scaled() {
+		var procs []*process.Process
+		procs, err = s.procs.StartScaled(l.name, l.command, l.dir, l.env, l.opts, p.svc.Scale)
+		if len(procs) > 0 {
+			proc = procs[0]
+		}
+	} else {
+		proc, err = s.procs.StartAsyncWith(l.name, l.command, l.dir, l.env, l.opts)
+	}
+	if err == nil {
+		s.holdSharedDependencies(p)
+	}
+	return proc, err
+}
+
+// launch is what a process is started with
+type launch struct {
+	name    string
+	command string
+	dir     string
+	env     map[string]string
+	opts    process.StartOptions
+}
+
+// launchFor returns how to start a process. ${port:service} references
+// are resolved here from the dependency's assigned port.
+func (s *Server) launchFor(p procRef) (launch, error) {
+	app, svc := p.app, p.svc
+	if svc == nil {
+		return launch{
+			name:    app.Name,
+			command: app.Command,
+			dir:     app.Dir,
+			env:     app.Env,
+			opts: process.StartOptions{
+				Port:        app.Port,
+				PortAuto:    app.PortAuto,
+				PortPattern: portPattern(app.PortPattern),
+				Host:        app.Host,
+				PIDFile:     app.PIDFile,
+				LogFile:     app.LogFile,
+				PauseAfter:  app.PauseAfter,
+				Limits:      app.Limits,
+			},
+		}, nil
+	}
+
+	procName := p.name()
+	portOf := func(name string) (int, bool) {
+		dep, found := s.procs.Get(fmt.Sprintf("%s-%s", slugify(name), app.Name))
+		if !found || dep.HasFailed() {
+			return 0, false
 		}
-		procName := fmt.Sprintf("%s-%s", slugify(dep.Name), app.Name)
-		proc, found := s.procs.Get(procName)
-		if !found || (!proc.IsRunning() && !proc.IsStarting()) {
-			// Start the dependency
-			s.procs.StartAsync(procName, dep.Command, dep.Dir, dep.Env)
+		return dep.ListenPort(), true
+	}
+
+	command, err := config.ExpandPorts(svc.Command, portOf)
+	if err != nil {
+		return launch{}, fmt.Errorf("%s: %w", procName, err)
+	}
+	var env map[string]string
+	if svc.Env != nil {
+		env = make(map[string]string, len(svc.Env))
+		for k, v := range svc.Env {
+			if env[k], err = config.ExpandPorts(v, portOf); err != nil {
+				return launch{}, fmt.Errorf("%s: %w", procName, err)
+			}
 		}
 	}
+
+	return launch{
+		name:    procName,
+		command: command,
+		dir:     svc.Dir,
+		env:     env,
+		opts: process.StartOptions{
+			PortAuto:    svc.PortAuto,
+			PortPattern: portPattern(svc.PortPattern),
+			Host:        svc.Host,
+			PIDFile:     svc.PIDFile,
+			LogFile:     svc.LogFile,
+			PauseAfter:  app.PauseAfter,
+			Limits:      svc.Limits,
+		},
+	}, nil
+}
+
+// portPattern compiles a port_pattern setting, which was validated when the
+// config was loaded. Returns nil if there is none.
+func portPattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	return regexp.MustCompile(pattern)
 }
 
 // handleService handles a request for a service within a multi-service app
@@ -236,7 +360,7 @@ func (s *Server) handleService(w http.ResponseWriter, r *http.Request, app *conf
 	if idx := strings.LastIndex(host, ":"); idx != -1 {
 		host = host[:idx] // Remove port
 	}
-	displayName := strings.TrimSuffix(host, "."+s.cfg.TLD)
+	displayName := strings.TrimSuffix(host, "."+s.tld())
 	configName := app.Name // e.g., "fireup-tests"
 	s.logRequest("handleService: %s (path=%s)", procName, r.URL.Path)
 
@@ -244,7 +368,7 @@ func (s *Server) handleService(w http.ResponseWriter, r *http.Request, app *conf
 	s.ensureDependencies(app, svc)
 
 	// Check process status and serve appropriately
-	proc, found := s.procs.Get(procName)
+	proc, found := s.getProc(procName)
 	s.logRequest("  %s: found=%v, running=%v, starting=%v, failed=%v",
 		procName, found,
 		found && proc.IsRunning(),
@@ -252,9 +376,14 @@ func (s *Server) handleService(w http.ResponseWriter, r *http.Request, app *conf
 		found && proc.HasFailed())
 
 	if found && proc.IsRunning() {
-		// Already running - proxy directly
-		s.logRequest("  -> PROXY to port %d", proc.Port)
-		proxy.NewReverseProxy(proc.Port, s.getTheme()).ServeHTTP(w, r)
+		// Already running - proxy directly, resuming it if paused
+		s.logRequest("  -> PROXY to %s", proc.Addr())
+		s.proxyTo(w, r, procRef{app: app, svc: svc}, proc)
+		return
+	}
+	if !isNavigation(r) {
+		// API clients can't use the interstitial - hold until ready
+		s.holdRequest(w, r, procRef{app: app, svc: svc})
 		return
 	}
 	if found && proc.HasFailed() {
@@ -262,7 +391,7 @@ func (s *Server) handleService(w http.ResponseWriter, r *http.Request, app *conf
 		s.logRequest("  -> INTERSTITIAL (failed)")
 		w.Header().Set("Content-Type", "text/html")
 		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-		w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.cfg.TLD, s.getTheme(), true, proc.ExitError())))
+		w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.tld(), s.getTheme(), true, proc.ExitError())))
 		return
 	}
 	if found && proc.IsStarting() {
@@ -270,34 +399,23 @@ func (s *Server) handleService(w http.ResponseWriter, r *http.Request, app *conf
 		s.logRequest("  -> INTERSTITIAL (starting)")
 		w.Header().Set("Content-Type", "text/html")
 		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-		w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.cfg.TLD, s.getTheme(), false, "")))
+		w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.tld(), s.getTheme(), false, "")))
 		return
 	}
 	// Idle - start async and show interstitial
 	s.logRequest("  -> INTERSTITIAL (idle, starting %s)", procName)
-	_, err := s.procs.StartAsync(procName, svc.Command, svc.Dir, svc.Env)
+	_, err := s.startService(app, svc)
 	if err != nil {
 		// Immediate failure (e.g., directory doesn't exist)
 		s.logRequest("  -> FAILED to start: %v", err)
 		w.Header().Set("Content-Type", "text/html")
 		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-		w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.cfg.TLD, s.getTheme(), true, err.Error())))
+		w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.tld(), s.getTheme(), true, err.Error())))
 		return
 	}
 	w.Header().Set("Content-Type", "text/html")
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-	w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.cfg.TLD, s.getTheme(), false, "")))
-}
-
-// ensureProcess ensures a process is running
-func (s *Server) ensureProcess(name, command, dir string, env map[string]string) (*process.Process, error) {
-	// Check if already running
-	if proc, found := s.procs.Get(name); found && proc.IsRunning() {
-		return proc, nil
-	}
-
-	// Start new process
-	return s.procs.Start(name, command, dir, env)
+	w.Write([]byte(pages.Interstitial(procName, displayName, configName, s.tld(), s.getTheme(), false, "")))
 }
 
 // startByName starts a process by its name (e.g., "myapp" or "web-myapp" for services)
@@ -306,7 +424,8 @@ func (s *Server) startByName(name string) {
 	if app, found := s.apps.Get(name); found {
 		switch app.Type {
 		case config.AppTypeCommand:
-			s.procs.StartAsync(app.Name, app.Command, app.Dir, app.Env)
+			s.ensureAppDependencies(procRef{app: app})
+			s.startCommand(app)
 		case config.AppTypeYAML:
 			// Start all services for multi-service app, respecting depends_on
 			// TODO: Consider pre-allocating ports and passing PORT_<SERVICE> env vars
@@ -315,8 +434,7 @@ func (s *Server) startByName(name string) {
 			for i := range app.Services {
 				svc := &app.Services[i]
 				s.ensureDependencies(app, svc)
-				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
-				s.procs.StartAsync(procName, svc.Command, svc.Dir, svc.Env)
+				s.startService(app, svc)
 			}
 		}
 		return
@@ -330,10 +448,10 @@ func (s *Server) startByName(name string) {
 		for i := range app.Services {
 			svc := &app.Services[i]
 			procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
-			if procName == name {
+			if procName == name || strings.HasPrefix(name, procName+"#") { // Or one of its instances
 				// Start dependencies first
 				s.ensureDependencies(app, svc)
-				s.procs.StartAsync(procName, svc.Command, svc.Dir, svc.Env)
+				s.startService(app, svc)
 				return
 			}
 		}
@@ -458,7 +576,7 @@ func (s *Server) handleTailscaleRequest(w http.ResponseWriter, r *http.Request)
 			"Tailscale Serve",
 			"Specify an app in the path: /appname/...",
 			s.listAppsHTML(),
-			s.cfg.TLD, s.getTheme()))
+			s.tld(), s.getTheme()))
 		return
 	}
 
@@ -494,7 +612,7 @@ func (s *Server) handleTailscaleRequest(w http.ResponseWriter, r *http.Request)
 			"App not found",
 			fmt.Sprintf("No app or service configured for '%s'", name),
 			s.listAppsHTML(),
-			s.cfg.TLD, s.getTheme()))
+			s.tld(), s.getTheme()))
 		return
 	}
 
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+// How long requests that can't show the starting page are held for a
+// process to become ready, how often it's checked, and the Retry-After
+// sent once they time out
+var (
+	holdTimeout    = 30 * time.Second
+	holdPoll       = 100 * time.Millisecond
+	holdRetryAfter = 5 * time.Second
+)
+
+// isNavigation returns true for requests from a browser loading a page,
+// which can show the starting page and reload it. fetch and XHR calls,
+// curl, webhooks and API clients get the response of the app instead.
+func isNavigation(r *http.Request) bool {
+	// Sent by browsers for every request since 2020
+	if mode := r.Header.Get("Sec-Fetch-Mode"); mode != "" {
+		return mode == "navigate"
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.Header.Get("X-Requested-With") != "" {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// holdRequest proxies a request that isn't a navigation once its process
+// is ready, starting the process if it's idle. The request fails with a
+// 503 if the process fails or isn't ready within holdTimeout.
+func (s *Server) holdRequest(w http.ResponseWriter, r *http.Request, p procRef) {
+	s.logRequest("  -> HOLD until %s is ready", p.name())
+	proc, err := s.startAndWait(r.Context(), p)
+	if err != nil {
+		s.logRequest("  -> 503 (%v)", err)
+		if errors.Is(err, errNotReady) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(holdRetryAfter.Seconds())))
+		}
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.logRequest("  -> PROXY to %s", proc.Addr())
+	s.proxyTo(w, r, p, proc)
+}
+
+// startAndWait returns the process of p once it's running, starting it if
+// it's idle. A process that failed isn't started again.
+func (s *Server) startAndWait(ctx context.Context, p procRef) (*process.Process, error) {
+	name := p.name()
+	proc, found := s.getProc(name)
+	if found && proc.HasFailed() {
+		return nil, fmt.Errorf("%s failed to start: %s", name, proc.ExitError())
+	}
+	if !found || (!proc.IsRunning() && !proc.IsStarting()) {
+		s.ensureAppDependencies(p)
+		if err := s.startProc(p); err != nil {
+			return nil, fmt.Errorf("%s failed to start: %v", name, err)
+		}
+	}
+	return s.waitReady(ctx, name)
+}
+
+// errNotReady is returned by waitReady when a process isn't ready in time
+var errNotReady = errors.New("still starting")
+
+// waitReady waits for the named process to be running. The process is
+// looked up again each time, so a restart that replaces it is waited
+// out.
+func (s *Server) waitReady(ctx context.Context, name string) (*process.Process, error) {
+	timeout := time.NewTimer(holdTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(holdPoll)
+	defer ticker.Stop()
+	for {
+		// Not found while a restart replaces it
+		if proc, found := s.getProc(name); found && !proc.IsStarting() {
+			switch {
+			case proc.IsRunning():
+				return proc, nil
+			case proc.HasFailed():
+				return nil, fmt.Errorf("%s failed to start: %s", name, proc.ExitError())
+			default:
+				return nil, fmt.Errorf("%s exited before it was ready", name)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			return nil, fmt.Errorf("%s is %w; retry in %s", name, errNotReady, holdRetryAfter)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
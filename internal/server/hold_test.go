@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestIsNavigation(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{"browser page load", "GET", map[string]string{"Sec-Fetch-Mode": "navigate", "Accept": "text/html"}, true},
+		{"fetch from a page", "GET", map[string]string{"Sec-Fetch-Mode": "cors", "Accept": "*/*"}, false},
+		{"fetch asking for html", "GET", map[string]string{"Sec-Fetch-Mode": "cors", "Accept": "text/html"}, false},
+		{"old browser", "GET", map[string]string{"Accept": "text/html,application/xhtml+xml"}, true},
+		{"curl", "GET", map[string]string{"Accept": "*/*"}, false},
+		{"json client", "GET", map[string]string{"Accept": "application/json"}, false},
+		{"xhr", "GET", map[string]string{"Accept": "text/html", "X-Requested-With": "XMLHttpRequest"}, false},
+		{"webhook", "POST", map[string]string{"Accept": "text/html"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://app.test/", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			if got := isNavigation(r); got != tt.want {
+				t.Errorf("isNavigation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoldRequest(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "hello"), []byte("hello"), 0644)
+
+	cfg := &config.Config{TLD: "test"}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, config.NewAppStore(cfg), procs)
+	s.requestLog = process.NewLogBuffer(100)
+	apiRequest := func(app *config.App) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "http://"+app.Name+".test/hello", nil)
+		r.Header.Set("Accept", "application/json")
+		s.handleApp(w, r, app)
+		return w
+	}
+
+	t.Run("holds requests until the app is ready", func(t *testing.T) {
+		app := &config.App{
+			Name:    "slow",
+			Type:    config.AppTypeCommand,
+			Command: "sleep 1 && exec python3 -m http.server $PORT --bind 127.0.0.1",
+			Dir:     tmpDir,
+		}
+		w := apiRequest(app)
+		if w.Code != http.StatusOK || w.Body.String() != "hello" {
+			t.Errorf("expected the app's response once it started, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("sends 503 with Retry-After on timeout", func(t *testing.T) {
+		defer func(d time.Duration) { holdTimeout = d }(holdTimeout)
+		holdTimeout = 300 * time.Millisecond
+
+		app := &config.App{Name: "stuck", Type: config.AppTypeCommand, Command: "sleep 999", Dir: tmpDir}
+		w := apiRequest(app)
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+			t.Errorf("expected 503 with Retry-After, got %d %v", w.Code, w.Header())
+		}
+		if strings.Contains(w.Header().Get("Content-Type"), "html") {
+			t.Errorf("expected a plain text error, got %s", w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("sends 503 when the app fails", func(t *testing.T) {
+		app := &config.App{Name: "broken", Type: config.AppTypeCommand, Command: "exit 3", Dir: tmpDir}
+		w := apiRequest(app)
+		if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "exit code 3") {
+			t.Errorf("expected 503 with the exit code, got %d %q", w.Code, w.Body.String())
+		}
+		if w.Header().Get("Retry-After") != "" {
+			t.Error("expected no Retry-After for a failed app")
+		}
+	})
+
+	t.Run("shows the interstitial to browsers", func(t *testing.T) {
+		app := &config.App{Name: "pagey", Type: config.AppTypeCommand, Command: "sleep 999", Dir: tmpDir}
+		w := httptest.NewRecorder()
+		s.handleApp(w, pageRequest("http://pagey.test/"), app)
+		if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("expected the interstitial, got %d %v", w.Code, w.Header())
+		}
+	})
+}
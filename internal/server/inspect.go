@@ -0,0 +1,229 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/inspect"
+)
+
+// captureFor returns the capture of an app's requests, or nil if inspect is
+// off for it. Changing the app's inspect settings starts a new capture.
+func (s *Server) captureFor(app *config.App) *inspect.Capture {
+	if app.Inspect == nil {
+		return nil
+	}
+	s.capturesMu.Lock()
+	defer s.capturesMu.Unlock()
+	c := s.captures[app.Name]
+	if c == nil || !c.Options().Equal(*app.Inspect) {
+		if s.captures == nil {
+			s.captures = make(map[string]*inspect.Capture)
+		}
+		c = inspect.NewCapture(*app.Inspect)
+		s.captures[app.Name] = c
+	}
+	return c
+}
+
+// handleRequests returns the captured requests of an app, or of one of its
+// services, with an ID above ?since=
+func (s *Server) handleRequests(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	var app *config.App
+	target := ""
+	if found, ok := s.apps.GetByNameOrAlias(name); ok {
+		app = found
+	} else if match := s.resolveServiceName(name); match != nil {
+		app = match.App
+		target = match.ProcName
+	}
+	if app == nil {
+		http.Error(w, fmt.Sprintf("no app or service: %s", name), http.StatusNotFound)
+		return
+	}
+	capture := s.captureFor(app)
+	if capture == nil {
+		http.Error(w, fmt.Sprintf("request capture is off for %s; set inspect: true in its config", app.Name), http.StatusConflict)
+		return
+	}
+
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	exchanges := capture.Since(since)
+	if target != "" {
+		var filtered []inspect.Exchange
+		for _, e := range exchanges {
+			if e.Target == target || strings.HasPrefix(e.Target, target+"#") { // Or one of its instances
+				filtered = append(filtered, e)
+			}
+		}
+		exchanges = filtered
+	}
+	if exchanges == nil {
+		exchanges = []inspect.Exchange{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exchanges)
+}
+
+// findExchange returns the captured exchange with the given ID from any app
+func (s *Server) findExchange(id int64) (inspect.Exchange, bool) {
+	s.capturesMu.Lock()
+	defer s.capturesMu.Unlock()
+	for _, c := range s.captures {
+		if e, ok := c.Get(id); ok {
+			return e, true
+		}
+	}
+	return inspect.Exchange{}, false
+}
+
+// replayID returns the ID in a /api/requests/<id>/replay path
+func replayID(path string) (int64, bool) {
+	rest, ok := strings.CutPrefix(path, "/api/requests/")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, "/replay")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	return id, err == nil
+}
+
+// handleReplay sends a captured request again, with the edits in the
+// request body if there are any, and returns the new exchange
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "replay needs POST", http.StatusMethodNotAllowed)
+		return
+	}
+	e, found := s.findExchange(id)
+	if !found {
+		http.Error(w, fmt.Sprintf("no captured request %d", id), http.StatusNotFound)
+		return
+	}
+	req, err := e.NewRequest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	var edit inspect.Edit
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+			http.Error(w, fmt.Sprintf("invalid edit: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := edit.Apply(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	replayed, err := s.replay(req, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.logRequest("Replayed request %d to %s as %d", id, e.Target, replayed.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(replayed)
+}
+
+// replayResult is the outcome of replaying one request of a HAR file
+type replayResult struct {
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Exchange *inspect.Exchange `json:"exchange,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// handleHAR exports an app's captured requests as a HAR file (GET), or
+// replays the requests of a HAR file against the app in order (POST)
+func (s *Server) handleHAR(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	app, found := s.apps.GetByNameOrAlias(name)
+	if !found {
+		http.Error(w, fmt.Sprintf("no app: %s", name), http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		capture := s.captureFor(app)
+		if capture == nil {
+			http.Error(w, fmt.Sprintf("request capture is off for %s; set inspect: true in its config", app.Name), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.har"`, app.Name))
+		json.NewEncoder(w).Encode(inspect.NewHAR(capture.Since(0), s.cfg.Version))
+		return
+	}
+
+	var har inspect.HAR
+	if err := json.NewDecoder(r.Body).Decode(&har); err != nil {
+		http.Error(w, fmt.Sprintf("invalid HAR file: %v", err), http.StatusBadRequest)
+		return
+	}
+	host := app.Name + "." + s.tld()
+	results := []replayResult{}
+	for _, entry := range har.Log.Entries {
+		result := replayResult{Method: entry.Request.Method, URL: entry.Request.URL}
+		req, err := entry.NewRequest(host)
+		if err == nil {
+			var replayed inspect.Exchange
+			if replayed, err = s.replay(req, 0); err == nil {
+				result.Exchange = &replayed
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	s.logRequest("Replayed %d requests from a HAR file to %s", len(results), app.Name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// replay serves req the way a request from a browser would be served,
+// marked as a replay of the exchange with ID of (0 for none). Returns the
+// exchange recorded by the capture of the app that served it.
+func (s *Server) replay(req *http.Request, of int64) (inspect.Exchange, error) {
+	rp := &inspect.Replay{Of: of}
+	w := &replayWriter{header: make(http.Header)}
+	s.handleRequest(w, req.WithContext(inspect.WithReplay(req.Context(), rp)))
+	if !rp.Recorded {
+		return inspect.Exchange{}, fmt.Errorf("%s%s wasn't proxied to a running app with inspect: on (status %d)", req.Host, req.RequestURI, w.status)
+	}
+	return rp.Exchange, nil
+}
+
+// replayWriter is the client of a replayed request. The response is read
+// from the capture, so it's discarded here.
+type replayWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *replayWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *replayWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(p), nil
+}
+
+func (w *replayWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
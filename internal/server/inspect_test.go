@@ -0,0 +1,147 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/inspect"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestRequestInspector(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("X-Saw-Authorization", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "got %s", body)
+	}))
+	defer backend.Close()
+	port := strings.TrimPrefix(backend.URL, "http://127.0.0.1:")
+
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "hooks.yml"), []byte("port: "+port+"\ninspect: true\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "plain.yml"), []byte("port: "+port+"\n"), 0644)
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(cfg, apps, process.NewManager())
+	s.requestLog = process.NewLogBuffer(100)
+	hooks, _ := apps.Get("hooks")
+
+	req := httptest.NewRequest("POST", "http://hooks.test/webhook?source=stripe", strings.NewReader(`{"id":1}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	s.handleApp(w, req, hooks)
+	if w.Code != http.StatusCreated || w.Body.String() != `got {"id":1}` {
+		t.Fatalf("expected the request to reach the backend, got %d %q", w.Code, w.Body.String())
+	}
+
+	get := func(url string) (int, []inspect.Exchange) {
+		w := httptest.NewRecorder()
+		s.handleDashboard(w, httptest.NewRequest("GET", url, nil))
+		var exchanges []inspect.Exchange
+		json.Unmarshal(w.Body.Bytes(), &exchanges)
+		return w.Code, exchanges
+	}
+
+	t.Run("captures requests and responses", func(t *testing.T) {
+		code, exchanges := get("/api/requests?name=hooks")
+		if code != 200 || len(exchanges) != 1 {
+			t.Fatalf("expected one exchange, got %d %+v", code, exchanges)
+		}
+		e := exchanges[0]
+		if e.Method != "POST" || e.URL != "/webhook?source=stripe" || e.Status != http.StatusCreated {
+			t.Errorf("unexpected exchange %+v", e)
+		}
+		if e.RequestBody.Text != `{"id":1}` || e.ResponseBody.Text != `got {"id":1}` {
+			t.Errorf("expected both bodies, got %+v and %+v", e.RequestBody, e.ResponseBody)
+		}
+		if e.RequestHeaders.Get("Authorization") != inspect.Redacted || e.ResponseHeaders.Get("Set-Cookie") != inspect.Redacted {
+			t.Errorf("expected sensitive headers to be redacted, got %v and %v", e.RequestHeaders, e.ResponseHeaders)
+		}
+		if _, later := get(fmt.Sprintf("/api/requests?name=hooks&since=%d", e.ID)); len(later) != 0 {
+			t.Errorf("expected nothing after the last ID, got %+v", later)
+		}
+	})
+
+	t.Run("rejects apps without inspect", func(t *testing.T) {
+		if code, _ := get("/api/requests?name=plain"); code != http.StatusConflict {
+			t.Errorf("expected 409, got %d", code)
+		}
+		if code, _ := get("/api/requests?name=nope"); code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", code)
+		}
+	})
+
+	post := func(url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handleDashboard(w, httptest.NewRequest("POST", url, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("replays requests with edits", func(t *testing.T) {
+		_, exchanges := get("/api/requests?name=hooks")
+		original := exchanges[0]
+
+		w := post(fmt.Sprintf("/api/requests/%d/replay", original.ID), `{"body": "{\"id\":2}"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+		}
+		var replayed inspect.Exchange
+		json.Unmarshal(w.Body.Bytes(), &replayed)
+		if replayed.ReplayOf != original.ID || replayed.ID <= original.ID {
+			t.Errorf("expected a new exchange replaying %d, got %+v", original.ID, replayed)
+		}
+		if replayed.URL != "/webhook?source=stripe" || replayed.ResponseBody.Text != `got {"id":2}` {
+			t.Errorf("expected the edited body to reach the same URL, got %+v", replayed)
+		}
+		if replayed.ResponseHeaders.Get("X-Saw-Authorization") != "Bearer secret" {
+			t.Errorf("expected the unredacted Authorization to be sent, got %v", replayed.ResponseHeaders)
+		}
+
+		if w := post("/api/requests/999999/replay", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for an unknown ID, got %d", w.Code)
+		}
+		if w := post(fmt.Sprintf("/api/requests/%d/replay", original.ID), `{"url": "http://evil.example/"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a URL with a host, got %d", w.Code)
+		}
+	})
+
+	t.Run("exports and imports HAR files", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleDashboard(w, httptest.NewRequest("GET", "/api/requests/har?name=hooks", nil))
+		var har inspect.HAR
+		if err := json.Unmarshal(w.Body.Bytes(), &har); err != nil || har.Log.Version != "1.2" || len(har.Log.Entries) == 0 {
+			t.Fatalf("expected a HAR file, got %d %s", w.Code, w.Body.String())
+		}
+		if got := har.Log.Entries[0].Request.URL; got != "http://hooks.test/webhook?source=stripe" {
+			t.Errorf("expected the full URL, got %q", got)
+		}
+
+		w = post("/api/requests/har?name=hooks", w.Body.String())
+		var results []replayResult
+		json.Unmarshal(w.Body.Bytes(), &results)
+		if w.Code != http.StatusOK || len(results) != len(har.Log.Entries) {
+			t.Fatalf("expected a result per entry, got %d %s", w.Code, w.Body.String())
+		}
+		for _, result := range results {
+			if result.Exchange == nil || result.Exchange.Status != http.StatusCreated {
+				t.Errorf("expected each entry to be replayed, got %+v", result)
+			}
+		}
+		if code := post("/api/requests/har?name=plain", w.Body.String()).Code; code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a file that isn't a HAR, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/panozzaj/fireup/internal/config"
+)
+
+// profilesFile stores the active profile of each app (app name → profile)
+const profilesFile = "config-profiles.json"
+
+// loadActiveProfiles reads remembered profiles from config-profiles.json
+func (s *Server) loadActiveProfiles() map[string]string {
+	profiles := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.cfg.Dir, profilesFile))
+	if err != nil {
+		return profiles
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		fmt.Printf("Warning: ignoring invalid %s: %v\n", profilesFile, err)
+		return make(map[string]string)
+	}
+	return profiles
+}
+
+// saveActiveProfiles writes the active profiles to config-profiles.json
+func (s *Server) saveActiveProfiles() error {
+	data, err := json.MarshalIndent(s.apps.ActiveProfiles(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.cfg.Dir, profilesFile), data, 0644)
+}
+
+// appProcessNames returns the process names an app runs under
+func appProcessNames(app *config.App) []string {
+	switch app.Type {
+	case config.AppTypeCommand:
+		return []string{app.Name}
+	case config.AppTypeYAML:
+		var names []string
+		for _, svc := range app.Services {
+			names = append(names, fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name))
+		}
+		return names
+	}
+	return nil
+}
+
+// switchProfile makes profile the active profile of app. Running processes
+// are stopped since their command and env may change. It returns the updated
+// app and whether anything was running before the switch.
+func (s *Server) switchProfile(app *config.App, profile string) (*config.App, bool, error) {
+	if profile == "" {
+		profile = config.DefaultProfile
+	}
+	current := app.Profile
+	if current == "" {
+		current = config.DefaultProfile
+	}
+	if profile == current {
+		return app, false, nil
+	}
+
+	// Validate before stopping anything
+	if _, err := app.WithProfile(profile); err != nil {
+		return nil, false, err
+	}
+
+	wasRunning := s.isAppActive(app)
+	for _, procName := range appProcessNames(app) {
+		s.stopProc(procName)
+	}
+
+	updated, err := s.apps.SetProfile(app.Name, profile)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.saveActiveProfiles(); err != nil {
+		s.logRequest("Failed to save %s: %v", profilesFile, err)
+	}
+	s.logRequest("Switched %s to profile %s", app.Name, profile)
+	return updated, wasRunning, nil
+}
+
+// handleProfile switches the active profile of an app, restarting it if it was running
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	app, found := s.apps.GetByNameOrAlias(name)
+	if !found {
+		http.Error(w, fmt.Sprintf("app not found: %s", name), http.StatusNotFound)
+		return
+	}
+
+	updated, wasRunning, err := s.switchProfile(app, r.URL.Query().Get("profile"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if wasRunning {
+		s.startByName(updated.Name)
+	}
+	s.broadcastStatus()
+
+	profile := updated.Profile
+	if profile == "" {
+		profile = config.DefaultProfile
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"name": updated.Name, "profile": profile})
+}
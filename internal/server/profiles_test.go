@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestStartWithProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "myapp.yml"), []byte(`
+root: /tmp
+cmd: sleep 999
+env:
+  API_URL: http://localhost
+profiles:
+  staging-api:
+    env:
+      API_URL: https://staging.example.com
+`), 0644)
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir, URLPort: 80}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(10)
+	defer procs.StopAll()
+
+	t.Run("unknown profile is rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleStart(w, httptest.NewRequest("GET", "/api/start?name=myapp&profile=nope", nil))
+		if w.Code != 400 {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+		if _, found := procs.Get("myapp"); found {
+			t.Error("expected app not to be started")
+		}
+	})
+
+	t.Run("starts with the profile applied", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleStart(w, httptest.NewRequest("GET", "/api/start?name=myapp&profile=staging-api", nil))
+		if w.Code != 200 {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		proc, found := procs.Get("myapp")
+		if !found {
+			t.Fatal("expected myapp to be started")
+		}
+		if proc.Env["API_URL"] != "https://staging.example.com" {
+			t.Errorf("expected staging API_URL, got %q", proc.Env["API_URL"])
+		}
+	})
+
+	t.Run("active profile is remembered and reported", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(tmpDir, profilesFile))
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", profilesFile, err)
+		}
+		var saved map[string]string
+		json.Unmarshal(data, &saved)
+		if saved["myapp"] != "staging-api" {
+			t.Errorf("unexpected saved profiles: %v", saved)
+		}
+
+		var status []appStatus
+		if err := json.Unmarshal(s.getStatus(), &status); err != nil {
+			t.Fatal(err)
+		}
+		if len(status) != 1 || status[0].Profile != "staging-api" || len(status[0].Profiles) != 1 {
+			t.Errorf("unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("switching profile restarts a running app", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleProfile(w, httptest.NewRequest("POST", "/api/profile?name=myapp&profile=default", nil))
+		if w.Code != 200 {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		proc, found := procs.Get("myapp")
+		if !found {
+			t.Fatal("expected myapp to be restarted")
+		}
+		if proc.Env["API_URL"] != "http://localhost" {
+			t.Errorf("expected default API_URL, got %q", proc.Env["API_URL"])
+		}
+		if app, _ := apps.Get("myapp"); app.Profile != "" {
+			t.Errorf("expected default profile, got %q", app.Profile)
+		}
+	})
+}
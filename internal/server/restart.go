@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/panozzaj/fireup/internal/config"
+)
+
+// restartStrategy returns the process's restart_strategy
+func (p procRef) restartStrategy() string {
+	if p.svc != nil {
+		return p.svc.RestartStrategy
+	}
+	return p.app.RestartStrategy
+}
+
+// restartProc restarts a process with its current config. With
+// restart_strategy: overlap, a running process keeps serving until its new
+// instance is ready and keeps running if that fails; other processes are
+// stopped first.
+func (s *Server) restartProc(p procRef) {
+	name := p.name()
+	proc, found := s.getProc(name)
+	if p.restartStrategy() != config.RestartOverlap || !found || !proc.IsRunning() {
+		s.stopProc(name)
+		s.ensureAppDependencies(p)
+		s.startProc(p)
+		return
+	}
+
+	failed := func(err error) {
+		msg := fmt.Sprintf("Restarting %s failed, the old instance keeps serving: %v", name, err)
+		s.logRequest("%s", msg)
+		s.broadcastNotice("error", msg, "")
+	}
+	s.ensureAppDependencies(p)
+	l, err := s.launchFor(p)
+	if err != nil {
+		failed(err)
+		return
+	}
+	go func() {
+		if err := s.procs.Overlap(l.name, l.command, l.dir, l.env, l.opts); err != nil {
+			failed(err)
+		} else {
+			s.logRequest("Switched %s to its new instance", name)
+			s.holdSharedDependencies(p)
+		}
+		s.broadcastStatus()
+	}()
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestRestartOverlap(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "shop.yml"), []byte(`
+root: /tmp
+restart_strategy: overlap
+services:
+  web:
+    cmd: python3 -m http.server $PORT --bind 127.0.0.1
+  worker:
+    cmd: sleep 999
+`), 0644)
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(100)
+
+	app, _ := apps.Get("shop")
+	var web *config.Service
+	for i := range app.Services {
+		if app.Services[i].Name == "web" {
+			web = &app.Services[i]
+		}
+	}
+	// The shell can take a while to start when the machine is busy, so
+	// wait in short rounds against a deadline of the test's own
+	defer func(timeout, poll time.Duration) { holdTimeout, holdPoll = timeout, poll }(holdTimeout, holdPoll)
+	holdTimeout, holdPoll = time.Second, 20*time.Millisecond
+	const readyDeadline = 60 * time.Second
+
+	p := procRef{app: app, svc: web}
+	if err := s.startProc(p); err != nil {
+		t.Fatal(err)
+	}
+	var old *process.Process
+	deadline := time.Now().Add(readyDeadline)
+	for {
+		proc, err := s.waitReady(context.Background(), p.name())
+		if err == nil {
+			old = proc
+			break
+		}
+		if !errors.Is(err, errNotReady) || time.Now().After(deadline) {
+			t.Fatalf("expected web to be ready within %v: %v", readyDeadline, err)
+		}
+	}
+
+	// Waits for the restart running in the background to finish
+	waitFor := func(done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(readyDeadline)
+		for !done() && time.Now().Before(deadline) {
+			time.Sleep(holdPoll)
+		}
+	}
+
+	t.Run("failed restart keeps the old instance and shows the error", func(t *testing.T) {
+		web.Command = "exit 3"
+		s.restartProc(p)
+		waitFor(func() bool { return old.RestartError() != "" })
+
+		if proc, _ := procs.Get(p.name()); proc != old {
+			t.Fatal("expected the old instance to keep serving")
+		}
+		var status []appStatus
+		json.Unmarshal(s.getStatus(), &status)
+		var errors []string
+		for _, as := range status {
+			for _, ss := range as.Services {
+				if ss.Error != "" {
+					errors = append(errors, ss.Name+": "+ss.Error)
+				}
+			}
+		}
+		if len(errors) != 1 || !strings.HasPrefix(errors[0], "web: ") {
+			t.Errorf("expected the restart error of web in the status, got %v", errors)
+		}
+	})
+
+	t.Run("restart switches to the new instance", func(t *testing.T) {
+		web.Command = "python3 -m http.server $PORT --bind 127.0.0.1"
+		s.restartProc(p)
+		if proc, _ := procs.Get(p.name()); proc != old {
+			t.Error("expected the old instance to serve while the new one starts")
+		}
+		waitFor(func() bool {
+			proc, _ := procs.Get(p.name())
+			return proc != old
+		})
+
+		proc, _ := procs.Get(p.name())
+		if proc == old || proc.RestartError() != "" {
+			t.Errorf("expected a new instance without an error")
+		}
+	})
+}
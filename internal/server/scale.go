@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/panozzaj/fireup/internal/process"
+	"github.com/panozzaj/fireup/internal/proxy"
+)
+
+// scaled returns true if p runs several instances (scale:)
+func (p procRef) scaled() bool {
+	return p.svc != nil && p.svc.Scale > 1
+}
+
+// getProc returns the named process. For a scaled service it returns the
+// instance that best stands for the service's state: a running one if
+// any, else a starting one, else a failed one.
+func (s *Server) getProc(name string) (*process.Process, bool) {
+	instances := s.procs.Instances(name)
+	switch len(instances) {
+	case 0:
+		return nil, false
+	case 1:
+		return instances[0], true
+	}
+	for _, state := range []func(*process.Process) bool{
+		(*process.Process).IsRunning,
+		(*process.Process).IsStarting,
+		(*process.Process).HasFailed,
+	} {
+		for _, proc := range instances {
+			if state(proc) {
+				return proc, true
+			}
+		}
+	}
+	return instances[0], true
+}
+
+// instanceSuffix returns the number of an instance from its process name,
+// e.g. "2" for web-myapp#2
+func instanceSuffix(name string) string {
+	if i := strings.LastIndex(name, "#"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// proxyTo proxies a request to p, whose process proc is running. Requests
+// to a scaled service are balanced across its running instances instead.
+func (s *Server) proxyTo(w http.ResponseWriter, r *http.Request, p procRef, proc *process.Process) {
+	defer s.beginRequest(p)()
+	if p.scaled() {
+		running := make(map[string]*process.Process)
+		var names []string
+		for _, instance := range s.procs.Instances(p.name()) {
+			if instance.IsRunning() {
+				running[instance.Name] = instance
+				names = append(names, instance.Name)
+			}
+		}
+		if len(names) > 0 {
+			name, done := s.balancers.Get(p.name(), proxy.Policy(p.svc.Balance)).Pick(w, r, names)
+			defer done()
+			proc = running[name]
+			s.logRequest("  -> BALANCE to %s (%d of %d running)", name, len(names), p.svc.Scale)
+		}
+	}
+	s.proxies.Get(proc.Name, proc.Addr(), s.getTheme(), s.captureFor(p.app)).ServeHTTP(w, r)
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
+)
+
+func TestScaledService(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	tmpDir := t.TempDir()
+	// Each instance serves its number at /id
+	os.WriteFile(filepath.Join(tmpDir, "shop.yml"), []byte(`
+root: `+tmpDir+`
+services:
+  web:
+    cmd: mkdir -p i$FIREUP_INSTANCE && cd i$FIREUP_INSTANCE && echo -n $FIREUP_INSTANCE > id && exec python3 -m http.server $PORT --bind 127.0.0.1
+    scale: 2
+`), 0644)
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(100)
+	app, _ := apps.Get("shop")
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "http://shop.test/id", nil)
+		r.Header.Set("Accept", "application/json")
+		s.handleApp(w, r, app)
+		return w
+	}
+
+	t.Run("balances requests across the instances", func(t *testing.T) {
+		// The first request starts the instances and waits for one of them
+		if w := get(); w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d %q", w.Code, w.Body.String())
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for time.Now().Before(deadline) {
+			starting := false
+			for _, proc := range procs.Instances("web-shop") {
+				starting = starting || proc.IsStarting()
+			}
+			if !starting {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		seen := make(map[string]bool)
+		for i := 0; i < 4; i++ {
+			seen[get().Body.String()] = true
+		}
+		if !seen["1"] || !seen["2"] || len(seen) != 2 {
+			t.Errorf("expected responses from instances 1 and 2, got %v", seen)
+		}
+	})
+
+	t.Run("reports each instance", func(t *testing.T) {
+		var status []appStatus
+		json.Unmarshal(s.getStatus(), &status)
+		if len(status) != 1 || len(status[0].Services) != 1 {
+			t.Fatalf("unexpected status %+v", status)
+		}
+		svc := status[0].Services[0]
+		if svc.Scale != 2 || svc.Balance != config.BalanceRoundRobin || len(svc.Instances) != 2 {
+			t.Fatalf("expected 2 round-robin instances, got %+v", svc)
+		}
+		if svc.Instances[0].Name != "web-shop#1" || !svc.Instances[0].Running || svc.Instances[0].Port == svc.Instances[1].Port {
+			t.Errorf("expected running instances on their own ports, got %+v", svc.Instances)
+		}
+	})
+
+	t.Run("takes crashed instances out of rotation", func(t *testing.T) {
+		crashed, _ := procs.Get("web-shop#1")
+		crashed.Kill()
+		deadline := time.Now().Add(3 * time.Second)
+		for crashed.IsRunning() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		for i := 0; i < 3; i++ {
+			if w := get(); w.Body.String() != "2" {
+				t.Errorf("expected instance 2 to serve, got %d %q", w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("logs are kept per instance", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleLogs(w, httptest.NewRequest("GET", "/api/logs?name=shop", nil))
+		var lines []string
+		json.Unmarshal(w.Body.Bytes(), &lines)
+		logs := strings.Join(lines, "\n")
+		if !strings.Contains(logs, "[web#1] ") || !strings.Contains(logs, "[web#2] ") {
+			t.Errorf("expected lines of both instances, got %v", lines)
+		}
+	})
+}
@@ -6,13 +6,18 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/panozzaj/fireup/internal/certs"
 	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/dns"
+	"github.com/panozzaj/fireup/internal/inspect"
 	"github.com/panozzaj/fireup/internal/ollama"
 	"github.com/panozzaj/fireup/internal/process"
+	"github.com/panozzaj/fireup/internal/proxy"
 )
 
 // slugify converts a name to a URL-safe slug (lowercase, spaces to dashes)
@@ -50,11 +55,24 @@ type Server struct {
 	broadcaster   *Broadcaster       // SSE broadcaster for real-time updates
 	configWatcher *config.Watcher    // Watches config directory for changes
 	ollamaClient  *ollama.Client     // Optional LLM client for log analysis
+	dnsServer     *dns.Server        // Built-in DNS server for the TLD (optional)
+	certManager   *certs.Manager     // Dynamic HTTPS certificates (optional)
+	globalMu      sync.RWMutex       // Guards settings reloaded from config.json
+	proxies       proxy.Cache        // Reverse proxies by app or process name
+	balancers     proxy.Balancers    // Balancers of scaled services by process name
+	forwardsMu    sync.Mutex
+	forwards      map[int]*forward // tcp: ports by port number
+	capturesMu    sync.Mutex
+	captures      map[string]*inspect.Capture // Captured requests by app name (inspect:)
+	themeMu       sync.Mutex
+	theme         string // Cached config-theme.json setting, "" until read
 }
 
 // New creates a new server
 func New(cfg *config.Config) (*Server, error) {
-	apps := config.NewAppStore(cfg)
+	// The app store gets its own copy so TLD changes can be applied to it separately
+	storeCfg := *cfg
+	apps := config.NewAppStore(&storeCfg)
 	if err := apps.Load(); err != nil {
 		return nil, fmt.Errorf("loading apps: %w", err)
 	}
@@ -66,6 +84,7 @@ func New(cfg *config.Config) (*Server, error) {
 		requestLog:  process.NewLogBuffer(500), // Keep last 500 request log entries
 		broadcaster: NewBroadcaster(),
 	}
+	apps.SetActiveProfiles(s.loadActiveProfiles())
 
 	// Initialize Ollama client if configured
 	if cfg.Ollama != nil && cfg.Ollama.Enabled {
@@ -75,41 +94,37 @@ func New(cfg *config.Config) (*Server, error) {
 
 	// Set up config watcher
 	watcher, err := config.NewWatcher(cfg.Dir, func(changedFiles []string) {
-		// Build a set of app names whose configs changed
-		changedApps := make(map[string]bool)
+		// Files outside the config directory (extends: targets, Procfiles)
+		// are reported with absolute paths and only matter to the apps
+		// built from them
+		var outside []string
 		for _, filename := range changedFiles {
-			// Strip .yml/.yaml extension to get app name
-			appName := strings.TrimSuffix(filename, ".yml")
-			appName = strings.TrimSuffix(appName, ".yaml")
-			changedApps[appName] = true
+			if filepath.IsAbs(filename) {
+				outside = append(outside, filename)
+			} else if filename == config.GlobalFile {
+				s.reloadGlobalConfig()
+			} else if filename == themeFile {
+				s.clearTheme()
+			}
+		}
+		if len(outside) == len(changedFiles) {
+			dependents := s.apps.DependentApps(outside)
+			if len(dependents) == 0 {
+				return
+			}
+			sort.Strings(dependents)
+			s.logRequest("%s changed, reloading %s", strings.Join(outside, ", "), strings.Join(dependents, ", "))
 		}
 
-		// Collect process names for current apps before reload
+		// Collect process names and running apps before reload
 		oldProcessNames := s.collectProcessNames()
-
-		// Track which apps were running before reload
-		runningApps := make(map[string]bool)
+		wasActive := make(map[string]bool)
 		for _, app := range s.apps.All() {
-			if changedApps[app.Name] {
-				switch app.Type {
-				case config.AppTypeCommand:
-					if proc, found := s.procs.Get(app.Name); found && (proc.IsRunning() || proc.IsStarting()) {
-						runningApps[app.Name] = true
-					}
-				case config.AppTypeYAML:
-					// Check if any service is running or starting (starting might be hung)
-					for _, svc := range app.Services {
-						procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
-						if proc, found := s.procs.Get(procName); found && (proc.IsRunning() || proc.IsStarting()) {
-							runningApps[app.Name] = true
-							break
-						}
-					}
-				}
-			}
+			wasActive[app.Name] = s.isAppActive(app)
 		}
 
-		if err := s.apps.Reload(); err != nil {
+		diff, err := s.apps.Reload()
+		if err != nil {
 			s.logRequest("Config reload error: %v", err)
 			return
 		}
@@ -120,53 +135,137 @@ func New(cfg *config.Config) (*Server, error) {
 		// Clean up processes for removed apps (running, starting, or failed)
 		for name := range oldProcessNames {
 			if _, exists := newProcessNames[name]; !exists {
-				if proc, found := s.procs.Get(name); found {
+				for _, proc := range s.procs.Instances(name) {
 					if proc.IsRunning() || proc.IsStarting() {
-						s.logRequest("Stopping orphaned process: %s", name)
-						s.procs.Stop(name)
+						s.logRequest("Stopping orphaned process: %s", proc.Name)
+						s.stopProc(proc.Name)
 					} else {
 						// Remove failed/stopped processes for removed services
-						s.logRequest("Removing orphaned process: %s", name)
-						s.procs.Remove(name)
+						s.logRequest("Removing orphaned process: %s", proc.Name)
+						s.procs.Remove(proc.Name)
+						s.proxies.Remove(proc.Name)
 					}
 				}
 			}
 		}
 
-		// Restart apps that were running and whose config changed
-		for appName := range runningApps {
-			app, exists := s.apps.Get(appName)
-			if !exists {
-				continue // App was removed
-			}
-			s.logRequest("Restarting %s (config changed)", appName)
-			switch app.Type {
-			case config.AppTypeCommand:
-				s.procs.Stop(appName)
-				s.procs.StartAsync(appName, app.Command, app.Dir, app.Env)
-			case config.AppTypeYAML:
-				// Restart all services for this app
-				for _, svc := range app.Services {
-					procName := fmt.Sprintf("%s-%s", slugify(svc.Name), appName)
-					s.procs.Stop(procName)
-					s.procs.StartAsync(procName, svc.Command, svc.Dir, svc.Env)
-				}
+		// Port apps have no process, so drop their proxies by app name
+		for _, name := range diff.Removed {
+			s.proxies.Remove(name)
+		}
+
+		// Restart what changed in apps that were running
+		for _, change := range diff.Changed {
+			if wasActive[change.Name] {
+				s.applyAppChange(change)
 			}
 		}
 
-		s.logRequest("Config reloaded")
+		s.configWatcher.WatchFiles(s.apps.SourceFiles())
+		s.syncForwards()
+		s.logRequest("Config reloaded (v%d): %s", s.apps.Snapshot().Version, diff)
 		s.broadcastStatus()
 	})
 	if err != nil {
 		// Log but don't fail - config watching is optional
 		fmt.Printf("Warning: could not watch config directory: %v\n", err)
 	} else {
+		watcher.WatchFiles(apps.SourceFiles())
 		s.configWatcher = watcher
 	}
 
 	return s, nil
 }
 
+// isAppActive returns true if any of the app's processes is running or
+// starting (starting might be hung)
+func (s *Server) isAppActive(app *config.App) bool {
+	for _, procName := range appProcessNames(app) {
+		if proc, found := s.getProc(procName); found && (proc.IsRunning() || proc.IsStarting()) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAppChange restarts the processes of a running app that are affected
+// by a config change. Services restart only if a setting they run with
+// changed (see config.AppChange.RestartReasons); edits like a new
+// description just update the dashboard.
+func (s *Server) applyAppChange(change config.AppChange) {
+	app, found := s.apps.Get(change.Name)
+	if !found {
+		return
+	}
+
+	// Newly added app dependencies only need starting, not a restart
+	if change.Has("app_depends_on") {
+		for _, p := range appProcs(app) {
+			if s.isActive(p) {
+				s.ensureAppDependencies(p)
+			}
+		}
+	}
+
+	// App-level changes (e.g. cmd, root, or the app type) restart everything
+	if reasons := change.RestartReasons(); len(reasons) > 0 {
+		reason := strings.Join(reasons, ", ") + " changed"
+		switch app.Type {
+		case config.AppTypeCommand:
+			s.logRequest("Restarting %s (%s)", app.Name, reason)
+			s.restartProc(procRef{app: app})
+		case config.AppTypeYAML:
+			for i := range app.Services {
+				svc := &app.Services[i]
+				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
+				s.logRequest("Restarting %s (%s)", procName, reason)
+				s.restartProc(procRef{app: app, svc: svc})
+			}
+		}
+		return
+	}
+
+	// Service name → why it restarts
+	restart := make(map[string]string)
+	for _, svc := range change.ChangedServices {
+		if reasons := svc.RestartReasons(); len(reasons) > 0 {
+			restart[svc.Name] = strings.Join(reasons, ", ") + " changed"
+		}
+	}
+	for _, name := range change.AddedServices {
+		restart[name] = "service added"
+	}
+	if app.RestartDependents {
+		// Services are sorted so dependencies come first
+		for _, svc := range app.Services {
+			if _, ok := restart[svc.Name]; ok {
+				continue
+			}
+			for _, dep := range svc.DependsOn {
+				if _, ok := restart[dep]; ok {
+					restart[svc.Name] = "depends on " + dep
+					break
+				}
+			}
+		}
+	}
+
+	if len(restart) == 0 {
+		s.logRequest("Updated %s without restarting", change)
+		return
+	}
+	for i := range app.Services {
+		svc := &app.Services[i]
+		reason, ok := restart[svc.Name]
+		if !ok {
+			continue
+		}
+		procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
+		s.logRequest("Restarting %s (%s)", procName, reason)
+		s.restartProc(procRef{app: app, svc: svc})
+	}
+}
+
 // getCertsDir returns the path to the certs directory
 func (s *Server) getCertsDir() string {
 	return filepath.Join(s.cfg.Dir, "certs")
@@ -193,13 +292,22 @@ func (s *Server) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleRequest)
 
+	// Start DNS server for custom TLDs
+	s.startDNS(s.tld())
+
+	// Open the tcp: ports of services
+	s.syncForwards()
+
 	// Start HTTPS servers if CA exists (dynamic cert generation)
 	certsDir := s.getCertsDir()
 	if certs.CAExists(certsDir) {
-		certManager, err := certs.NewManager(certsDir, s.cfg.TLD)
+		certManager, err := certs.NewManager(certsDir, s.tld())
 		if err != nil {
 			fmt.Printf("Warning: failed to load CA: %v\n", err)
 		} else {
+			s.globalMu.Lock()
+			s.certManager = certManager
+			s.globalMu.Unlock()
 			go s.startHTTPS(mux, certManager, "127.0.0.1")
 			go s.startHTTPS(mux, certManager, "[::1]")
 		}
@@ -259,6 +367,13 @@ func (s *Server) Shutdown() {
 	if s.configWatcher != nil {
 		s.configWatcher.Stop()
 	}
+	s.globalMu.RLock()
+	dnsServer := s.dnsServer
+	s.globalMu.RUnlock()
+	if dnsServer != nil {
+		dnsServer.Stop()
+	}
+	s.closeForwards()
 	fmt.Println("[fireup] Shutdown: stopping all processes...")
 	s.procs.StopAll()
 	fmt.Println("[fireup] Shutdown: closing HTTP servers...")
@@ -279,9 +394,32 @@ func (s *Server) logRequest(format string, args ...interface{}) {
 	fmt.Printf("[%s] %s\n", timestamp, msg) // Also print to stdout
 }
 
-// getTheme reads the theme from config-theme.json, defaults to "system"
+// themeFile stores the dashboard theme
+const themeFile = "config-theme.json"
+
+// getTheme returns the theme from config-theme.json, defaults to "system".
+// The file is read once; setTheme and clearTheme keep the cached value
+// current.
 func (s *Server) getTheme() string {
-	data, err := os.ReadFile(filepath.Join(s.cfg.Dir, "config-theme.json"))
+	s.themeMu.Lock()
+	defer s.themeMu.Unlock()
+	if s.theme == "" {
+		s.theme = s.readTheme()
+	}
+	return s.theme
+}
+
+// clearTheme makes getTheme read config-theme.json again, e.g. after it was
+// edited by hand
+func (s *Server) clearTheme() {
+	s.themeMu.Lock()
+	s.theme = ""
+	s.themeMu.Unlock()
+}
+
+// readTheme reads the theme from config-theme.json, defaults to "system"
+func (s *Server) readTheme() string {
+	data, err := os.ReadFile(filepath.Join(s.cfg.Dir, themeFile))
 	if err != nil {
 		return "system"
 	}
@@ -303,5 +441,11 @@ func (s *Server) setTheme(theme string) error {
 		return fmt.Errorf("invalid theme: %s", theme)
 	}
 	data, _ := json.Marshal(map[string]string{"theme": theme})
-	return os.WriteFile(filepath.Join(s.cfg.Dir, "config-theme.json"), data, 0644)
+	if err := os.WriteFile(filepath.Join(s.cfg.Dir, themeFile), data, 0644); err != nil {
+		return err
+	}
+	s.themeMu.Lock()
+	s.theme = theme
+	s.themeMu.Unlock()
+	return nil
 }
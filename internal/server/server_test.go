@@ -1,11 +1,19 @@
 package server
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/limits"
 	"github.com/panozzaj/fireup/internal/process"
 )
 
@@ -159,6 +167,54 @@ func TestEnsureDependencies(t *testing.T) {
 	})
 }
 
+func TestStartServiceResolvesPortReferences(t *testing.T) {
+	cfg := &config.Config{TLD: "test"}
+	apps := config.NewAppStore(cfg)
+	procs := process.NewManager()
+	s := newTestServer(cfg, apps, procs)
+	defer procs.StopAll()
+
+	app := &config.App{
+		Name: "portref",
+		Services: []config.Service{
+			{Name: "api", Command: "sleep 999", Dir: "/tmp"},
+			{
+				Name:      "web",
+				Command:   "sleep 999 # ${port:api}",
+				Dir:       "/tmp",
+				Env:       map[string]string{"API_PORT": "${port:api}"},
+				DependsOn: []string{"api"},
+			},
+		},
+	}
+
+	t.Run("fails when the referenced service has no port", func(t *testing.T) {
+		if _, err := s.startService(app, &app.Services[1]); err == nil {
+			t.Error("expected error when api has not been started")
+		}
+	})
+
+	t.Run("substitutes the dependency's port", func(t *testing.T) {
+		s.ensureDependencies(app, &app.Services[1])
+		api, found := procs.Get("api-portref")
+		if !found {
+			t.Fatal("expected api-portref to be started")
+		}
+
+		web, err := s.startService(app, &app.Services[1])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := fmt.Sprintf("%d", api.Port)
+		if web.Env["API_PORT"] != want {
+			t.Errorf("expected API_PORT=%s, got %q", want, web.Env["API_PORT"])
+		}
+		if web.Command != "sleep 999 # "+want {
+			t.Errorf("unexpected command: %q", web.Command)
+		}
+	})
+}
+
 func TestStartByNameServiceLookup(t *testing.T) {
 	tmpDir := t.TempDir()
 	cfg := &config.Config{TLD: "test", Dir: tmpDir}
@@ -798,3 +854,252 @@ services:
 		t.Error("multi-service app should list services, not app name")
 	}
 }
+
+func TestApplyAppChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfig := func(extra, apiEnv, webCmd string) {
+		os.WriteFile(tmpDir+"/shop.yml", []byte(`
+root: /tmp
+`+extra+`
+services:
+  api:
+    cmd: sleep 999
+    env:
+      MODE: `+apiEnv+`
+  web:
+    cmd: `+webCmd+`
+    depends_on: [api]
+  worker:
+    cmd: sleep 999
+`), 0644)
+	}
+	writeConfig("description: Shop", "a", "sleep 999")
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(10)
+
+	app, _ := apps.Get("shop")
+	for i := range app.Services {
+		if _, err := s.startService(app, &app.Services[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Returns the services whose process was replaced since the last call
+	current := make(map[string]*process.Process)
+	for _, name := range []string{"api", "web", "worker"} {
+		current[name], _ = procs.Get(name + "-shop")
+	}
+	restarted := func() []string {
+		var names []string
+		for _, name := range []string{"api", "web", "worker"} {
+			proc, _ := procs.Get(name + "-shop")
+			if proc != current[name] {
+				names = append(names, name)
+				current[name] = proc
+			}
+		}
+		return names
+	}
+	reload := func() {
+		t.Helper()
+		diff, err := apps.Reload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, change := range diff.Changed {
+			s.applyAppChange(change)
+		}
+	}
+
+	t.Run("cosmetic change restarts nothing", func(t *testing.T) {
+		writeConfig("description: The shop", "a", "sleep 999")
+		reload()
+		if got := restarted(); len(got) != 0 {
+			t.Errorf("expected no restarts, got %v", got)
+		}
+	})
+
+	t.Run("only the changed service restarts", func(t *testing.T) {
+		writeConfig("description: The shop", "a", "sleep 998")
+		reload()
+		if got := strings.Join(restarted(), ","); got != "web" {
+			t.Errorf("expected only web to restart, got %q", got)
+		}
+	})
+
+	t.Run("dependents restart when configured", func(t *testing.T) {
+		writeConfig("restart_dependents: true", "a", "sleep 998")
+		reload()
+		if got := restarted(); len(got) != 0 {
+			t.Errorf("expected no restarts for restart_dependents itself, got %v", got)
+		}
+
+		writeConfig("restart_dependents: true", "b", "sleep 998")
+		reload()
+		if got := strings.Join(restarted(), ","); got != "api,web" {
+			t.Errorf("expected api and its dependent web to restart, got %q", got)
+		}
+		if !strings.Contains(strings.Join(s.requestLog.Lines(), "\n"), "Restarting web-shop (depends on api)") {
+			t.Errorf("expected the restart reason to be logged, got %v", s.requestLog.Lines())
+		}
+	})
+}
+
+// pageRequest returns a GET of a browser loading a page, which is shown the
+// interstitial while the app starts
+func pageRequest(url string) *http.Request {
+	r := httptest.NewRequest("GET", url, nil)
+	r.Header.Set("Accept", "text/html,application/xhtml+xml")
+	r.Header.Set("Sec-Fetch-Mode", "navigate")
+	return r
+}
+
+func TestFixedPortApp(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	cfg := &config.Config{TLD: "test"}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, config.NewAppStore(cfg), procs)
+	app := &config.App{Name: "legacy", Type: config.AppTypeCommand, Command: "sleep 999", Dir: "/tmp", Port: port}
+
+	w := httptest.NewRecorder()
+	s.handleApp(w, pageRequest("http://legacy.test/"), app)
+	if body := w.Body.String(); !strings.Contains(body, fmt.Sprintf("port %d is already in use", port)) {
+		t.Errorf("expected the interstitial to report the port conflict, got %s", body)
+	}
+	if _, found := procs.Get("legacy"); found {
+		t.Error("expected legacy not to be started")
+	}
+}
+
+func TestThemeFileEdits(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	s := newTestServer(cfg, config.NewAppStore(cfg), process.NewManager())
+
+	if err := s.setTheme("dark"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, themeFile), []byte(`{"theme": "light"}`), 0644)
+	if got := s.getTheme(); got != "dark" {
+		t.Errorf("expected the cached theme until the watcher reports the edit, got %q", got)
+	}
+	s.clearTheme()
+	if got := s.getTheme(); got != "light" {
+		t.Errorf("expected the edited theme, got %q", got)
+	}
+}
+
+func TestLimitExceededInterstitial(t *testing.T) {
+	cfg := &config.Config{TLD: "test"}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, config.NewAppStore(cfg), procs)
+	app := &config.App{
+		Name:    "leaky",
+		Type:    config.AppTypeCommand,
+		Command: "while :; do :; done",
+		Dir:     "/tmp",
+		Limits:  limits.Limits{CPUTime: time.Second},
+	}
+
+	s.handleApp(httptest.NewRecorder(), pageRequest("http://leaky.test/"), app)
+	proc, _ := procs.Get("leaky")
+	deadline := time.Now().Add(90 * time.Second)
+	for !proc.HasFailed() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleApp(w, pageRequest("http://leaky.test/"), app)
+	if body := w.Body.String(); !strings.Contains(body, "exceeded cpu time limit of 1s") {
+		t.Errorf("expected the interstitial to name the limit, got %s", body)
+	}
+}
+
+func TestPathRoutes(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"web", "api"} {
+		os.MkdirAll(tmpDir+"/"+dir, 0755)
+		os.WriteFile(tmpDir+"/"+dir+"/users", []byte(dir+" users"), 0644)
+	}
+	os.WriteFile(tmpDir+"/shop.yml", []byte(`
+root: `+tmpDir+`
+services:
+  web:
+    cmd: python3 -m http.server $PORT --bind 127.0.0.1 --directory web
+    default: true
+  api:
+    cmd: python3 -m http.server $PORT --bind 127.0.0.1 --directory api
+routes:
+  - path: /api
+    service: api
+    strip_prefix: true
+`), 0644)
+
+	cfg := &config.Config{TLD: "test", Dir: tmpDir}
+	apps := config.NewAppStore(cfg)
+	if err := apps.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := process.NewManager()
+	defer procs.StopAll()
+	s := newTestServer(cfg, apps, procs)
+	s.requestLog = process.NewLogBuffer(20)
+	app, _ := apps.Get("shop")
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		r := pageRequest(url)
+		if strings.HasSuffix(r.Host, ".ts.net") {
+			s.handleTailscaleRequest(w, r)
+		} else {
+			s.handleApp(w, r, app)
+		}
+		return w.Body.String()
+	}
+
+	// The first request starts the routed service, not the default one
+	get("http://shop.test/api/users")
+	api, found := procs.Get("api-shop")
+	if !found {
+		t.Fatal("expected the api service to be started on demand")
+	}
+	if _, found := procs.Get("web-shop"); found {
+		t.Error("expected the web service to stay idle")
+	}
+	deadline := time.Now().Add(90 * time.Second)
+	for api.IsStarting() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !api.IsRunning() {
+		t.Fatalf("expected api to be running: %s", api.ExitError())
+	}
+
+	if body := get("http://shop.test/api/users"); body != "api users" {
+		t.Errorf("expected /api/users to reach the api service as /users, got %q", body)
+	}
+	if body := get("https://machine.tailnet.ts.net/shop/api/users"); body != "api users" {
+		t.Errorf("expected the route to apply over Tailscale, got %q", body)
+	}
+	get("http://shop.test/apiary")
+	if _, found := procs.Get("web-shop"); !found {
+		t.Error("expected /apiary to fall through to the default service")
+	}
+}
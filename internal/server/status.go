@@ -4,21 +4,47 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/panozzaj/fireup/internal/config"
+	"github.com/panozzaj/fireup/internal/process"
 )
 
 // serviceStatus represents the status of a single service
 type serviceStatus struct {
-	Name     string `json:"name"`
+	Name     string   `json:"name"`
+	Running  bool     `json:"running"`
+	Starting bool     `json:"starting,omitempty"`
+	Failed   bool     `json:"failed,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Uptime   string   `json:"uptime,omitempty"`
+	Default  bool     `json:"default,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	HeldBy   []string `json:"held_by,omitempty"` // Dependents keeping a shared service running
+
+	PortDetected bool `json:"port_detected,omitempty"` // port: auto found it listening elsewhere
+	Paused       bool `json:"paused,omitempty"`        // Stopped with SIGSTOP until the next request
+
+	// Scaled services (scale:) report each of their instances
+	Scale     int              `json:"scale,omitempty"`
+	Balance   string           `json:"balance,omitempty"`
+	Instances []instanceStatus `json:"instances,omitempty"`
+
+	// Stable local port relayed to the service (tcp: listen)
+	TCP int `json:"tcp,omitempty"`
+}
+
+// instanceStatus represents the status of one instance of a scaled service
+type instanceStatus struct {
+	Name     string `json:"name"` // Process name, e.g. web-myapp#2
 	Running  bool   `json:"running"`
 	Starting bool   `json:"starting,omitempty"`
 	Failed   bool   `json:"failed,omitempty"`
 	Error    string `json:"error,omitempty"`
 	Port     int    `json:"port,omitempty"`
 	Uptime   string `json:"uptime,omitempty"`
-	Default  bool   `json:"default,omitempty"`
-	URL      string `json:"url,omitempty"`
+	Paused   bool   `json:"paused,omitempty"`
 }
 
 // appStatus represents the status of an app
@@ -36,6 +62,15 @@ type appStatus struct {
 	Uptime      string          `json:"uptime,omitempty"`
 	Services    []serviceStatus `json:"services,omitempty"`
 	Warnings    []string        `json:"warnings,omitempty"`
+	Profile     string          `json:"profile,omitempty"`  // Active profile (only for apps with profiles)
+	Profiles    []string        `json:"profiles,omitempty"` // Available profiles
+	Group       string          `json:"group,omitempty"`    // Config group directory, e.g. "work"
+	Shared      bool            `json:"shared,omitempty"`   // Started and stopped by its dependents
+	HeldBy      []string        `json:"held_by,omitempty"`  // Dependents keeping a shared app running
+
+	PortDetected bool `json:"port_detected,omitempty"` // port: auto found it listening elsewhere
+	Paused       bool `json:"paused,omitempty"`        // Stopped with SIGSTOP until the next request
+	Inspect      bool `json:"inspect,omitempty"`       // Requests are captured (inspect:)
 }
 
 // reservedTailscalePaths are path prefixes reserved for fireup internal use.
@@ -52,6 +87,39 @@ func isReservedTailscalePath(name string) bool {
 	return false
 }
 
+// instanceStatuses returns the status of the n instances of a scaled
+// service, including those that aren't started
+func (s *Server) instanceStatuses(procName string, n int) []instanceStatus {
+	statuses := make([]instanceStatus, n)
+	for i := range statuses {
+		statuses[i].Name = process.InstanceName(procName, i+1)
+	}
+	for _, proc := range s.procs.Instances(procName) {
+		var is *instanceStatus
+		for i := range statuses {
+			if statuses[i].Name == proc.Name {
+				is = &statuses[i]
+			}
+		}
+		if is == nil {
+			continue // Left from a larger scale and being stopped
+		}
+		if proc.IsRunning() {
+			is.Running = true
+			is.Port = proc.ListenPort()
+			is.Paused = proc.IsPaused()
+			is.Uptime = proc.Uptime().Round(1e9).String()
+		} else if proc.IsStarting() {
+			is.Starting = true
+			is.Port = proc.ListenPort()
+		} else if proc.HasFailed() {
+			is.Failed = true
+			is.Error = proc.ExitError()
+		}
+	}
+	return statuses
+}
+
 // getStatus returns the current status of all apps as JSON
 func (s *Server) getStatus() []byte {
 	var status []appStatus
@@ -59,9 +127,9 @@ func (s *Server) getStatus() []byte {
 	// Build base URL with port if not 80
 	baseURL := func(name string) string {
 		if s.cfg.URLPort == 80 {
-			return fmt.Sprintf("http://%s.%s", name, s.cfg.TLD)
+			return fmt.Sprintf("http://%s.%s", name, s.tld())
 		}
-		return fmt.Sprintf("http://%s.%s:%d", name, s.cfg.TLD, s.cfg.URLPort)
+		return fmt.Sprintf("http://%s.%s:%d", name, s.tld(), s.cfg.URLPort)
 	}
 
 	for _, app := range s.apps.All() {
@@ -73,6 +141,16 @@ func (s *Server) getStatus() []byte {
 			Description: app.Description,
 			Aliases:     app.Aliases,
 			URL:         baseURL(app.Name),
+			Group:       app.Group,
+			Shared:      app.Shared,
+			Inspect:     app.Inspect != nil,
+		}
+		if len(app.Profiles) > 0 {
+			as.Profiles = app.ProfileNames()
+			as.Profile = app.Profile
+			if as.Profile == "" {
+				as.Profile = config.DefaultProfile
+			}
 		}
 
 		// Check for reserved Tailscale path conflicts
@@ -95,14 +173,19 @@ func (s *Server) getStatus() []byte {
 
 		case config.AppTypeCommand:
 			as.Type = "command"
+			as.Port = app.Port // Fixed port, if any
+			as.HeldBy = s.procs.Holders(app.Name)
 			if proc, found := s.procs.Get(app.Name); found {
 				if proc.IsRunning() {
 					as.Running = true
-					as.Port = proc.Port
+					as.Port = proc.ListenPort()
+					as.PortDetected = proc.PortDetected()
+					as.Paused = proc.IsPaused()
 					as.Uptime = proc.Uptime().Round(1e9).String()
+					as.Error = proc.RestartError() // Failed overlap restart
 				} else if proc.IsStarting() {
 					as.Starting = true
-					as.Port = proc.Port
+					as.Port = proc.ListenPort()
 				} else if proc.HasFailed() {
 					as.Failed = true
 					as.Error = proc.ExitError()
@@ -117,24 +200,37 @@ func (s *Server) getStatus() []byte {
 			as.Type = "multi-service"
 			// Keep base URL (app.test) - default service routes there automatically
 			for _, svc := range app.Services {
-				ss := serviceStatus{Name: svc.Name, Default: svc.Default}
+				ss := serviceStatus{Name: svc.Name, Default: svc.Default, TCP: svc.TCPListen}
 				procName := fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name)
+				ss.HeldBy = s.procs.Holders(procName)
+				as.HeldBy = mergeHolders(as.HeldBy, ss.HeldBy)
 				// Set service URL
 				if app.Name == "fireup-tests" {
-					ss.URL = fmt.Sprintf("http://%s.fireup.%s", svc.Name, s.cfg.TLD)
+					ss.URL = fmt.Sprintf("http://%s.fireup.%s", svc.Name, s.tld())
 				} else if svc.Default {
 					ss.URL = baseURL(app.Name)
 				} else {
 					ss.URL = baseURL(fmt.Sprintf("%s-%s", slugify(svc.Name), app.Name))
 				}
-				if proc, found := s.procs.Get(procName); found {
+				if svc.Scale > 1 {
+					ss.Scale = svc.Scale
+					ss.Balance = svc.Balance
+					if ss.Balance == "" {
+						ss.Balance = config.BalanceRoundRobin
+					}
+					ss.Instances = s.instanceStatuses(procName, svc.Scale)
+				}
+				if proc, found := s.getProc(procName); found {
 					if proc.IsRunning() {
 						ss.Running = true
-						ss.Port = proc.Port
+						ss.Port = proc.ListenPort()
+						ss.PortDetected = proc.PortDetected()
+						ss.Paused = proc.IsPaused()
 						ss.Uptime = proc.Uptime().Round(1e9).String()
+						ss.Error = proc.RestartError() // Failed overlap restart
 					} else if proc.IsStarting() {
 						ss.Starting = true
-						ss.Port = proc.Port
+						ss.Port = proc.ListenPort()
 					} else if proc.HasFailed() {
 						ss.Failed = true
 						ss.Error = proc.ExitError()
@@ -151,6 +247,18 @@ func (s *Server) getStatus() []byte {
 	return data
 }
 
+// mergeHolders adds the holders in more to holders, keeping them sorted and unique
+func mergeHolders(holders, more []string) []string {
+	for _, holder := range more {
+		i := sort.SearchStrings(holders, holder)
+		if i < len(holders) && holders[i] == holder {
+			continue
+		}
+		holders = append(holders[:i], append([]string{holder}, holders[i:]...)...)
+	}
+	return holders
+}
+
 // handleAPIStatus returns status of all apps and processes
 func (s *Server) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -213,6 +321,17 @@ func (s *Server) broadcastTheme(theme string) {
 	s.broadcaster.Broadcast(data)
 }
 
+// broadcastNotice sends a notice to all connected dashboard clients.
+// url, if set, is a link the notice should offer (e.g., the dashboard at a new TLD).
+func (s *Server) broadcastNotice(level, message, url string) {
+	notice := map[string]string{"type": "notice", "level": level, "message": message}
+	if url != "" {
+		notice["url"] = url
+	}
+	data, _ := json.Marshal(notice)
+	s.broadcaster.Broadcast(data)
+}
+
 // getStatusJSON returns the current status as JSON bytes
 func (s *Server) getStatusJSON() []byte {
 	// Reuse getStatus since it already does what we need
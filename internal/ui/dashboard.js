@@ -615,6 +615,8 @@ function connectSSE() {
             var data = JSON.parse(event.data)
             if (data.type === 'theme') {
                 applyTheme(data.theme)
+            } else if (data.type === 'notice') {
+                showNotice(data)
             } else if (Array.isArray(data)) {
                 updateApps(data)
             }
@@ -630,6 +632,30 @@ function connectSSE() {
     }
 }
 
+// Show a server notice (e.g. config.json applied or rejected). Info notices
+// fade after a few seconds; errors stay until dismissed.
+var noticeTimer = null
+function showNotice(notice) {
+    var el = document.getElementById('notice')
+    var html = '<span>' + escapeHtml(notice.message) + '</span>'
+    if (notice.url) {
+        html += ' <a href="' + escapeHtml(notice.url) + '">' + escapeHtml(notice.url) + '</a>'
+    }
+    html += '<button class="notice-close" onclick="hideNotice()" title="Dismiss">&times;</button>'
+    el.innerHTML = html
+    el.className = 'notice notice-' + notice.level
+    el.hidden = false
+
+    clearTimeout(noticeTimer)
+    if (notice.level !== 'error') {
+        noticeTimer = setTimeout(hideNotice, 8000)
+    }
+}
+
+function hideNotice() {
+    document.getElementById('notice').hidden = true
+}
+
 // Update apps using morphdom for efficient DOM diffing
 function updateApps(newApps) {
     var container = document.getElementById('apps')
@@ -731,6 +757,14 @@ function updateApps(newApps) {
     }
 }
 
+var statusTooltips = {
+    failed: 'Failed',
+    running: 'Running',
+    paused: 'Paused (resumes on the next request)',
+    starting: 'Starting',
+    idle: 'Idle',
+}
+
 function renderApp(app) {
     var isRunning =
         app.running ||
@@ -750,11 +784,27 @@ function renderApp(app) {
             app.services.some(function (s) {
                 return s.failed
             }))
-    var statusClass = hasFailed ? 'failed' : isRunning ? 'running' : isStarting ? 'starting' : 'idle'
+    var statusClass = hasFailed
+        ? 'failed'
+        : isPaused(app)
+          ? 'paused'
+          : isRunning
+            ? 'running'
+            : isStarting
+              ? 'starting'
+              : 'idle'
     var displayName = app.description || app.name
 
     var getServiceStatus = function (svc) {
-        return svc.failed ? 'failed' : svc.running ? 'running' : svc.starting ? 'starting' : 'idle'
+        return svc.failed
+            ? 'failed'
+            : svc.paused
+              ? 'paused'
+              : svc.running
+                ? 'running'
+                : svc.starting
+                  ? 'starting'
+                  : 'idle'
     }
 
     var servicesHTML = ''
@@ -764,8 +814,7 @@ function renderApp(app) {
             app.services
                 .map(function (svc) {
                     var svcStatus = getServiceStatus(svc)
-                    var svcTooltip =
-                        { failed: 'Failed', running: 'Running', starting: 'Starting', idle: 'Idle' }[svcStatus] || ''
+                    var svcTooltip = statusTooltips[svcStatus] || ''
                     var svcSlug = slugify(svc.name)
                     var svcName = svcSlug + '-' + app.name
                     return (
@@ -785,6 +834,19 @@ function renderApp(app) {
                         '<button onclick="event.stopPropagation(); doRestart(\'' +
                         svcName +
                         '\', event)">Restart</button>' +
+                        '<button onclick="event.stopPropagation(); doPause(\'' +
+                        svcName +
+                        '\')">Pause</button>' +
+                        '<button class="danger" onclick="event.stopPropagation(); doStop(\'' +
+                        svcName +
+                        '\')">Stop</button>' +
+                        '</div>' +
+                        '<div class="status-menu" id="menu-' +
+                        svcName +
+                        '-paused">' +
+                        '<button onclick="event.stopPropagation(); doResume(\'' +
+                        svcName +
+                        '\')">Resume</button>' +
                         '<button class="danger" onclick="event.stopPropagation(); doStop(\'' +
                         svcName +
                         '\')">Stop</button>' +
@@ -803,11 +865,13 @@ function renderApp(app) {
                         '<span class="service-name">' +
                         svc.name +
                         '</span>' +
+                        renderScaleTag(svc) +
+                        renderForwardTag(svc) +
                         (svc.error ? '<span class="app-error">' + svc.error + '</span>' : '') +
                         '</div>' +
                         '<div class="service-meta">' +
                         '<span class="app-port">' +
-                        (svc.port ? ':' + svc.port : '') +
+                        portLabel(svc) +
                         '</span>' +
                         '<span class="app-uptime">' +
                         (svc.uptime || '') +
@@ -818,14 +882,15 @@ function renderApp(app) {
                         svc.url.replace(/^https?:\/\//, '') +
                         '</a>' +
                         '</div>' +
-                        '</div>'
+                        '</div>' +
+                        renderInstances(svc, getServiceStatus)
                     )
                 })
                 .join('') +
             '</div>'
     }
 
-    var statusTooltip = { failed: 'Failed', running: 'Running', starting: 'Starting', idle: 'Idle' }[statusClass] || ''
+    var statusTooltip = statusTooltips[statusClass] || ''
 
     var statusIndicator =
         app.type === 'static'
@@ -844,6 +909,19 @@ function renderApp(app) {
               '<button onclick="event.stopPropagation(); doRestart(\'' +
               app.name +
               '\', event)">Restart</button>' +
+              '<button onclick="event.stopPropagation(); doPause(\'' +
+              app.name +
+              '\')">Pause</button>' +
+              '<button class="danger" onclick="event.stopPropagation(); doStop(\'' +
+              app.name +
+              '\')">Stop</button>' +
+              '</div>' +
+              '<div class="status-menu" id="menu-' +
+              app.name +
+              '-paused">' +
+              '<button onclick="event.stopPropagation(); doResume(\'' +
+              app.name +
+              '\')">Resume</button>' +
               '<button class="danger" onclick="event.stopPropagation(); doStop(\'' +
               app.name +
               '\')">Stop</button>' +
@@ -863,6 +941,8 @@ function renderApp(app) {
     return (
         '<div class="app" data-name="' +
         app.name +
+        '" data-group="' +
+        escapeHtml(app.group || '') +
         '">' +
         '<div class="app-header" onclick="toggleLogs(\'' +
         app.name +
@@ -875,10 +955,14 @@ function renderApp(app) {
         (app.aliases && app.aliases.length
             ? '<span class="app-aliases">aka ' + app.aliases.join(', ') + '</span>'
             : '') +
+        (app.error ? '<span class="app-error">' + escapeHtml(app.error) + '</span>' : '') +
         '</div>' +
         '<div class="app-meta">' +
+        renderGroupTag(app) +
+        renderSharedTag(app) +
+        renderProfileSwitcher(app) +
         '<span class="app-port">' +
-        (app.port ? ':' + app.port : '') +
+        portLabel(app) +
         '</span>' +
         '<span class="app-uptime">' +
         (app.uptime || '') +
@@ -893,10 +977,10 @@ function renderApp(app) {
         app.name +
         '">' +
         '<span class="app-settings-filename">' +
-        app.name +
-        '.yml</span>' +
+        escapeHtml(configFileName(app)) +
+        '</span>' +
         '<button class="app-settings-action" onclick="event.stopPropagation(); copyAppConfigPath(\'' +
-        app.name +
+        escapeHtml(configFileName(app)) +
         '\', event)">' +
         ICONS.copy +
         ' Copy path</button>' +
@@ -964,11 +1048,154 @@ function renderApp(app) {
         '<div class="logs-content" id="logs-content-' +
         app.name +
         '"></div>' +
+        (app.inspect
+            ? '<div class="logs-header requests-header"><span class="logs-title">Requests</span></div>' +
+              '<div class="requests-content" id="requests-content-' +
+              app.name +
+              '"></div>'
+            : '') +
         '</div>' +
         '</div>'
     )
 }
 
+// Config file relative to the config directory, e.g. "work/shop.yml"
+function configFileName(app) {
+    return (app.group ? app.group + '/' : '') + app.name + '.yml'
+}
+
+function renderGroupTag(app) {
+    if (!app.group) return ''
+    return (
+        '<button class="group-tag" title="Show only this group" onclick="event.stopPropagation(); filterByGroup(\'' +
+        escapeHtml(app.group) +
+        '\')">' +
+        escapeHtml(app.group) +
+        '</button>'
+    )
+}
+
+// Port of an app or service; port: auto processes show whether they ignored $PORT
+function portLabel(item) {
+    if (!item.port) return ''
+    return ':' + item.port + (item.port_detected ? ' (detected)' : '')
+}
+
+// Paused apps are frozen until the next request. Multi-service apps count as
+// paused once every running service is.
+function isPaused(app) {
+    if (app.paused) return true
+    var running = (app.services || []).filter(function (s) {
+        return s.running
+    })
+    return (
+        running.length > 0 &&
+        running.every(function (s) {
+            return s.paused
+        })
+    )
+}
+
+// Label for shared apps, listing the dependents that keep them running
+function sharedLabel(app) {
+    if (!app.shared) return ''
+    if (!app.held_by || !app.held_by.length) return 'shared, unused'
+    return 'shared by ' + app.held_by.join(', ')
+}
+
+function renderSharedTag(app) {
+    var label = sharedLabel(app)
+    if (!label) return ''
+    return '<span class="shared-tag" title="' + escapeHtml(label) + '">' + escapeHtml(label) + '</span>'
+}
+
+// Label for scaled services: how many instances run and how requests are
+// balanced across them
+function scaleLabel(svc) {
+    if (!svc.scale) return ''
+    var running = (svc.instances || []).filter(function (i) {
+        return i.running
+    }).length
+    return running + '/' + svc.scale + ' instances, ' + (svc.balance || 'round-robin')
+}
+
+function renderScaleTag(svc) {
+    var label = scaleLabel(svc)
+    if (!label) return ''
+    return '<span class="shared-tag" title="' + escapeHtml(label) + '">' + escapeHtml(label) + '</span>'
+}
+
+// The stable local port relayed to a service, for clients that can't use
+// its hostname (tcp: listen)
+function forwardLabel(svc) {
+    return svc.tcp ? 'tcp :' + svc.tcp : ''
+}
+
+function renderForwardTag(svc) {
+    var label = forwardLabel(svc)
+    if (!label) return ''
+    return '<span class="shared-tag" title="Relayed to this service on 127.0.0.1">' + escapeHtml(label) + '</span>'
+}
+
+// Each instance of a scaled service, with its own status, port and error.
+// Their logs are in the app's logs, prefixed with [service#N].
+function renderInstances(svc, statusOf) {
+    if (!svc.instances || !svc.instances.length) return ''
+    return (
+        '<div class="service-instances">' +
+        svc.instances
+            .map(function (inst) {
+                var status = statusOf(inst)
+                return (
+                    '<div class="service-instance">' +
+                    '<div class="status-dot ' +
+                    status +
+                    '" data-tooltip="' +
+                    (statusTooltips[status] || '') +
+                    '"></div>' +
+                    '<span class="service-name">' +
+                    escapeHtml(inst.name.slice(inst.name.lastIndexOf('#'))) +
+                    '</span>' +
+                    '<span class="app-port">' +
+                    portLabel(inst) +
+                    '</span>' +
+                    '<span class="app-uptime">' +
+                    (inst.uptime || '') +
+                    '</span>' +
+                    (inst.error ? '<span class="app-error">' + escapeHtml(inst.error) + '</span>' : '') +
+                    '</div>'
+                )
+            })
+            .join('') +
+        '</div>'
+    )
+}
+
+function renderProfileSwitcher(app) {
+    if (!app.profiles || !app.profiles.length) return ''
+    var active = app.profile || 'default'
+    return (
+        '<select class="profile-select" title="Profile" onclick="event.stopPropagation()" onchange="setProfile(\'' +
+        app.name +
+        '\', this.value)">' +
+        ['default']
+            .concat(app.profiles)
+            .map(function (p) {
+                return (
+                    '<option value="' +
+                    escapeHtml(p) +
+                    '"' +
+                    (p === active ? ' selected' : '') +
+                    '>' +
+                    escapeHtml(p) +
+                    '</option>'
+                )
+            })
+            .join('') +
+        '</select>'
+    )
+}
+
 function toggleLogs(name) {
     var panel = document.getElementById('logs-' + name)
     var isVisible = panel.classList.contains('visible')
@@ -993,6 +1220,7 @@ function toggleLogs(name) {
                         return s.failed
                     })))
         fetchLogs(name, hasFailed)
+        fetchRequests(name)
     } else {
         expandedLogs = null
     }
@@ -1134,6 +1362,118 @@ function updateLogButtons(name, hasLogs) {
     if (claudeBtn) claudeBtn.classList.toggle('visible', hasLogs)
 }
 
+// Fetch the requests captured for an app with inspect: and show them
+// newest first, keeping expanded ones open
+function fetchRequests(name) {
+    var content = document.getElementById('requests-content-' + name)
+    if (!content) return
+    fetch('/api/requests?name=' + encodeURIComponent(name))
+        .then(function (res) {
+            return res.ok ? res.json() : []
+        })
+        .then(function (exchanges) {
+            if (hasSelectionIn(content)) return
+            var open = {}
+            content.querySelectorAll('details[open]').forEach(function (d) {
+                open[d.dataset.id] = true
+            })
+            var html = exchanges
+                .slice()
+                .reverse()
+                .map(function (e) {
+                    return renderRequest(e, open[e.id])
+                })
+                .join('')
+            content.innerHTML = html || '<div class="requests-empty">No requests yet</div>'
+        })
+        .catch(function (e) {
+            console.error('Failed to fetch requests:', e)
+        })
+}
+
+function renderRequest(e, open) {
+    return (
+        '<details class="request" data-id="' +
+        e.id +
+        '"' +
+        (open ? ' open' : '') +
+        '>' +
+        '<summary>' +
+        '<span class="request-status ' +
+        requestStatusClass(e.status) +
+        '">' +
+        e.status +
+        '</span>' +
+        '<span class="request-method">' +
+        escapeHtml(e.method) +
+        '</span>' +
+        '<span class="request-url">' +
+        escapeHtml(e.url) +
+        '</span>' +
+        (e.replay_of ? '<span class="request-replay-of">replay of #' + e.replay_of + '</span>' : '') +
+        '<span class="request-duration">' +
+        e.duration_ms.toFixed(1) +
+        ' ms</span>' +
+        '</summary>' +
+        (e.error ? '<span class="app-error">' + escapeHtml(e.error) + '</span>' : '') +
+        '<button class="request-replay" onclick="replayRequest(' +
+        e.id +
+        ')" title="Send this request again">Replay</button>' +
+        '<div class="logs-title">Request</div>' +
+        '<pre>' +
+        escapeHtml(requestText(e.request_headers, e.request_body)) +
+        '</pre>' +
+        '<div class="logs-title">Response</div>' +
+        '<pre>' +
+        escapeHtml(requestText(e.response_headers, e.response_body)) +
+        '</pre>' +
+        '</details>'
+    )
+}
+
+// Send a captured request again and show the result with the others
+function replayRequest(id) {
+    fetch('/api/requests/' + id + '/replay', { method: 'POST' })
+        .then(function (res) {
+            if (res.ok) return
+            return res.text().then(function (text) {
+                showNotice({ level: 'error', message: 'Replay failed: ' + text.trim() })
+            })
+        })
+        .then(function () {
+            if (expandedLogs) fetchRequests(expandedLogs)
+        })
+        .catch(function (e) {
+            console.error('Failed to replay request:', e)
+        })
+}
+
+function requestStatusClass(status) {
+    return status >= 500 ? 'failed' : status >= 400 ? 'warning' : 'ok'
+}
+
+// Headers and body of a captured request or response as plain text
+function requestText(headers, body) {
+    var lines = []
+    Object.keys(headers || {})
+        .sort()
+        .forEach(function (name) {
+            headers[name].forEach(function (value) {
+                lines.push(name + ': ' + value)
+            })
+        })
+    if (body && body.size) {
+        lines.push('')
+        if (body.binary) {
+            lines.push('(' + body.size + ' bytes, binary)')
+        } else {
+            lines.push(body.text)
+            if (body.truncated) lines.push('(truncated, ' + body.size + ' bytes in all)')
+        }
+    }
+    return lines.join('\n')
+}
+
 function clearLogs(name) {
     var content = document.getElementById('logs-content-' + name)
     if (content) content.textContent = ''
@@ -1193,8 +1533,8 @@ function toggleAppSettings(name) {
     }
 }
 
-function copyAppConfigPath(name, event) {
-    var path = '~/.config/fireup/' + name + '.yml'
+function copyAppConfigPath(file, event) {
+    var path = '~/.config/fireup/' + file
 
     var textarea = document.createElement('textarea')
     textarea.value = path
@@ -1355,6 +1695,25 @@ function start(name) {
     return fetch('/api/start?name=' + encodeURIComponent(name))
 }
 
+function pause(name) {
+    return fetch('/api/pause?name=' + encodeURIComponent(name))
+}
+
+function resume(name) {
+    return fetch('/api/resume?name=' + encodeURIComponent(name))
+}
+
+function setProfile(name, profile) {
+    var url = '/api/profile?name=' + encodeURIComponent(name) + '&profile=' + encodeURIComponent(profile)
+    return fetch(url, { method: 'POST' }).then(function (res) {
+        if (!res.ok) {
+            return res.text().then(function (msg) {
+                alert('Failed to switch profile: ' + msg)
+            })
+        }
+    })
+}
+
 function closeAllMenus() {
     document.querySelectorAll('.status-menu').forEach(function (m) {
         m.classList.remove('visible')
@@ -1369,9 +1728,13 @@ function handleDotClick(name, event) {
     var isRunning = dot.classList.contains('running')
     var isStarting = dot.classList.contains('starting')
     var isFailed = dot.classList.contains('failed')
+    var isPausedDot = dot.classList.contains('paused')
     var menu
 
-    if (isRunning || isStarting) {
+    if (isPausedDot) {
+        menu = document.getElementById('menu-' + name + '-paused')
+        if (menu) menu.classList.add('visible')
+    } else if (isRunning || isStarting) {
         menu = document.getElementById('menu-' + name + '-active')
         if (menu) menu.classList.add('visible')
     } else if (isFailed) {
@@ -1396,6 +1759,16 @@ function doStop(name) {
     return stop(name)
 }
 
+function doPause(name) {
+    closeAllMenus()
+    return pause(name)
+}
+
+function doResume(name) {
+    closeAllMenus()
+    return resume(name)
+}
+
 function doClear(name) {
     closeAllMenus()
     // Stop clears the failed state and turns it grey
@@ -1408,6 +1781,7 @@ document.addEventListener('click', closeAllMenus)
 setInterval(function () {
     if (expandedLogs) {
         fetchLogs(expandedLogs)
+        fetchRequests(expandedLogs)
     }
 }, 2000)
 
@@ -1463,8 +1837,34 @@ function matchesFilter(text, normalizedQuery) {
     return normalizeForSearch(text).indexOf(normalizedQuery) !== -1
 }
 
+// Split a filter like "group:work api" into the group and the search text
+function parseFilter(filter) {
+    var group = ''
+    var words = filter.split(/\s+/).filter(function (word) {
+        if (word.indexOf('group:') === 0) {
+            group = word.slice('group:'.length).replace(/^\/+|\/+$/g, '')
+            return false
+        }
+        return word !== ''
+    })
+    return { group: group, text: words.join(' ') }
+}
+
+// Check if an app's group is the filter group or one of its subgroups
+function inGroup(appGroup, group) {
+    appGroup = appGroup.toLowerCase()
+    return !group || appGroup === group || appGroup.indexOf(group + '/') === 0
+}
+
+function filterByGroup(group) {
+    filterInput.value = 'group:' + group
+    filterInput.dispatchEvent(new Event('input'))
+    filterBar.classList.add('active')
+}
+
 function applyFilter() {
-    var normalizedQuery = normalizeForSearch(currentFilter)
+    var filter = parseFilter(currentFilter)
+    var normalizedQuery = normalizeForSearch(filter.text)
     var apps = document.querySelectorAll('.app')
 
     apps.forEach(function (appEl) {
@@ -1480,7 +1880,7 @@ function applyFilter() {
 
         // Check all fields for match
         var isMatch =
-            !currentFilter ||
+            !filter.text ||
             matchesFilter(name, normalizedQuery) ||
             matchesFilter(displayName, normalizedQuery) ||
             matchesFilter(aliases, normalizedQuery)
@@ -1499,22 +1899,24 @@ function applyFilter() {
             }
         })
 
+        isMatch = isMatch && inGroup(appEl.getAttribute('data-group') || '', filter.group)
+
         // Update classes
         appEl.classList.toggle('filter-match', isMatch && currentFilter)
         appEl.classList.toggle('filter-mismatch', !isMatch && currentFilter)
 
         // Highlight matching text in visible elements
         if (appNameEl) {
-            appNameEl.innerHTML = highlightMatch(displayName, currentFilter)
+            appNameEl.innerHTML = highlightMatch(displayName, filter.text)
         }
         if (aliasesEl) {
-            aliasesEl.innerHTML = highlightMatch(aliasesEl.textContent, currentFilter)
+            aliasesEl.innerHTML = highlightMatch(aliasesEl.textContent, filter.text)
         }
         urlEls.forEach(function (urlEl) {
-            urlEl.innerHTML = highlightMatch(urlEl.textContent, currentFilter)
+            urlEl.innerHTML = highlightMatch(urlEl.textContent, filter.text)
         })
         serviceNames.forEach(function (svc) {
-            svc.innerHTML = highlightMatch(svc.textContent, currentFilter)
+            svc.innerHTML = highlightMatch(svc.textContent, filter.text)
         })
     })
 }
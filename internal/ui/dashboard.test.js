@@ -35,6 +35,84 @@ function matchesFilter(text, normalizedQuery) {
     return normalizeForSearch(text).indexOf(normalizedQuery) !== -1
 }
 
+function parseFilter(filter) {
+    var group = ''
+    var words = filter.split(/\s+/).filter(function (word) {
+        if (word.indexOf('group:') === 0) {
+            group = word.slice('group:'.length).replace(/^\/+|\/+$/g, '')
+            return false
+        }
+        return word !== ''
+    })
+    return { group: group, text: words.join(' ') }
+}
+
+function inGroup(appGroup, group) {
+    appGroup = appGroup.toLowerCase()
+    return !group || appGroup === group || appGroup.indexOf(group + '/') === 0
+}
+
+function isPaused(app) {
+    if (app.paused) return true
+    var running = (app.services || []).filter(function (s) {
+        return s.running
+    })
+    return (
+        running.length > 0 &&
+        running.every(function (s) {
+            return s.paused
+        })
+    )
+}
+
+function sharedLabel(app) {
+    if (!app.shared) return ''
+    if (!app.held_by || !app.held_by.length) return 'shared, unused'
+    return 'shared by ' + app.held_by.join(', ')
+}
+
+function portLabel(item) {
+    if (!item.port) return ''
+    return ':' + item.port + (item.port_detected ? ' (detected)' : '')
+}
+
+function scaleLabel(svc) {
+    if (!svc.scale) return ''
+    var running = (svc.instances || []).filter(function (i) {
+        return i.running
+    }).length
+    return running + '/' + svc.scale + ' instances, ' + (svc.balance || 'round-robin')
+}
+
+function forwardLabel(svc) {
+    return svc.tcp ? 'tcp :' + svc.tcp : ''
+}
+
+function requestStatusClass(status) {
+    return status >= 500 ? 'failed' : status >= 400 ? 'warning' : 'ok'
+}
+
+function requestText(headers, body) {
+    var lines = []
+    Object.keys(headers || {})
+        .sort()
+        .forEach(function (name) {
+            headers[name].forEach(function (value) {
+                lines.push(name + ': ' + value)
+            })
+        })
+    if (body && body.size) {
+        lines.push('')
+        if (body.binary) {
+            lines.push('(' + body.size + ' bytes, binary)')
+        } else {
+            lines.push(body.text)
+            if (body.truncated) lines.push('(truncated, ' + body.size + ' bytes in all)')
+        }
+    }
+    return lines.join('\n')
+}
+
 // Tests for normalizeForSearch
 console.log('\n=== normalizeForSearch ===')
 assertEqual(normalizeForSearch('hello'), 'hello', 'lowercase passthrough')
@@ -73,6 +151,85 @@ assert(matchesFilter('android-assistant', normalizeForSearch('android_assist')),
 assert(matchesFilter('my-cool-app', normalizeForSearch('my cool')), 'query: "my cool"')
 assert(matchesFilter('foo_bar_service', normalizeForSearch('bar service')), 'query: "bar service"')
 
+// Tests for group filters
+console.log('\n=== parseFilter / inGroup ===')
+assertEqual(parseFilter('api').group, '', 'no group by default')
+assertEqual(parseFilter('group:work').group, 'work', 'group only')
+assertEqual(parseFilter('group:work').text, '', 'group only has no text')
+assertEqual(parseFilter('group:work/ api web').text, 'api web', 'text around a group')
+assertEqual(parseFilter('group:work/ api').group, 'work', 'trailing slash is ignored')
+assert(inGroup('work', 'work'), 'same group matches')
+assert(inGroup('work/clients', 'work'), 'subgroup matches')
+assert(inGroup('Work', 'work'), 'group match ignores case')
+assert(!inGroup('workshop', 'work'), 'group prefix without slash does not match')
+assert(!inGroup('', 'work'), 'top-level app is not in a group')
+assert(inGroup('', ''), 'no group filter matches everything')
+
+// Tests for shared apps
+console.log('\n=== sharedLabel ===')
+assertEqual(sharedLabel({ name: 'blog' }), '', 'not shared')
+assertEqual(sharedLabel({ name: 'redis', shared: true }), 'shared, unused', 'shared without holders')
+assertEqual(
+    sharedLabel({ name: 'redis', shared: true, held_by: ['storefront', 'web-admin'] }),
+    'shared by storefront, web-admin',
+    'lists holders'
+)
+
+// Tests for port labels
+console.log('\n=== portLabel ===')
+assertEqual(portLabel({ name: 'blog' }), '', 'no port')
+assertEqual(portLabel({ name: 'blog', port: 4001 }), ':4001', 'assigned port')
+assertEqual(portLabel({ name: 'vite', port: 5173, port_detected: true }), ':5173 (detected)', 'detected port')
+
+console.log('\n=== scaleLabel ===')
+assertEqual(scaleLabel({ name: 'web' }), '', 'not scaled')
+assertEqual(
+    scaleLabel({
+        name: 'web',
+        scale: 3,
+        balance: 'sticky',
+        instances: [{ running: true }, { running: true }, { failed: true }],
+    }),
+    '2/3 instances, sticky',
+    'counts running instances'
+)
+assertEqual(scaleLabel({ name: 'web', scale: 2 }), '0/2 instances, round-robin', 'not started')
+
+console.log('\n=== forwardLabel ===')
+assertEqual(forwardLabel({ name: 'web' }), '', 'no ports')
+assertEqual(forwardLabel({ name: 'db', tcp: 5433 }), 'tcp :5433', 'tcp port')
+
+console.log('\n=== isPaused ===')
+assertEqual(isPaused({ name: 'blog', running: true }), false, 'running app')
+assertEqual(isPaused({ name: 'blog', running: true, paused: true }), true, 'paused app')
+assertEqual(isPaused({ name: 'shop', services: [] }), false, 'no services')
+assertEqual(
+    isPaused({ name: 'shop', services: [{ running: true, paused: true }, { running: false }] }),
+    true,
+    'every running service paused'
+)
+assertEqual(
+    isPaused({ name: 'shop', services: [{ running: true, paused: true }, { running: true }] }),
+    false,
+    'one service still running'
+)
+
+console.log('\n=== requests ===')
+assertEqual(requestStatusClass(201), 'ok', 'success status')
+assertEqual(requestStatusClass(404), 'warning', 'client error status')
+assertEqual(requestStatusClass(502), 'failed', 'server error status')
+assertEqual(
+    requestText({ Accept: ['*/*'], 'Set-Cookie': ['a', 'b'] }, { size: 0 }),
+    'Accept: */*\nSet-Cookie: a\nSet-Cookie: b',
+    'headers only'
+)
+assertEqual(
+    requestText({ 'Content-Type': ['application/json'] }, { text: '{"id":1}', size: 20, truncated: true }),
+    'Content-Type: application/json\n\n{"id":1}\n(truncated, 20 bytes in all)',
+    'truncated body'
+)
+assertEqual(requestText(null, { size: 512, binary: true }), '\n(512 bytes, binary)', 'binary body')
+
 // Summary
 console.log('\n=== Summary ===')
 console.log('Passed:', passed)
@@ -84,6 +84,7 @@ const dashboardHTML = `<!DOCTYPE html>
                 </button>
             </div>
         </header>
+        <div class="notice" id="notice" hidden></div>
         <main id="apps"></main>
     </div>
 